@@ -0,0 +1,391 @@
+// Package archive moves the whole database of a Scout between servers as a single file.
+//
+// An archive is a zip file holding a JSON array of the rows of each table under tables/, e.g. tables/players.json,
+// and a manifest.json that describes the archive. The manifest records the FormatVersion of the archive, the
+// database.SchemaVersion of the rows, and the size and SHA-256 checksum of every other file. Rows keep their IDs, so
+// the links between them survive the move. Files that aren't rows, such as photos, belong under attachments/, but no
+// model has attachments yet.
+//
+// The secrets of the Scout, the key that signs share links and the token of the calendar feed, are left out, so that
+// an archive can't be used to forge share links or read the feed. New ones are made when they are next needed, which
+// stops the URLs of earlier share links and of the feed from working on the server the archive is imported to.
+package archive
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+// FormatVersion is the version of the archive layout written by Export. It is increased whenever archives change in
+// a way that older versions of Import can't read.
+const FormatVersion = 1
+
+const (
+	manifestName = "manifest.json"
+	tablesDir    = "tables/"
+	// batchSize is how many rows are read or written at a time.
+	batchSize = 500
+)
+
+var (
+	// ErrInvalidArchive is wrapped by errors about files that aren't intact archives.
+	ErrInvalidArchive = errors.New("invalid archive")
+	// ErrIncompatible is wrapped by errors about archives written by a version of the app that this one can't read.
+	ErrIncompatible = errors.New("incompatible archive")
+)
+
+// Manifest describes an archive.
+type Manifest struct {
+	FormatVersion int `json:"formatVersion"`
+	// SchemaVersion is the database.SchemaVersion of the server that wrote the archive.
+	SchemaVersion int       `json:"schemaVersion"`
+	Created       time.Time `json:"created"`
+	// Files lists every file in the archive except the manifest, in the order they were written.
+	Files []File `json:"files"`
+}
+
+// File describes one file in an archive.
+type File struct {
+	Name string `json:"name"`
+	// Table is the database table whose rows the file holds. It is empty for attachments.
+	Table string `json:"table,omitempty"`
+	// Rows is the number of rows in a table file.
+	Rows   int    `json:"rows"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Rows is the total number of rows in the archive.
+func (m *Manifest) Rows() int {
+	rows := 0
+	for _, f := range m.Files {
+		rows += f.Rows
+	}
+	return rows
+}
+
+// table is a model of the schema along with the name of its table.
+type table struct {
+	name  string
+	model any
+}
+
+// tables returns every model in the schema, in database.Models order.
+func tables(db *gorm.DB) ([]table, error) {
+	var tables []table
+	for _, model := range database.Models() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("reading the table of %T failed: %w", model, err)
+		}
+		tables = append(tables, table{name: stmt.Schema.Table, model: model})
+	}
+	return tables, nil
+}
+
+// newRows returns a pointer to an empty slice of pointers to the model, e.g. *[]*database.Player.
+func (t table) newRows() reflect.Value {
+	return reflect.New(reflect.SliceOf(reflect.TypeOf(t.model)))
+}
+
+// withoutSecrets clears the secrets of a row, if it has any.
+func withoutSecrets(row any) {
+	if scout, ok := row.(*database.Scout); ok {
+		scout.ShareKey, scout.CalendarToken = nil, ""
+	}
+}
+
+// countingHash sums and counts everything written to it.
+type countingHash struct {
+	hash.Hash
+	size int64
+}
+
+func (h *countingHash) Write(p []byte) (int, error) {
+	h.size += int64(len(p))
+	return h.Hash.Write(p)
+}
+
+// Export writes every row of every table in db, including soft-deleted rows, as an archive. Rows are read and written
+// in batches, so the archive can be streamed. It returns the manifest of the archive.
+func Export(w io.Writer, db *gorm.DB, now time.Time) (*Manifest, error) {
+	tables, err := tables(db)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{FormatVersion: FormatVersion, SchemaVersion: database.SchemaVersion, Created: now.UTC()}
+	zw := zip.NewWriter(w)
+	for _, t := range tables {
+		file, err := exportTable(zw, db, t, now)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: manifestName, Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return nil, fmt.Errorf("writing archive manifest failed: %w", err)
+	}
+	encoder := json.NewEncoder(mw)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return nil, fmt.Errorf("writing archive manifest failed: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("writing archive failed: %w", err)
+	}
+	return manifest, nil
+}
+
+// exportTable writes the rows of a table as a JSON array, one row per line.
+func exportTable(zw *zip.Writer, db *gorm.DB, t table, now time.Time) (File, error) {
+	file := File{Name: tablesDir + t.name + ".json", Table: t.name}
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: file.Name, Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return file, fmt.Errorf("writing table %s failed: %w", t.name, err)
+	}
+	sum := &countingHash{Hash: sha256.New()}
+	w := io.MultiWriter(fw, sum)
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return file, fmt.Errorf("writing table %s failed: %w", t.name, err)
+	}
+	for offset := 0; ; offset += batchSize {
+		rows := t.newRows()
+		if err := db.Unscoped().Order("id").Limit(batchSize).Offset(offset).Find(rows.Interface()).Error; err != nil {
+			return file, fmt.Errorf("retrieving rows of table %s from %d failed: %w", t.name, offset, err)
+		}
+		for i := 0; i < rows.Elem().Len(); i++ {
+			withoutSecrets(rows.Elem().Index(i).Interface())
+			row, err := json.Marshal(rows.Elem().Index(i).Interface())
+			if err != nil {
+				return file, fmt.Errorf("encoding row of table %s failed: %w", t.name, err)
+			}
+			separator := ",\n"
+			if file.Rows == 0 {
+				separator = "\n"
+			}
+			if _, err := io.WriteString(w, separator); err != nil {
+				return file, fmt.Errorf("writing table %s failed: %w", t.name, err)
+			}
+			if _, err := w.Write(row); err != nil {
+				return file, fmt.Errorf("writing table %s failed: %w", t.name, err)
+			}
+			file.Rows++
+		}
+		if rows.Elem().Len() < batchSize {
+			break
+		}
+	}
+	if _, err := io.WriteString(w, "\n]\n"); err != nil {
+		return file, fmt.Errorf("writing table %s failed: %w", t.name, err)
+	}
+	file.Size, file.SHA256 = sum.size, hex.EncodeToString(sum.Sum(nil))
+	return file, nil
+}
+
+// readManifest reads the manifest of an archive and checks that this version of the app can import it.
+func readManifest(zr *zip.Reader) (*Manifest, error) {
+	f, err := zr.Open(manifestName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, manifestName)
+	}
+	defer f.Close()
+	var manifest Manifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: reading %s failed: %v", ErrInvalidArchive, manifestName, err)
+	}
+	if manifest.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("%w: archive format %d is not supported, only format %d is", ErrIncompatible, manifest.FormatVersion, FormatVersion)
+	}
+	// Older schemas are read as the current models, where fields that didn't exist yet keep their zero value.
+	if manifest.SchemaVersion < 1 {
+		return nil, fmt.Errorf("%w: %s has no schema version", ErrInvalidArchive, manifestName)
+	}
+	if manifest.SchemaVersion > database.SchemaVersion {
+		return nil, fmt.Errorf("%w: schema version %d is newer than this server's version %d", ErrIncompatible, manifest.SchemaVersion, database.SchemaVersion)
+	}
+	return &manifest, nil
+}
+
+// verify checks that the archive holds exactly the files listed in the manifest, intact, and that every table file
+// is of a known table. It returns the files keyed by table name.
+func verify(zr *zip.Reader, manifest *Manifest, tables []table) (map[string]*zip.File, error) {
+	known := map[string]bool{}
+	for _, t := range tables {
+		known[t.name] = true
+	}
+	entries := map[string]*zip.File{}
+	for _, f := range zr.File {
+		if f.Name == manifestName || strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		entries[f.Name] = f
+	}
+
+	byTable := map[string]*zip.File{}
+	for _, file := range manifest.Files {
+		entry, ok := entries[file.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, file.Name)
+		}
+		delete(entries, file.Name)
+		switch {
+		case file.Table == "":
+			return nil, fmt.Errorf("%w: attachment %s can't be imported, as this version has no models with attachments", ErrIncompatible, file.Name)
+		case !known[file.Table]:
+			return nil, fmt.Errorf("%w: unknown table %s", ErrIncompatible, file.Table)
+		case byTable[file.Table] != nil:
+			return nil, fmt.Errorf("%w: table %s is listed twice", ErrInvalidArchive, file.Table)
+		case path.Dir(file.Name)+"/" != tablesDir:
+			return nil, fmt.Errorf("%w: table %s is outside of %s", ErrInvalidArchive, file.Table, tablesDir)
+		}
+		if err := checkFile(entry, file); err != nil {
+			return nil, err
+		}
+		byTable[file.Table] = entry
+	}
+	if len(entries) > 0 {
+		var unlisted []string
+		for name := range entries {
+			unlisted = append(unlisted, name)
+		}
+		sort.Strings(unlisted)
+		return nil, fmt.Errorf("%w: files not listed in the manifest: %s", ErrInvalidArchive, strings.Join(unlisted, ", "))
+	}
+	return byTable, nil
+}
+
+// checkFile compares the size and checksum of a file in the archive with those in the manifest.
+func checkFile(entry *zip.File, file File) error {
+	r, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("%w: opening %s failed: %v", ErrInvalidArchive, file.Name, err)
+	}
+	defer r.Close()
+	sum := &countingHash{Hash: sha256.New()}
+	if _, err := io.Copy(sum, r); err != nil {
+		return fmt.Errorf("%w: reading %s failed: %v", ErrInvalidArchive, file.Name, err)
+	}
+	if sum.size != file.Size || hex.EncodeToString(sum.Sum(nil)) != file.SHA256 {
+		return fmt.Errorf("%w: %s doesn't match its checksum", ErrInvalidArchive, file.Name)
+	}
+	return nil
+}
+
+// Import replaces every row of every table in db with the rows in an archive, keeping their IDs. Tables missing from
+// the archive, e.g. because they were added after it was written, are left empty. The archive is checked in full
+// before anything is changed, and nothing is changed if it can't be imported. It returns the manifest of the archive.
+func Import(db *gorm.DB, r io.ReaderAt, size int64) (*Manifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	manifest, err := readManifest(zr)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := tables(db)
+	if err != nil {
+		return nil, err
+	}
+	files, err := verify(zr, manifest, tables)
+	if err != nil {
+		return nil, err
+	}
+	rows := map[string]int{}
+	for _, f := range manifest.Files {
+		rows[f.Table] = f.Rows
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range tables {
+			if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(t.model).Error; err != nil {
+				return fmt.Errorf("clearing table %s failed: %w", t.name, err)
+			}
+		}
+		// The IDs in the archive are kept, rather than generated by BaseModel.BeforeCreate.
+		tx = tx.Session(&gorm.Session{SkipHooks: true})
+		for _, t := range tables {
+			file, ok := files[t.name]
+			if !ok {
+				continue
+			}
+			imported, err := importTable(tx, t, file)
+			if err != nil {
+				return err
+			}
+			if imported != rows[t.name] {
+				return fmt.Errorf("%w: table %s has %d rows, but the manifest lists %d", ErrInvalidArchive, t.name, imported, rows[t.name])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// importTable creates the rows in a table file, in batches, and returns how many there were.
+func importTable(tx *gorm.DB, t table, entry *zip.File) (int, error) {
+	r, err := entry.Open()
+	if err != nil {
+		return 0, fmt.Errorf("%w: opening %s failed: %v", ErrInvalidArchive, entry.Name, err)
+	}
+	defer r.Close()
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return 0, fmt.Errorf("%w: %s is not a JSON array", ErrInvalidArchive, entry.Name)
+	}
+
+	imported := 0
+	rows := t.newRows()
+	create := func() error {
+		if rows.Elem().Len() == 0 {
+			return nil
+		}
+		if err := tx.Create(rows.Interface()).Error; err != nil {
+			return fmt.Errorf("importing rows of table %s failed: %w", t.name, err)
+		}
+		imported += rows.Elem().Len()
+		rows = t.newRows()
+		return nil
+	}
+	for decoder.More() {
+		row := reflect.New(reflect.TypeOf(t.model).Elem())
+		if err := decoder.Decode(row.Interface()); err != nil {
+			return 0, fmt.Errorf("%w: reading row %d of %s failed: %v", ErrInvalidArchive, imported+rows.Elem().Len()+1, entry.Name, err)
+		}
+		// Archives written before secrets were left out may still hold them.
+		withoutSecrets(row.Interface())
+		rows.Elem().Set(reflect.Append(rows.Elem(), row))
+		if rows.Elem().Len() == batchSize {
+			if err := create(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := create(); err != nil {
+		return 0, err
+	}
+	if _, err := decoder.Token(); err != nil {
+		return 0, fmt.Errorf("%w: %s is not a JSON array: %v", ErrInvalidArchive, entry.Name, err)
+	}
+	return imported, nil
+}
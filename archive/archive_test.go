@@ -0,0 +1,274 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+func create(t *testing.T, db *gorm.DB, row any) {
+	t.Helper()
+	if err := db.Create(row).Error; err != nil {
+		t.Fatalf("Failed to create %T: %v", row, err)
+	}
+}
+
+func export(t *testing.T, db *gorm.DB) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if _, err := Export(&b, db, time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Export() failed: %v", err)
+	}
+	return b.Bytes()
+}
+
+// rewrite copies an archive, changing the contents of the files in changes.
+func rewrite(t *testing.T, archive []byte, changes map[string]func([]byte) []byte) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		contents, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", f.Name, err)
+		}
+		if change, ok := changes[f.Name]; ok {
+			contents = change(contents)
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatalf("Failed to write %s: %v", f.Name, err)
+		}
+		w.Write(contents)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return b.Bytes()
+}
+
+// changeManifest returns a change that edits the decoded manifest.
+func changeManifest(t *testing.T, edit func(*Manifest)) func([]byte) []byte {
+	return func(contents []byte) []byte {
+		var m Manifest
+		if err := json.Unmarshal(contents, &m); err != nil {
+			t.Fatalf("Failed to read manifest: %v", err)
+		}
+		edit(&m)
+		contents, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("Failed to write manifest: %v", err)
+		}
+		return contents
+	}
+}
+
+func TestExportLeavesOutSecrets(t *testing.T) {
+	source := database.CreateTestDB(t)
+	create(t, source, &database.Scout{Username: "leaving", ShareKey: []byte("share key"), CalendarToken: "calendar token"})
+	archive := export(t, source)
+	if bytes.Contains(archive, []byte("calendar token")) {
+		t.Errorf("Expected the calendar token to be left out of the archive")
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	f, err := zr.Open(tablesDir + "scouts.json")
+	if err != nil {
+		t.Fatalf("Failed to open scouts.json: %v", err)
+	}
+	defer f.Close()
+	var scouts []*database.Scout
+	if err := json.NewDecoder(f).Decode(&scouts); err != nil {
+		t.Fatalf("Failed to read scouts.json: %v", err)
+	}
+	if len(scouts) != 1 || scouts[0].Username != "leaving" || len(scouts[0].ShareKey) != 0 || scouts[0].CalendarToken != "" {
+		t.Errorf("Expected the Scout without secrets, got %+v", scouts)
+	}
+
+	// Secrets in archives written before they were left out aren't imported.
+	archive = rewrite(t, archive, map[string]func([]byte) []byte{
+		tablesDir + "scouts.json": func(contents []byte) []byte {
+			return bytes.Replace(contents, []byte(`"CalendarToken":""`), []byte(`"CalendarToken":"calendar token"`), 1)
+		},
+	})
+	archive = rewrite(t, archive, map[string]func([]byte) []byte{manifestName: changeManifest(t, func(m *Manifest) {
+		for i, file := range m.Files {
+			if file.Table == "scouts" {
+				contents := readFile(t, archive, file.Name)
+				sum := sha256.Sum256(contents)
+				m.Files[i].Size, m.Files[i].SHA256 = int64(len(contents)), hex.EncodeToString(sum[:])
+			}
+		}
+	})})
+	target := database.CreateTestDB(t)
+	if _, err := Import(target, bytes.NewReader(archive), int64(len(archive))); err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	scout, err := database.LocalScout(target)
+	if err != nil {
+		t.Fatalf("LocalScout() failed: %v", err)
+	}
+	if scout.Username != "leaving" || scout.CalendarToken != "" {
+		t.Errorf("Expected the Scout without secrets, got %+v", scout)
+	}
+}
+
+// readFile returns the contents of a file in an archive.
+func readFile(t *testing.T, archive []byte, name string) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", name, err)
+	}
+	defer f.Close()
+	contents, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return contents
+}
+
+func TestExportImport(t *testing.T) {
+	source := database.CreateTestDB(t)
+	create(t, source, &database.Scout{Username: "leaving", Language: "es"})
+	player := &database.Player{Name: "Pelé"}
+	create(t, source, player)
+	create(t, source, &database.PlayerAnalysis{PlayerID: player.ID, Position: database.Forward, Telephone: "+55 1234"})
+	tactical := &database.TacticalAnalysis{Vision: 9, Awareness: 8, MovementOffTheBall: database.Unrated}
+	create(t, source, tactical)
+	analysis := &database.Analysis{PlayerID: player.ID, Date: "2024-05-01 15:00", TacticalAnalysisID: tactical.ID, Notes: "Great."}
+	create(t, source, analysis)
+	deleted := &database.Player{Name: "Deleted"}
+	create(t, source, deleted)
+	if err := source.Delete(deleted).Error; err != nil {
+		t.Fatalf("Failed to delete Player: %v", err)
+	}
+
+	archive := export(t, source)
+
+	target := database.CreateTestDB(t)
+	create(t, target, &database.Scout{Username: "replaced"})
+	create(t, target, &database.Player{Name: "Replaced"})
+	manifest, err := Import(target, bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if manifest.FormatVersion != FormatVersion || manifest.SchemaVersion != database.SchemaVersion {
+		t.Errorf("Unexpected versions in manifest %+v", manifest)
+	}
+	if len(manifest.Files) != len(database.Models()) {
+		t.Errorf("Expected a file per table, got %+v", manifest.Files)
+	}
+
+	players, err := database.AllPlayers(target)
+	if err != nil {
+		t.Fatalf("AllPlayers() failed: %v", err)
+	}
+	if len(players) != 1 || players[0].ID != player.ID || players[0].Name != "Pelé" {
+		t.Errorf("Expected only the archived player with its ID, got %+v", players)
+	}
+	var all []*database.Player
+	if err := target.Unscoped().Find(&all).Error; err != nil {
+		t.Fatalf("Failed to find Players: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected the deleted player to be kept, got %d players", len(all))
+	}
+	analyses, err := database.AnalysesForPlayer(target, player.ID)
+	if err != nil {
+		t.Fatalf("AnalysesForPlayer() failed: %v", err)
+	}
+	if len(analyses) != 1 || analyses[0].ID != analysis.ID || analyses[0].Notes != "Great." {
+		t.Fatalf("Expected the archived analysis, got %+v", analyses)
+	}
+	if r, ok := analyses[0].Rating(database.Attribute{Group: database.TacticalRatings, Name: "Vision"}); !ok || r != 9 {
+		t.Errorf("Expected the analysis to keep its link to its ratings, got vision %d", r)
+	}
+	scout, err := database.LocalScout(target)
+	if err != nil {
+		t.Fatalf("LocalScout() failed: %v", err)
+	}
+	if scout.Username != "leaving" || scout.Language != "es" {
+		t.Errorf("Expected the archived Scout, got %+v", scout)
+	}
+
+	// Exporting the imported database gives the same rows.
+	again := export(t, target)
+	manifestAgain, err := Import(database.CreateTestDB(t), bytes.NewReader(again), int64(len(again)))
+	if err != nil {
+		t.Fatalf("Import() of re-export failed: %v", err)
+	}
+	for i, f := range manifestAgain.Files {
+		if f.SHA256 != manifest.Files[i].SHA256 {
+			t.Errorf("Expected re-exporting %s to give the same rows", f.Name)
+		}
+	}
+}
+
+func TestImportRefusesArchives(t *testing.T) {
+	source := database.CreateTestDB(t)
+	create(t, source, &database.Player{Name: "Archived"})
+	archive := export(t, source)
+
+	for _, test := range []struct {
+		name    string
+		archive []byte
+		want    error
+	}{
+		{"not a zip", []byte("players.csv"), ErrInvalidArchive},
+		{"tampered", rewrite(t, archive, map[string]func([]byte) []byte{
+			"tables/players.json": func(b []byte) []byte { return bytes.Replace(b, []byte("Archived"), []byte("Tampered"), 1) },
+		}), ErrInvalidArchive},
+		{"newer format", rewrite(t, archive, map[string]func([]byte) []byte{
+			manifestName: changeManifest(t, func(m *Manifest) { m.FormatVersion = FormatVersion + 1 }),
+		}), ErrIncompatible},
+		{"newer schema", rewrite(t, archive, map[string]func([]byte) []byte{
+			manifestName: changeManifest(t, func(m *Manifest) { m.SchemaVersion = database.SchemaVersion + 1 }),
+		}), ErrIncompatible},
+		{"unknown table", rewrite(t, archive, map[string]func([]byte) []byte{
+			manifestName: changeManifest(t, func(m *Manifest) { m.Files[0].Table = "clubs" }),
+		}), ErrIncompatible},
+		{"missing file", rewrite(t, archive, map[string]func([]byte) []byte{
+			manifestName: changeManifest(t, func(m *Manifest) { m.Files[0].Name = "tables/missing.json" }),
+		}), ErrInvalidArchive},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			target := database.CreateTestDB(t)
+			create(t, target, &database.Player{Name: "Kept"})
+			_, err := Import(target, bytes.NewReader(test.archive), int64(len(test.archive)))
+			if !errors.Is(err, test.want) {
+				t.Fatalf("Import() returned %v, expected %v", err, test.want)
+			}
+			players, err := database.AllPlayers(target)
+			if err != nil {
+				t.Fatalf("AllPlayers() failed: %v", err)
+			}
+			if len(players) != 1 || players[0].Name != "Kept" {
+				t.Errorf("Expected a refused import to change nothing, got %+v", players)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Package calendar converts scouting events to and from iCalendar (RFC 5545), so that Scouts can follow their
+// upcoming events in any calendar app and plan events from the fixture lists that leagues and clubs publish.
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/i18n"
+)
+
+const (
+	// ContentType is the media type of iCalendar files.
+	ContentType = "text/calendar; charset=utf-8"
+	// EventDuration is how long events are shown in calendars, since events only record when they start.
+	EventDuration = 2 * time.Hour
+
+	// lineLimit is the longest a line may be in octets, excluding the line break, before it is folded.
+	lineLimit = 75
+	// uidDomain makes the UIDs of events planned in the app globally unique.
+	uidDomain     = "scoutingapp"
+	dateTimeUTC   = "20060102T150405Z"
+	dateTimeLocal = "20060102T150405"
+	dateOnly      = "20060102"
+)
+
+// ErrInvalidFile is wrapped by errors about files that aren't iCalendar files.
+var ErrInvalidFile = errors.New("invalid iCalendar file")
+
+// UID returns the iCalendar UID of the Event, which is the UID it was imported with, if any.
+func UID(e *database.Event) string {
+	if e.UID != "" {
+		return e.UID
+	}
+	return fmt.Sprintf("%s@%s", e.ID, uidDomain)
+}
+
+// WriteEvents writes events as an iCalendar file named name. Event times are floating, i.e. shown at the same time
+// of day in any time zone, since events don't record their time zone. now is the time the file is written.
+func WriteEvents(w io.Writer, l *i18n.Localizer, name string, events []*database.UpcomingEvent, now time.Time) error {
+	out := &writer{w: bufio.NewWriter(w)}
+	out.line("BEGIN", "VCALENDAR")
+	out.line("VERSION", "2.0")
+	out.line("PRODID", "-//thirdknife//scoutingapp//EN")
+	out.line("CALSCALE", "GREGORIAN")
+	out.line("METHOD", "PUBLISH")
+	out.line("X-WR-CALNAME", escape(name))
+	for _, e := range events {
+		start, err := database.ParseDate(e.Date)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", e.ID, err)
+		}
+		out.line("BEGIN", "VEVENT")
+		out.line("UID", escape(UID(e.Event)))
+		out.line("DTSTAMP", now.UTC().Format(dateTimeUTC))
+		out.line("DTSTART", start.Format(dateTimeLocal))
+		out.line("DTEND", start.Add(EventDuration).Format(dateTimeLocal))
+		out.line("SUMMARY", escape(e.Name))
+		if e.Venue != "" {
+			out.line("LOCATION", escape(e.Venue))
+		}
+		if e.Competition != "" {
+			out.line("CATEGORIES", escape(e.Competition))
+		}
+		if description := describe(l, e); description != "" {
+			out.line("DESCRIPTION", escape(description))
+		}
+		out.line("END", "VEVENT")
+	}
+	out.line("END", "VCALENDAR")
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// describe lists the competition of an event, the players to watch there and the Scout's notes.
+func describe(l *i18n.Localizer, e *database.UpcomingEvent) string {
+	var lines []string
+	if e.Competition != "" {
+		lines = append(lines, l.T("calendar.competition", e.Competition))
+	}
+	if len(e.Players) > 0 {
+		names := make([]string, len(e.Players))
+		for i, p := range e.Players {
+			names[i] = p.Name
+		}
+		lines = append(lines, l.T("calendar.playersToWatch", strings.Join(names, ", ")))
+	}
+	if e.Notes != "" {
+		lines = append(lines, e.Notes)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writer writes content lines, remembering the first error so that callers only check once.
+type writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a property whose value is already escaped, folding it into lines of at most lineLimit octets without
+// splitting characters.
+func (w *writer) line(name, value string) {
+	if w.err != nil {
+		return
+	}
+	line := name + ":" + value
+	limit := lineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.write(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with the space that folded them.
+		limit = lineLimit - 1
+	}
+	w.write(line + "\r\n")
+}
+
+func (w *writer) write(s string) {
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+var (
+	escaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+// escape escapes text for a TEXT property value.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// unescape reverses escape.
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
@@ -0,0 +1,174 @@
+package calendar
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/i18n"
+	"golang.org/x/text/language"
+)
+
+func TestWriteEventsRoundTrip(t *testing.T) {
+	event := &database.UpcomingEvent{
+		Event: &database.Event{
+			Name:        "Rovers v United; semi-final, second leg",
+			Date:        "2024-05-01 15:30",
+			Venue:       "Rovers Park, Gate 4",
+			Competition: "Cup",
+			Notes:       "Bring binoculars\nSit high up — the pitch is wide. " + strings.Repeat("Ärger ", 20),
+		},
+		Players: []*database.Player{{Name: "Ann"}, {Name: "Bo"}},
+	}
+	var buf bytes.Buffer
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	if err := WriteEvents(&buf, i18n.New(language.English), "Scouting", []*database.UpcomingEvent{event}, now); err != nil {
+		t.Fatalf("WriteEvents() failed: %v", err)
+	}
+
+	file := buf.String()
+	if !strings.HasSuffix(file, "END:VCALENDAR\r\n") {
+		t.Errorf("Expected CRLF line endings, got %q", file)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(file, "\r\n"), "\r\n") {
+		if len(line) > lineLimit {
+			t.Errorf("Expected lines of at most %d octets, got %q", lineLimit, line)
+		}
+	}
+	for _, want := range []string{"DTSTART:20240501T153000\r\n", "DTEND:20240501T173000\r\n", "DTSTAMP:20240401T120000Z\r\n"} {
+		if !strings.Contains(file, want) {
+			t.Errorf("Expected %q in %s", want, file)
+		}
+	}
+
+	fixtures, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if len(fixtures) != 1 {
+		t.Fatalf("Expected 1 fixture, got %d", len(fixtures))
+	}
+	got := fixtures[0]
+	if got.Name != event.Name || got.Venue != event.Venue || got.Competition != event.Competition {
+		t.Errorf("Expected the event to survive the round trip, got %+v", got)
+	}
+	if got.UID != UID(event.Event) {
+		t.Errorf("Expected UID %q, got %q", UID(event.Event), got.UID)
+	}
+	if want := time.Date(2024, 5, 1, 15, 30, 0, 0, time.Local); !got.Start.Equal(want) {
+		t.Errorf("Expected start %v, got %v", want, got.Start)
+	}
+	for _, want := range []string{"Ann, Bo", "Cup", event.Notes} {
+		if !strings.Contains(got.Notes, want) {
+			t.Errorf("Expected the description to contain %q, got %q", want, got.Notes)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("No time zone database: %v", err)
+	}
+	file := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Berlin",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:utc@league",
+		"SUMMARY:Rovers v Uni",
+		" ted",
+		"DTSTART:20240501T130000Z",
+		"CATEGORIES:League\\, North,Friendly",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:zoned@league",
+		"SUMMARY:Zoned",
+		`DTSTART;TZID="Europe/Berlin":20240502T150000`,
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:All day",
+		"DTSTART;VALUE=DATE:20240503",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Cancelled",
+		"STATUS:CANCELLED",
+		"DTSTART:20240504T150000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	fixtures, err := Parse(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if len(fixtures) != 3 {
+		t.Fatalf("Expected 3 fixtures without the cancelled one, got %+v", fixtures)
+	}
+	if fixtures[0].Name != "Rovers v United" || fixtures[0].Competition != "League, North" {
+		t.Errorf("Expected unfolded name and first category, got %+v", fixtures[0])
+	}
+	for i, want := range []time.Time{
+		time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 2, 15, 0, 0, 0, berlin),
+		time.Date(2024, 5, 3, 0, 0, 0, 0, time.Local),
+	} {
+		if !fixtures[i].Start.Equal(want) {
+			t.Errorf("Expected fixture %d to start at %v, got %v", i, want, fixtures[i].Start)
+		}
+	}
+
+	for _, invalid := range []string{
+		"not a calendar",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:No start\r\nEND:VEVENT\r\nEND:VCALENDAR",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:tomorrow\r\nEND:VEVENT\r\nEND:VCALENDAR",
+	} {
+		if _, err := Parse(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("Parse(%q) = %v, expected ErrInvalidFile", invalid, err)
+		}
+	}
+}
+
+func TestImportFixtures(t *testing.T) {
+	db := database.CreateTestDB(t)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	fixtures := []Fixture{
+		{UID: "a@league", Name: "Rovers v United", Venue: "Rovers Park", Start: time.Date(2024, 5, 1, 15, 0, 0, 0, time.Local)},
+		{Name: "No UID", Start: time.Date(2024, 5, 2, 15, 0, 0, 0, time.Local)},
+		{UID: "old@league", Name: "Past", Start: time.Date(2024, 1, 1, 15, 0, 0, 0, time.Local)},
+	}
+	result, err := ImportFixtures(db, fixtures, from)
+	if err != nil {
+		t.Fatalf("ImportFixtures() failed: %v", err)
+	}
+	if *result != (ImportResult{Created: 2, Past: 1}) {
+		t.Errorf("Expected 2 created and 1 past, got %+v", result)
+	}
+	if err := db.Model(&database.Event{}).Where("uid = ?", "a@league").Update("notes", "Watch the left back").Error; err != nil {
+		t.Fatalf("Failed to update Event: %v", err)
+	}
+
+	// The fixture was moved, which updates the Event rather than planning another.
+	fixtures[0].Start = fixtures[0].Start.Add(48 * time.Hour)
+	result, err = ImportFixtures(db, fixtures, from)
+	if err != nil {
+		t.Fatalf("ImportFixtures() failed: %v", err)
+	}
+	if *result != (ImportResult{Updated: 2, Past: 1}) {
+		t.Errorf("Expected 2 updated and 1 past, got %+v", result)
+	}
+	var events []*database.Event
+	if err := db.Order("date").Find(&events).Error; err != nil {
+		t.Fatalf("Failed to retrieve Events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if events[1].Date != "2024-05-03 15:00" || events[1].Notes != "Watch the left back" {
+		t.Errorf("Expected the moved event to keep its notes, got %+v", events[1])
+	}
+}
@@ -0,0 +1,63 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+// ImportResult counts what ImportFixtures did.
+type ImportResult struct {
+	Created int
+	Updated int
+	// Past is how many fixtures were left out because they had already started.
+	Past int
+}
+
+// ImportFixtures plans an Event for every fixture that starts at or after from, in the Scout's local time. Fixtures
+// that were imported before, recognised by their UID or otherwise by their name and start, update their Event
+// instead. The Scout's notes on an Event are kept when it is updated.
+func ImportFixtures(db *gorm.DB, fixtures []Fixture, from time.Time) (*ImportResult, error) {
+	result := &ImportResult{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, f := range fixtures {
+			if f.Start.Before(from) {
+				result.Past++
+				continue
+			}
+			date := f.Start.In(time.Local).Format(database.DateFormat)
+			var event database.Event
+			query := tx.Where("name = ? AND date = ?", f.Name, date)
+			if f.UID != "" {
+				query = tx.Where("uid = ?", f.UID)
+			}
+			err := query.First(&event).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				event = database.Event{
+					UID: f.UID, Name: f.Name, Date: date, Venue: f.Venue, Competition: f.Competition, Notes: f.Notes,
+				}
+				if err := tx.Create(&event).Error; err != nil {
+					return fmt.Errorf("creating Event %q failed: %w", f.Name, err)
+				}
+				result.Created++
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("retrieving Event %q failed: %w", f.Name, err)
+			}
+			updates := map[string]any{"name": f.Name, "date": date, "venue": f.Venue, "competition": f.Competition}
+			if err := tx.Model(&event).Updates(updates).Error; err != nil {
+				return fmt.Errorf("updating Event %q failed: %w", f.Name, err)
+			}
+			result.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
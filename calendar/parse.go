@@ -0,0 +1,209 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxLineSize limits the length of an unfolded content line.
+const maxLineSize = 1 << 20
+
+// Fixture is an event read from an iCalendar file.
+type Fixture struct {
+	// UID identifies the event in the calendar it was read from. It may be empty.
+	UID         string
+	Name        string
+	Venue       string
+	Competition string
+	Notes       string
+	// Start is in the time zone of the event, or in time.Local if the event doesn't name one.
+	Start time.Time
+}
+
+// property is a content line, e.g. DTSTART;TZID=Europe/Berlin:20240501T150000.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the events of an iCalendar file. Cancelled events are left out.
+func Parse(r io.Reader) ([]Fixture, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		fixtures  []Fixture
+		current   *Fixture
+		cancelled bool
+		calendar  bool
+	)
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, i+1, err)
+		}
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VCALENDAR"):
+			calendar = true
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			current, cancelled = &Fixture{}, false
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			if current == nil {
+				return nil, fmt.Errorf("%w: line %d: END:VEVENT without BEGIN:VEVENT", ErrInvalidFile, i+1)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("%w: event %q has no DTSTART", ErrInvalidFile, current.Name)
+			}
+			if !cancelled {
+				fixtures = append(fixtures, *current)
+			}
+			current = nil
+		case current != nil:
+			if err := current.set(p); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, i+1, err)
+			}
+			if p.name == "STATUS" && strings.EqualFold(p.value, "CANCELLED") {
+				cancelled = true
+			}
+		}
+	}
+	if !calendar {
+		return nil, fmt.Errorf("%w: no VCALENDAR", ErrInvalidFile)
+	}
+	return fixtures, nil
+}
+
+// unfold reads the content lines of r, joining folded lines.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return lines, nil
+}
+
+// parseProperty splits a content line into its name, parameters and value. Parameter values may be quoted, in which
+// case they may contain ':' and ';'.
+func parseProperty(line string) (property, error) {
+	p := property{params: map[string]string{}}
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return p, fmt.Errorf("%q is not a property", line)
+	}
+	p.name = strings.ToUpper(line[:end])
+	rest := line[end:]
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return p, fmt.Errorf("parameter of %s has no value", p.name)
+		}
+		key := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return p, fmt.Errorf("parameter %s of %s is not closed", key, p.name)
+			}
+			value, rest = rest[1:closing+1], rest[closing+2:]
+		} else {
+			stop := strings.IndexAny(rest, ";:")
+			if stop < 0 {
+				return p, fmt.Errorf("%s has no value", p.name)
+			}
+			value, rest = rest[:stop], rest[stop:]
+		}
+		p.params[key] = value
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return p, fmt.Errorf("%s has no value", p.name)
+	}
+	p.value = rest[1:]
+	return p, nil
+}
+
+// set records the property of an event on the Fixture, ignoring properties that events don't have.
+func (f *Fixture) set(p property) error {
+	switch p.name {
+	case "UID":
+		f.UID = unescape(p.value)
+	case "SUMMARY":
+		f.Name = unescape(p.value)
+	case "LOCATION":
+		f.Venue = unescape(p.value)
+	case "DESCRIPTION":
+		f.Notes = unescape(p.value)
+	case "CATEGORIES":
+		// Only the first category fits Event.Competition. Unescaped commas separate categories.
+		first := p.value
+		for i := 0; i < len(first); i++ {
+			if first[i] == '\\' {
+				i++
+			} else if first[i] == ',' {
+				first = first[:i]
+				break
+			}
+		}
+		if f.Competition == "" {
+			f.Competition = unescape(first)
+		}
+	case "DTSTART":
+		start, err := parseStart(p)
+		if err != nil {
+			return err
+		}
+		f.Start = start
+	}
+	return nil
+}
+
+// parseStart parses a DTSTART, which is a date or a date and time that is either in UTC, in the time zone named by
+// the TZID parameter, or floating.
+func parseStart(p property) (time.Time, error) {
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(p.value) == len(dateOnly) {
+		t, err := time.ParseInLocation(dateOnly, p.value, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("DTSTART %q is not a date", p.value)
+		}
+		return t, nil
+	}
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse(dateTimeUTC, p.value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("DTSTART %q is not a date and time", p.value)
+		}
+		return t, nil
+	}
+	location := time.Local
+	if tzid := p.params["TZID"]; tzid != "" {
+		// Calendars may name time zones that aren't in the IANA database, e.g. Windows names. The time is then taken
+		// as it is, like a floating time.
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			location = l
+		}
+	}
+	t, err := time.ParseInLocation(dateTimeLocal, p.value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("DTSTART %q is not a date and time", p.value)
+	}
+	return t, nil
+}
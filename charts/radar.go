@@ -0,0 +1,126 @@
+package charts
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// The chart is wider than it is tall to leave room for long axis labels on either side.
+	radarWidth  = 560
+	radarHeight = 360
+	radarRadius = 130
+	// radarRings is the number of concentric grid lines.
+	radarRings = 5
+)
+
+// Series is a named set of values, such as the average ratings of one player.
+type Series struct {
+	Name string
+	// Values holds one value per axis or point. NaN marks a missing value.
+	Values []float64
+}
+
+// Radar is a radar (or spider) chart, with one axis per attribute and one polygon per series.
+type Radar struct {
+	// ID identifies the chart in URLs, e.g. the rating group it shows. Unlike Title, it isn't translated.
+	ID    string
+	Title string
+	Axes  []string
+	// Max is the value at the outer edge of every axis. Values are clamped to [0, Max].
+	Max    float64
+	Series []Series
+}
+
+// WriteSVG writes the chart as an <svg> element. Missing values are drawn at the centre, without a marker.
+func (r *Radar) WriteSVG(w io.Writer) error {
+	if len(r.Axes) < 3 {
+		return fmt.Errorf("a radar chart needs at least 3 axes, got %d", len(r.Axes))
+	}
+	if r.Max <= 0 {
+		return fmt.Errorf("radar chart maximum must be positive, got %v", r.Max)
+	}
+	for _, s := range r.Series {
+		if len(s.Values) != len(r.Axes) {
+			return fmt.Errorf("series %q has %d values for %d axes", s.Name, len(s.Values), len(r.Axes))
+		}
+	}
+
+	height := radarHeight + 18*len(r.Series)
+	cx, cy := float64(radarWidth)/2, float64(radarHeight)/2+10
+	radius := float64(radarRadius)
+
+	svg := &svgWriter{w: w}
+	svg.open(radarWidth, height, r.Title)
+	svg.text(cx, 18, "middle", 14, "#111827", r.Title)
+
+	// Grid rings and axes.
+	for ring := 1; ring <= radarRings; ring++ {
+		xs, ys := r.polygon(cx, cy, radius*float64(ring)/radarRings, nil)
+		svg.printf(`<polygon points="%s" fill="none" stroke="#d1d5db"/>`, points(xs, ys))
+	}
+	for i, axis := range r.Axes {
+		x, y := r.point(cx, cy, radius, i)
+		svg.line(cx, cy, x, y, "#d1d5db", false)
+		lx, ly := r.point(cx, cy, radius+12, i)
+		svg.text(lx, ly+4, anchorFor(lx, cx), 11, "#374151", axis)
+	}
+	svg.text(cx+4, cy-radius-2, "start", 9, "#6b7280", fmt.Sprint(r.Max))
+
+	for i, s := range r.Series {
+		xs, ys := r.polygon(cx, cy, radius, s.Values)
+		color := Color(i)
+		svg.printf(`<polygon points="%s" fill="%s" fill-opacity="0.2" stroke="%s" stroke-width="2"/>`, points(xs, ys), color, color)
+		for j, v := range s.Values {
+			if !math.IsNaN(v) {
+				svg.circle(xs[j], ys[j], 3, color)
+			}
+		}
+		ly := float64(radarHeight + 18*i + 4)
+		svg.printf(`<rect x="20" y="%s" width="12" height="12" fill="%s"/>`, num(ly), color)
+		svg.text(38, ly+10, "start", 12, "#111827", s.Name)
+	}
+	svg.close()
+	return svg.err
+}
+
+// point returns the coordinates at distance d from the centre along axis i. The first axis points straight up and
+// the rest follow clockwise.
+func (r *Radar) point(cx, cy, d float64, i int) (float64, float64) {
+	angle := 2*math.Pi*float64(i)/float64(len(r.Axes)) - math.Pi/2
+	return cx + d*math.Cos(angle), cy + d*math.Sin(angle)
+}
+
+// polygon returns the corners of the polygon for the given values, or of a regular polygon of the given radius if
+// values is nil.
+func (r *Radar) polygon(cx, cy, radius float64, values []float64) (xs, ys []float64) {
+	xs, ys = make([]float64, len(r.Axes)), make([]float64, len(r.Axes))
+	for i := range r.Axes {
+		d := radius
+		if values != nil {
+			d = radius * clamp(values[i], r.Max) / r.Max
+		}
+		xs[i], ys[i] = r.point(cx, cy, d, i)
+	}
+	return xs, ys
+}
+
+// clamp limits v to [0, max], treating missing values as 0.
+func clamp(v, max float64) float64 {
+	if math.IsNaN(v) || v < 0 {
+		return 0
+	}
+	return math.Min(v, max)
+}
+
+// anchorFor aligns a label so it extends away from the centre of the chart.
+func anchorFor(x, cx float64) string {
+	switch {
+	case math.Abs(x-cx) < 1:
+		return "middle"
+	case x < cx:
+		return "end"
+	}
+	return "start"
+}
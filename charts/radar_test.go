@@ -0,0 +1,98 @@
+package charts
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/i18n"
+	"github.com/thirdknife/scoutingapp/scoring"
+)
+
+// parseSVG checks that the chart is well-formed XML and counts its elements by name.
+func parseSVG(t *testing.T, svg []byte) map[string]int {
+	t.Helper()
+	counts := map[string]int{}
+	d := xml.NewDecoder(bytes.NewReader(svg))
+	for {
+		token, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return counts
+			}
+			t.Fatalf("Chart is not well-formed XML: %v\n%s", err, svg)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+}
+
+func TestRadar(t *testing.T) {
+	radar := &Radar{
+		Title: "Tactical <& co>",
+		Axes:  []string{"Vision", "Awareness", "Movement"},
+		Max:   10,
+		Series: []Series{
+			{Name: "A", Values: []float64{5, 7, math.NaN()}},
+			{Name: "B", Values: []float64{10, 12, 3}},
+		},
+	}
+	svg, err := Standalone(radar)
+	if err != nil {
+		t.Fatalf("Standalone() failed: %v", err)
+	}
+	counts := parseSVG(t, svg)
+	// Five grid rings plus one polygon per series.
+	if counts["polygon"] != radarRings+2 {
+		t.Errorf("Expected %d polygons, got %d", radarRings+2, counts["polygon"])
+	}
+	// Missing values don't get a marker.
+	if counts["circle"] != 5 {
+		t.Errorf("Expected 5 markers, got %d", counts["circle"])
+	}
+	if !strings.Contains(string(svg), "Tactical &lt;&amp; co&gt;") {
+		t.Error("Expected the title to be escaped")
+	}
+}
+
+func TestRadarErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		radar *Radar
+	}{
+		{name: "too few axes", radar: &Radar{Axes: []string{"A", "B"}, Max: 10}},
+		{name: "no maximum", radar: &Radar{Axes: []string{"A", "B", "C"}}},
+		{name: "mismatched series", radar: &Radar{Axes: []string{"A", "B", "C"}, Max: 10, Series: []Series{{Values: []float64{1}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Standalone(tt.radar); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestGroupRadar(t *testing.T) {
+	vision := database.Attribute{Group: database.TacticalRatings, Name: "Vision"}
+	players := []PlayerAverages{
+		{Name: "A", Averages: map[database.Attribute]scoring.Average{vision: {Mean: 7, Samples: 2}}},
+		{Name: "B"},
+	}
+	if groups := RatedGroups(players); len(groups) != 1 || groups[0] != database.TacticalRatings {
+		t.Errorf("RatedGroups() = %v, want only %v", groups, database.TacticalRatings)
+	}
+
+	radar := GroupRadar(database.TacticalRatings, players, i18n.New(i18n.English))
+	if len(radar.Axes) != 3 || radar.Axes[0] != "Vision" {
+		t.Errorf("Expected the tactical attributes as axes, got %v", radar.Axes)
+	}
+	if radar.Series[0].Values[0] != 7 || !math.IsNaN(radar.Series[0].Values[1]) || !math.IsNaN(radar.Series[1].Values[0]) {
+		t.Errorf("Unexpected values %v", radar.Series)
+	}
+}
@@ -0,0 +1,155 @@
+package charts
+
+import (
+	"math"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/scoring"
+)
+
+// Labels names what rating charts show, in the language of the Scout. i18n.Localizer implements it.
+type Labels interface {
+	// T translates a message, such as "chart.noRatings".
+	T(key string, args ...any) string
+	Attribute(a database.Attribute) string
+	Group(g database.RatingGroup) string
+	Category(c database.AnalysisCategory) string
+}
+
+// PlayerAverages are the averaged ratings of one player, as computed by scoring.Averages.
+type PlayerAverages struct {
+	Name     string
+	Averages map[database.Attribute]scoring.Average
+}
+
+// GroupRadar draws the averaged ratings of a rating group for one or more players. Players are overlaid in the
+// order given, and attributes a player wasn't rated on are treated as missing.
+func GroupRadar(group database.RatingGroup, players []PlayerAverages, labels Labels) *Radar {
+	attributes := database.GroupAttributes(group)
+	radar := &Radar{ID: string(group), Title: labels.Group(group), Max: database.MaxRating}
+	for _, a := range attributes {
+		radar.Axes = append(radar.Axes, labels.Attribute(a))
+	}
+	for _, p := range players {
+		series := Series{Name: p.Name, Values: make([]float64, len(attributes))}
+		for i, a := range attributes {
+			series.Values[i] = math.NaN()
+			if avg, ok := p.Averages[a]; ok && avg.Rated() {
+				series.Values[i] = avg.Mean
+			}
+		}
+		radar.Series = append(radar.Series, series)
+	}
+	return radar
+}
+
+// RatedGroups returns the rating groups that at least one of the players was rated on, in database.RatingGroups
+// order.
+func RatedGroups(players []PlayerAverages) []database.RatingGroup {
+	var groups []database.RatingGroup
+	for _, g := range database.RatingGroups {
+	attributes:
+		for _, a := range database.GroupAttributes(g) {
+			for _, p := range players {
+				if p.Averages[a].Rated() {
+					groups = append(groups, g)
+					break attributes
+				}
+			}
+		}
+	}
+	return groups
+}
+
+// ComparisonAverages returns the averages of each compared player, in the same order as comparison.Players.
+func ComparisonAverages(comparison *scoring.Comparison) []PlayerAverages {
+	players := make([]PlayerAverages, len(comparison.Players))
+	for i, p := range comparison.Players {
+		players[i] = PlayerAverages{Name: p.Name, Averages: map[database.Attribute]scoring.Average{}}
+	}
+	for _, row := range comparison.Rows {
+		for i, avg := range row.Averages {
+			players[i].Averages[row.Attribute] = avg
+		}
+	}
+	return players
+}
+
+// GroupRadars draws a radar chart for every group that at least one of the players was rated on.
+func GroupRadars(players []PlayerAverages, labels Labels) []*Radar {
+	var radars []*Radar
+	for _, g := range RatedGroups(players) {
+		radars = append(radars, GroupRadar(g, players, labels))
+	}
+	return radars
+}
+
+// categoryMarkers distinguishes analyses recorded at matches from those recorded at training sessions.
+var categoryMarkers = map[database.AnalysisCategory]Marker{
+	database.Match:    Circle,
+	database.Training: Square,
+	database.Other:    Diamond,
+}
+
+// AttributeTrend draws how the ratings of an attribute changed over time. analyses must be in chronological order,
+// and analyses without a valid date or rating are skipped. If window is greater than one, a moving average over that
+// many analyses is drawn as well.
+func AttributeTrend(attribute database.Attribute, analyses []*database.AnalysisDetail, window int, labels Labels) *Trend {
+	series := TimeSeries{Name: labels.Attribute(attribute)}
+	for _, a := range analyses {
+		date, err := database.ParseDate(a.Date)
+		if err != nil {
+			continue
+		}
+		if r, ok := a.Rating(attribute); ok {
+			series.Points = append(series.Points, TimePoint{Time: date, Value: float64(r), Marker: categoryMarkers[a.Category]})
+		}
+	}
+	title := labels.T("chart.attributeTitle", labels.Group(attribute.Group), labels.Attribute(attribute))
+	return newRatingTrend(attribute.Key(), title, series, window, labels)
+}
+
+// GroupTrend draws how the mean of the rated attributes of a group changed over time. It treats analyses and window
+// like AttributeTrend.
+func GroupTrend(group database.RatingGroup, analyses []*database.AnalysisDetail, window int, labels Labels) *Trend {
+	series := TimeSeries{Name: labels.T("chart.groupAverage", labels.Group(group))}
+	for _, a := range analyses {
+		date, err := database.ParseDate(a.Date)
+		if err != nil {
+			continue
+		}
+		sum, count := 0, 0
+		for _, attribute := range database.GroupAttributes(group) {
+			if r, ok := a.Rating(attribute); ok {
+				sum += r
+				count++
+			}
+		}
+		if count > 0 {
+			series.Points = append(series.Points, TimePoint{Time: date, Value: float64(sum) / float64(count), Marker: categoryMarkers[a.Category]})
+		}
+	}
+	return newRatingTrend(string(group), labels.Group(group), series, window, labels)
+}
+
+func newRatingTrend(id, title string, series TimeSeries, window int, labels Labels) *Trend {
+	trend := &Trend{
+		ID:     id,
+		Title:  title,
+		Max:    database.MaxRating,
+		Series: []TimeSeries{series},
+		// The legend of categoryMarkers.
+		Markers: map[Marker]string{
+			Circle:  labels.Category(database.Match),
+			Square:  labels.Category(database.Training),
+			Diamond: labels.Category(database.Other),
+		},
+		Empty: labels.T("chart.noRatings"),
+	}
+	if window > 1 && len(series.Points) > 1 {
+		average := MovingAverage(series, window)
+		average.Name = labels.T("chart.movingAverage", series.Name, window)
+		trend.Series = append(trend.Series, average)
+	}
+	return trend
+}
@@ -0,0 +1,177 @@
+package charts
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	trendWidth  = 640
+	trendHeight = 300
+	// The plot area is inset from the edges to leave room for axis labels.
+	trendLeft   = 40
+	trendRight  = 20
+	trendTop    = 30
+	trendBottom = 50
+	// trendTicks is the number of horizontal grid lines above zero.
+	trendTicks = 5
+)
+
+// Marker is the shape drawn at each point of a trend, used to tell different kinds of points apart.
+type Marker int
+
+const (
+	Circle Marker = iota
+	Square
+	Diamond
+	NoMarker
+)
+
+// TimePoint is a value at a point in time.
+type TimePoint struct {
+	Time   time.Time
+	Value  float64
+	Marker Marker
+}
+
+// TimeSeries is a line through points ordered by time.
+type TimeSeries struct {
+	Name   string
+	Points []TimePoint
+	// Dashed draws the line dashed, which is used for derived series such as moving averages.
+	Dashed bool
+}
+
+// Trend is a line chart of values over time.
+type Trend struct {
+	// ID identifies the chart in URLs, e.g. the rating group it shows. Unlike Title, it isn't translated.
+	ID    string
+	Title string
+	// Max is the value at the top of the chart. Values are clamped to [0, Max].
+	Max    float64
+	Series []TimeSeries
+	// Markers explains each marker in the legend, e.g. {Circle: "Match"}. Markers without an entry are not listed.
+	Markers map[Marker]string
+	// Empty is shown instead of lines when there are no points. It defaults to "No ratings".
+	Empty string
+}
+
+// WriteSVG writes the chart as an <svg> element.
+func (t *Trend) WriteSVG(w io.Writer) error {
+	if t.Max <= 0 {
+		return fmt.Errorf("trend chart maximum must be positive, got %v", t.Max)
+	}
+	first, last, ok := t.timeRange()
+
+	svg := &svgWriter{w: w}
+	svg.open(trendWidth, trendHeight, t.Title)
+	svg.text(trendWidth/2, 18, "middle", 14, "#111827", t.Title)
+
+	left, right := float64(trendLeft), float64(trendWidth-trendRight)
+	top, bottom := float64(trendTop), float64(trendHeight-trendBottom)
+	for tick := 0; tick <= trendTicks; tick++ {
+		value := t.Max * float64(tick) / trendTicks
+		y := bottom - (bottom-top)*float64(tick)/trendTicks
+		svg.line(left, y, right, y, "#e5e7eb", false)
+		svg.text(left-6, y+4, "end", 10, "#6b7280", fmt.Sprint(math.Round(value*10)/10))
+	}
+	if !ok {
+		empty := t.Empty
+		if empty == "" {
+			empty = "No ratings"
+		}
+		svg.text(trendWidth/2, (top+bottom)/2, "middle", 12, "#6b7280", empty)
+		svg.close()
+		return svg.err
+	}
+
+	x := func(at time.Time) float64 {
+		if !last.After(first) {
+			return (left + right) / 2
+		}
+		return left + (right-left)*float64(at.Sub(first))/float64(last.Sub(first))
+	}
+	y := func(v float64) float64 {
+		return bottom - (bottom-top)*clamp(v, t.Max)/t.Max
+	}
+
+	svg.text(left, bottom+16, "start", 10, "#6b7280", first.Format(time.DateOnly))
+	if last.After(first) {
+		svg.text(right, bottom+16, "end", 10, "#6b7280", last.Format(time.DateOnly))
+	}
+
+	legendX := left
+	for i, s := range t.Series {
+		color := Color(i)
+		xs, ys := make([]float64, len(s.Points)), make([]float64, len(s.Points))
+		for j, p := range s.Points {
+			xs[j], ys[j] = x(p.Time), y(p.Value)
+		}
+		dash := ""
+		if s.Dashed {
+			dash = ` stroke-dasharray="6 4"`
+		}
+		svg.printf(`<polyline points="%s" fill="none" stroke="%s" stroke-width="2"%s/>`, points(xs, ys), color, dash)
+		for j, p := range s.Points {
+			svg.marker(p.Marker, xs[j], ys[j], color)
+		}
+		svg.line(legendX, bottom+30, legendX+16, bottom+30, color, s.Dashed)
+		svg.text(legendX+20, bottom+34, "start", 11, "#111827", s.Name)
+		legendX += 30 + 7*float64(len(s.Name))
+	}
+	for _, m := range []Marker{Circle, Square, Diamond} {
+		if label, ok := t.Markers[m]; ok {
+			svg.marker(m, legendX+4, bottom+30, "#374151")
+			svg.text(legendX+12, bottom+34, "start", 11, "#111827", label)
+			legendX += 20 + 7*float64(len(label))
+		}
+	}
+	svg.close()
+	return svg.err
+}
+
+// timeRange returns the earliest and latest time of any point. ok is false if there are no points.
+func (t *Trend) timeRange() (first, last time.Time, ok bool) {
+	for _, s := range t.Series {
+		for _, p := range s.Points {
+			if !ok || p.Time.Before(first) {
+				first = p.Time
+			}
+			if !ok || p.Time.After(last) {
+				last = p.Time
+			}
+			ok = true
+		}
+	}
+	return first, last, ok
+}
+
+func (s *svgWriter) marker(m Marker, x, y float64, color string) {
+	switch m {
+	case Circle:
+		s.circle(x, y, 4, color)
+	case Square:
+		s.printf(`<rect x="%s" y="%s" width="8" height="8" fill="white" stroke="%s" stroke-width="2"/>`,
+			num(x-4), num(y-4), color)
+	case Diamond:
+		s.printf(`<polygon points="%s" fill="%s"/>`, points([]float64{x, x + 5, x, x - 5}, []float64{y - 5, y, y + 5, y}), color)
+	}
+}
+
+// MovingAverage smooths a series with the trailing mean of up to window points. The result has the same points in
+// time, without markers.
+func MovingAverage(s TimeSeries, window int) TimeSeries {
+	smoothed := TimeSeries{Name: fmt.Sprintf("%s (%d-point average)", s.Name, window), Dashed: true}
+	sum := 0.0
+	for i, p := range s.Points {
+		sum += p.Value
+		if i >= window {
+			sum -= s.Points[i-window].Value
+		}
+		n := min(i+1, window)
+		smoothed.Points = append(smoothed.Points, TimePoint{Time: p.Time, Value: sum / float64(n), Marker: NoMarker})
+	}
+	return smoothed
+}
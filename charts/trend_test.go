@@ -0,0 +1,68 @@
+package charts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/i18n"
+)
+
+func TestMovingAverage(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	series := TimeSeries{Name: "Pace", Points: []TimePoint{
+		{Time: day(1), Value: 2},
+		{Time: day(2), Value: 4},
+		{Time: day(3), Value: 9},
+		{Time: day(4), Value: 1},
+	}}
+	got := MovingAverage(series, 3)
+	want := []float64{2, 3, 5, 14.0 / 3}
+	if len(got.Points) != len(want) {
+		t.Fatalf("Expected %d points, got %d", len(want), len(got.Points))
+	}
+	for i, p := range got.Points {
+		if p.Value != want[i] || !p.Time.Equal(series.Points[i].Time) {
+			t.Errorf("Point %d = %+v, want %v at %v", i, p, want[i], series.Points[i].Time)
+		}
+	}
+	if !got.Dashed {
+		t.Error("Expected the moving average to be dashed")
+	}
+}
+
+func TestAttributeTrend(t *testing.T) {
+	pace := database.Attribute{Group: database.AthleticRatings, Name: "Pace"}
+	athletic := func(pace int) *database.AthleticAnalysis {
+		return &database.AthleticAnalysis{Pace: pace, Sharpness: database.Unrated, Mobility: database.Unrated, BodyStrength: database.Unrated, WorkRate: database.Unrated}
+	}
+	analyses := []*database.AnalysisDetail{
+		{Analysis: &database.Analysis{Category: database.Match, Date: "2024-01-01 15:00"}, Athletic: athletic(5)},
+		{Analysis: &database.Analysis{Category: database.Training, Date: "2024-01-08 10:00"}, Athletic: athletic(7)},
+		// Skipped: unrated, and no valid date.
+		{Analysis: &database.Analysis{Category: database.Match, Date: "2024-01-15 15:00"}, Athletic: athletic(database.Unrated)},
+		{Analysis: &database.Analysis{Category: database.Match, Date: "someday"}, Athletic: athletic(9)},
+	}
+
+	trend := AttributeTrend(pace, analyses, 2, i18n.New(i18n.English))
+	if len(trend.Series) != 2 {
+		t.Fatalf("Expected the ratings and their moving average, got %d series", len(trend.Series))
+	}
+	points := trend.Series[0].Points
+	if len(points) != 2 || points[0].Marker != Circle || points[1].Marker != Square {
+		t.Errorf("Expected a match and a training point, got %+v", points)
+	}
+
+	svg, err := Standalone(trend)
+	if err != nil {
+		t.Fatalf("Standalone() failed: %v", err)
+	}
+	counts := parseSVG(t, svg)
+	if counts["polyline"] != 2 {
+		t.Errorf("Expected 2 lines, got %d", counts["polyline"])
+	}
+
+	if _, err := Standalone(AttributeTrend(pace, nil, 1, i18n.New(i18n.English))); err != nil {
+		t.Errorf("Expected an empty trend to render, got %v", err)
+	}
+}
@@ -1,17 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"net/http"
+	"io"
+	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/a-h/templ"
 	"github.com/thirdknife/scoutingapp/database"
-	base "github.com/thirdknife/scoutingapp/views"
-
-	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	"github.com/thirdknife/scoutingapp/logging"
+	"github.com/thirdknife/scoutingapp/server"
 )
 
 // TODO: use a persistent directory.
@@ -47,50 +49,73 @@ func createFakeDatabaseFile(path string) error {
 	return nil
 }
 
-func RenderComponent(c echo.Context, status int, cmp templ.Component) error {
-	c.Response().WriteHeader(status)
-	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTML)
+// options are what the command line flags configure.
+type options struct {
+	// logger logs to the writer given to parseFlags.
+	logger         *slog.Logger
+	syncPeers      []string
+	trustedProxies []*net.IPNet
+}
+
+// parseFlags parses the command line flags, reporting problems with them to w.
+func parseFlags(args []string, w io.Writer) (*options, error) {
+	flags := flag.NewFlagSet("scoutingapp", flag.ContinueOnError)
+	flags.SetOutput(w)
+	logFormat := flags.String("log-format", "text", fmt.Sprintf("format of log records, one of %v", logging.Formats))
+	debug := flags.Bool("debug", false, "log every database query")
+	syncPeers := flags.String("sync-peers", "", "comma-separated URLs of servers on the local network that Scouts may sync with")
+	trustedProxies := flags.String("trusted-proxies", "", "comma-separated networks of reverse proxies whose X-Forwarded-For header is believed, e.g. 10.0.0.0/8")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
 
-	return cmp.Render(c.Request().Context(), c.Response().Writer)
+	level := slog.LevelInfo
+	if *debug {
+		level = slog.LevelDebug
+	}
+	logger, err := logging.New(w, *logFormat, level)
+	if err != nil {
+		return nil, err
+	}
+	opts := &options{logger: logger}
+	for _, peer := range strings.Split(*syncPeers, ",") {
+		if peer = strings.TrimSpace(peer); peer != "" {
+			opts.syncPeers = append(opts.syncPeers, peer)
+		}
+	}
+	for _, proxy := range strings.Split(*trustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+		}
+		opts.trustedProxies = append(opts.trustedProxies, network)
+	}
+	return opts, nil
 }
 
 func main() {
+	opts, err := parseFlags(os.Args[1:], os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger := opts.logger
+	slog.SetDefault(logger)
+
 	// TODO: This should be a hash of the scout's email (or whatever they use to log in).
 	userHash := "FAKE_SCOUT_HASH"
 	dbPath := filepath.Join(databaseDir, userHash+".db")
 	if err := createFakeDatabaseFile(dbPath); err != nil {
-		fmt.Printf("error creating fake database: %v", err)
+		logger.Error("Error creating fake database", "error", err)
 		os.Exit(1)
 	}
 
-	db, err := database.Load(dbPath)
-	if err != nil {
-		fmt.Printf("Error loading database: %v", err)
+	s := server.New(server.Config{Scout: userHash, SyncPeers: opts.syncPeers, TrustedProxies: opts.trustedProxies}, server.Deps{Scouts: database.NewScouts(databaseDir), Logger: logger})
+	if err := s.Run(context.Background()); err != nil {
+		logger.Error("Error running server", "error", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Loaded database from %s\n", dbPath)
-
-	e := echo.New()
-
-	e.Static("/public", "public")
-	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format:           `${time_custom}: ${method} ${uri} -> status=${status} ${error}` + "\n",
-		CustomTimeFormat: "2006-01-02 15:04:05",
-	}))
-
-	e.GET("/players", func(c echo.Context) error {
-
-		players, err := database.AllPlayers(db)
-		if err != nil {
-			return c.HTML(http.StatusInternalServerError, "<p>Error fetching players.</p>")
-		}
-
-		return RenderComponent(c, http.StatusOK, base.ListPlayers(players))
-	})
-
-	e.GET("/", func(c echo.Context) error {
-		return RenderComponent(c, http.StatusOK, base.Home())
-	})
-
-	e.Logger.Fatal(e.Start(":42069"))
 }
@@ -1,16 +1,67 @@
 package main
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
 
-func Test_main(t *testing.T) {
+func TestParseFlags(t *testing.T) {
 	tests := []struct {
-		name string
+		name    string
+		args    []string
+		debug   bool
+		json    bool
+		peers   []string
+		proxies []string
 	}{
-		// TODO: Add test cases.
+		{name: "defaults", args: nil},
+		{name: "debug", args: []string{"-debug"}, debug: true},
+		{name: "json", args: []string{"-log-format", "json"}, json: true},
+		{name: "sync peers", args: []string{"-sync-peers", "http://10.0.0.2:42069, http://nas.local,"}, peers: []string{"http://10.0.0.2:42069", "http://nas.local"}},
+		{name: "trusted proxies", args: []string{"-trusted-proxies", "10.0.0.0/8, 192.168.1.1/32"}, proxies: []string{"10.0.0.0/8", "192.168.1.1/32"}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			main()
+			var out bytes.Buffer
+			opts, err := parseFlags(tt.args, &out)
+			if err != nil {
+				t.Fatalf("parseFlags(%q) failed: %v", tt.args, err)
+			}
+			if !slices.Equal(opts.syncPeers, tt.peers) {
+				t.Errorf("Sync peers = %q, want %q", opts.syncPeers, tt.peers)
+			}
+			var proxies []string
+			for _, network := range opts.trustedProxies {
+				proxies = append(proxies, network.String())
+			}
+			if !slices.Equal(proxies, tt.proxies) {
+				t.Errorf("Trusted proxies = %q, want %q", proxies, tt.proxies)
+			}
+			logger := opts.logger
+			if got := logger.Enabled(context.Background(), slog.LevelDebug); got != tt.debug {
+				t.Errorf("Debug logging enabled = %v, want %v", got, tt.debug)
+			}
+			logger.Info("hello")
+			if got := json.Valid(bytes.TrimSpace(out.Bytes())); got != tt.json {
+				t.Errorf("Logged %q, want JSON = %v", out.String(), tt.json)
+			}
+			if !strings.Contains(out.String(), "hello") {
+				t.Errorf("Logged %q, want the message", out.String())
+			}
 		})
 	}
 }
+
+func TestParseInvalidFlags(t *testing.T) {
+	for _, args := range [][]string{{"-log-format", "xml"}, {"-unknown"}, {"-debug=maybe"}, {"-trusted-proxies", "10.0.0.1"}} {
+		if _, err := parseFlags(args, io.Discard); err == nil {
+			t.Errorf("parseFlags(%q) succeeded, want an error", args)
+		}
+	}
+}
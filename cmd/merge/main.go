@@ -0,0 +1,133 @@
+// Command merge combines the databases of several Scouts into a new database, see package merge.
+//
+//	merge -out merged.db [-accept ask|all|none] ann.db bo.db ...
+//
+// Players that are probably the same, by name and birthdate, are shown one pair at a time to confirm whether they
+// should be merged, unless -accept says otherwise. The profile fields the databases disagree on are listed at the end.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/merge"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	out := flags.String("out", "", "path of the merged database, which must not exist yet")
+	accept := flags.String("accept", "ask", "which probable matches to merge: ask, all or none")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" || flags.NArg() < 2 {
+		return errors.New("usage: merge -out merged.db [-accept ask|all|none] a.db b.db ...")
+	}
+	if *accept != "ask" && *accept != "all" && *accept != "none" {
+		return fmt.Errorf("-accept must be ask, all or none, not %q", *accept)
+	}
+	if _, err := os.Stat(*out); !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s already exists", *out)
+	}
+
+	var sources []merge.Source
+	for _, path := range flags.Args() {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+		// Sources are only read, so that merging never changes the databases of the Scouts.
+		db, err := database.LoadReadOnly(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer database.SaveToFile(db)
+		sources = append(sources, merge.Source{Name: filepath.Base(path), DB: db})
+	}
+	plan, err := merge.NewPlan(sources)
+	if err != nil {
+		return err
+	}
+
+	answers := bufio.NewScanner(stdin)
+	for i, c := range plan.Candidates {
+		confirmed := *accept == "all"
+		if *accept == "ask" {
+			fmt.Fprintf(stdout, "(%d/%d) Are these the same player? [y/N]\n", i+1, len(plan.Candidates))
+			fmt.Fprintf(stdout, "  %s\n  %s\n", describe(plan, c.A), describe(plan, c.B))
+			confirmed = answers.Scan() && strings.HasPrefix(strings.ToLower(strings.TrimSpace(answers.Text())), "y")
+		}
+		if confirmed {
+			plan.Confirm(c)
+		}
+	}
+
+	merged, err := database.Load(*out)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *out, err)
+	}
+	report, err := merge.Merge(merged, plan)
+	if saveErr := database.SaveToFile(merged); err == nil {
+		err = saveErr
+	}
+	if err != nil {
+		os.Remove(*out)
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Wrote %d players, %d of them found in several databases, and %d analyses to %s.\n",
+		report.Players, report.Merged, report.Analyses, *out)
+	if report.Duplicates > 0 {
+		fmt.Fprintf(stdout, "Left out %d analyses found in several databases.\n", report.Duplicates)
+	}
+	if len(report.Conflicts) > 0 {
+		fmt.Fprintf(stdout, "The databases disagree on %d fields, which kept the most recent value:\n", len(report.Conflicts))
+		for _, c := range report.Conflicts {
+			fmt.Fprintf(stdout, "  %s (%s) %s: %q, from %s\n", c.PlayerName, c.PlayerID, c.Field, c.Kept, values(plan, c))
+		}
+	}
+	return nil
+}
+
+// describe shows a player of a candidate with what they were matched by.
+func describe(plan *merge.Plan, ref merge.PlayerRef) string {
+	var birthdate, club string
+	if ref.Profile != nil {
+		birthdate, club = ref.Profile.Birthdate, ref.Profile.Club
+	}
+	return fmt.Sprintf("%s, born %s, %s (%s)", ref.Player.Name, birthdate, club, plan.Sources[ref.Source].Name)
+}
+
+// values lists the values of a conflict by the player and source they came from.
+func values(plan *merge.Plan, c merge.Conflict) string {
+	var refs []merge.PlayerRef
+	for ref := range c.Values {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Source != refs[j].Source {
+			return refs[i].Source < refs[j].Source
+		}
+		return refs[i].Player.CreatedAt.Before(refs[j].Player.CreatedAt)
+	})
+	values := make([]string, len(refs))
+	for i, ref := range refs {
+		values[i] = fmt.Sprintf("%q for %s in %s", c.Values[ref], ref.Player.Name, plan.Sources[ref.Source].Name)
+	}
+	return strings.Join(values, ", ")
+}
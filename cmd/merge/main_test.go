@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"Jon Smith", "John Smith"} {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "")+".db")
+		db, err := database.Load(path)
+		if err != nil {
+			t.Fatalf("Failed to load database: %v", err)
+		}
+		player := &database.Player{Name: name}
+		if err := db.Create(player).Error; err != nil {
+			t.Fatalf("Failed to create Player: %v", err)
+		}
+		if err := db.Create(&database.PlayerAnalysis{PlayerID: player.ID, Birthdate: "01/02/03"}).Error; err != nil {
+			t.Fatalf("Failed to create PlayerAnalysis: %v", err)
+		}
+		if err := database.SaveToFile(db); err != nil {
+			t.Fatalf("SaveToFile() failed: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	var before [][]byte
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read database: %v", err)
+		}
+		before = append(before, data)
+	}
+
+	out := filepath.Join(dir, "merged.db")
+	var stdout bytes.Buffer
+	if err := run(append([]string{"-out", out}, paths...), strings.NewReader("y\n"), &stdout, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Wrote 1 players") {
+		t.Errorf("Expected the confirmed players to be merged, got %s", stdout.String())
+	}
+	for i, path := range paths {
+		if after, err := os.ReadFile(path); err != nil || !bytes.Equal(before[i], after) {
+			t.Errorf("Expected %s to be left unchanged (%v)", path, err)
+		}
+	}
+	if err := run(append([]string{"-out", out}, paths...), strings.NewReader(""), io.Discard, io.Discard); err == nil {
+		t.Errorf("Expected run() to refuse an existing output database")
+	}
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DateFormat is the layout of Analysis.Date and Event.Date.
+const DateFormat = "2006-01-02 15:04"
+
+// ParseDate parses a date stored in DateFormat. The time of day may be omitted.
+func ParseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(DateFormat, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date %q is not of the form yyyy-mm-dd hh:mm", s)
+	}
+	return t, nil
+}
+
+// LatestAnalyses returns the most recent analyses across all players, newest first.
+func LatestAnalyses(db *gorm.DB, limit int) ([]*Analysis, error) {
+	var analyses []*Analysis
+	if err := db.Order("date DESC").Limit(limit).Find(&analyses).Error; err != nil {
+		return nil, fmt.Errorf("retrieving latest Analyses failed: %w", err)
+	}
+	return analyses, nil
+}
+
+// AnalysisBatchSize is how many analyses EachAnalysisBatch loads at a time when going through every analysis, e.g.
+// to export or score them.
+const AnalysisBatchSize = 500
+
+// LoadAnalysisDetails loads the detailed analyses linked from each Analysis. The result is in the same order as
+// analyses.
+func LoadAnalysisDetails(db *gorm.DB, analyses []*Analysis) ([]*AnalysisDetail, error) {
+	details := make([]*AnalysisDetail, len(analyses))
+	ids := map[RatingGroup][]uuid.UUID{}
+	for i, a := range analyses {
+		details[i] = &AnalysisDetail{Analysis: a}
+		for g, id := range a.groupIDs() {
+			if id != uuid.Nil {
+				ids[g] = append(ids[g], id)
+			}
+		}
+	}
+
+	defenders, err := findByIDs[DefenderAnalysis](db, ids[DefenderRatings])
+	if err != nil {
+		return nil, err
+	}
+	midfielders, err := findByIDs[MidfielderAnalysis](db, ids[MidfielderRatings])
+	if err != nil {
+		return nil, err
+	}
+	forwards, err := findByIDs[ForwardAnalysis](db, ids[ForwardRatings])
+	if err != nil {
+		return nil, err
+	}
+	tacticals, err := findByIDs[TacticalAnalysis](db, ids[TacticalRatings])
+	if err != nil {
+		return nil, err
+	}
+	athletics, err := findByIDs[AthleticAnalysis](db, ids[AthleticRatings])
+	if err != nil {
+		return nil, err
+	}
+	characters, err := findByIDs[CharacterAnalysis](db, ids[CharacterRatings])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range details {
+		d.Defender = defenders[d.DefenderAnalysisID]
+		d.Midfielder = midfielders[d.MidfielderAnalysisID]
+		d.Forward = forwards[d.ForwardAnalysisID]
+		d.Tactical = tacticals[d.TacticalAnalysisID]
+		d.Athletic = athletics[d.AthleticAnalysisID]
+		d.Character = characters[d.CharacterAnalysisID]
+	}
+	return details, nil
+}
+
+// groupIDs returns the ID of the detailed analysis linked for each group, which is uuid.Nil if there is none.
+func (a *Analysis) groupIDs() map[RatingGroup]uuid.UUID {
+	return map[RatingGroup]uuid.UUID{
+		DefenderRatings:   a.DefenderAnalysisID,
+		MidfielderRatings: a.MidfielderAnalysisID,
+		ForwardRatings:    a.ForwardAnalysisID,
+		TacticalRatings:   a.TacticalAnalysisID,
+		AthleticRatings:   a.AthleticAnalysisID,
+		CharacterRatings:  a.CharacterAnalysisID,
+	}
+}
+
+// findByIDs loads the rows with the given IDs, keyed by ID. T must be one of the models in schema.go.
+func findByIDs[T any](db *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]*T, error) {
+	byID := map[uuid.UUID]*T{}
+	if len(ids) == 0 {
+		return byID, nil
+	}
+	var rows []*T
+	if err := db.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("retrieving %T rows by ID failed: %w", *new(T), err)
+	}
+	for _, r := range rows {
+		byID[idOf(r)] = r
+	}
+	return byID, nil
+}
+
+// idOf returns the primary key of a row from any table in the schema.
+func idOf(row any) uuid.UUID {
+	return row.(interface{ primaryKey() uuid.UUID }).primaryKey()
+}
+
+func (bm *BaseModel) primaryKey() uuid.UUID {
+	return bm.ID
+}
+
+// AnalysesForPlayer returns every analysis of a player in chronological order.
+func AnalysesForPlayer(db *gorm.DB, playerID uuid.UUID) ([]*AnalysisDetail, error) {
+	var analyses []*Analysis
+	if err := db.Where("player_id = ?", playerID).Order("date").Find(&analyses).Error; err != nil {
+		return nil, fmt.Errorf("retrieving Analyses for Player %v failed: %w", playerID, err)
+	}
+	return LoadAnalysisDetails(db, analyses)
+}
+
+// AnalysesForPlayers returns every analysis of the given players in chronological order, keyed by PlayerID.
+func AnalysesForPlayers(db *gorm.DB, playerIDs []uuid.UUID) (map[uuid.UUID][]*AnalysisDetail, error) {
+	var analyses []*Analysis
+	if err := db.Where("player_id IN ?", playerIDs).Order("date").Find(&analyses).Error; err != nil {
+		return nil, fmt.Errorf("retrieving Analyses for Players %v failed: %w", playerIDs, err)
+	}
+	details, err := LoadAnalysisDetails(db, analyses)
+	if err != nil {
+		return nil, err
+	}
+	byPlayer := map[uuid.UUID][]*AnalysisDetail{}
+	for _, d := range details {
+		byPlayer[d.PlayerID] = append(byPlayer[d.PlayerID], d)
+	}
+	return byPlayer, nil
+}
+
+// EachAnalysisBatch calls fn with the details of every analysis that keep accepts, in chronological order, loading
+// at most size analyses at a time so that exports don't hold the whole database in memory. A nil keep accepts every
+// analysis. Iteration stops at the first error returned by fn.
+func EachAnalysisBatch(db *gorm.DB, size int, keep func(*Analysis) bool, fn func([]*AnalysisDetail) error) error {
+	for offset := 0; ; offset += size {
+		var analyses []*Analysis
+		if err := db.Order("date, id").Limit(size).Offset(offset).Find(&analyses).Error; err != nil {
+			return fmt.Errorf("retrieving Analyses from %d failed: %w", offset, err)
+		}
+		kept := analyses[:0:0]
+		for _, a := range analyses {
+			if keep == nil || keep(a) {
+				kept = append(kept, a)
+			}
+		}
+		if len(kept) > 0 {
+			details, err := LoadAnalysisDetails(db, kept)
+			if err != nil {
+				return err
+			}
+			if err := fn(details); err != nil {
+				return err
+			}
+		}
+		if len(analyses) < size {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestEachAnalysisBatch(t *testing.T) {
+	db := CreateTestDB(t)
+	kept := createTestPlayer(t, db, "Kept", Forward)
+	skipped := createTestPlayer(t, db, "Skipped", Forward)
+	dates := []string{"2024-03-01 15:00", "2024-01-01 15:00", "2024-02-01 15:00", "2024-05-01 15:00", "2024-04-01 15:00"}
+	for _, date := range dates {
+		createTestAnalysis(t, db, kept.ID, date, &TacticalAnalysis{Vision: 7, Awareness: Unrated, MovementOffTheBall: Unrated})
+	}
+	createTestAnalysis(t, db, skipped.ID, "2024-01-15 15:00", nil)
+
+	var batches [][]string
+	err := EachAnalysisBatch(db, 2, func(a *Analysis) bool { return a.PlayerID == kept.ID }, func(details []*AnalysisDetail) error {
+		var batch []string
+		for _, d := range details {
+			if rating, ok := d.Rating(Attribute{TacticalRatings, "Vision"}); !ok || rating != 7 {
+				t.Errorf("Expected the details of analysis %s to be loaded", d.Date)
+			}
+			batch = append(batch, d.Date)
+		}
+		batches = append(batches, batch)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachAnalysisBatch() failed: %v", err)
+	}
+	// The batch with the skipped analysis only contains one kept analysis.
+	want := [][]string{{"2024-01-01 15:00"}, {"2024-02-01 15:00", "2024-03-01 15:00"}, {"2024-04-01 15:00", "2024-05-01 15:00"}}
+	if len(batches) != len(want) {
+		t.Fatalf("Expected batches %v, got %v", want, batches)
+	}
+	for i := range want {
+		if len(batches[i]) != len(want[i]) {
+			t.Fatalf("Expected batches %v, got %v", want, batches)
+		}
+		for j := range want[i] {
+			if batches[i][j] != want[i][j] {
+				t.Errorf("Expected batches %v, got %v", want, batches)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ChangeLogModels returns a new, empty value of every model that records the changes to a database so that it can be
+// synced with other copies, or replicas, of it, see package replication. They aren't part of Models: they describe
+// the database rather than the Scout's data, so they are neither synced, archived nor merged.
+func ChangeLogModels() []any {
+	return []any{&Change{}, &SyncState{}, &SyncConflict{}}
+}
+
+// ReplicatedModels returns a new, empty value of every model in Models that is synced between replicas: the scouting
+// data. The Scout's row holds the secrets of their share links and calendar feed, which, like the share links and
+// their access log, stay in the replica they were made in. Drafts only matter to the replica they were saved to.
+func ReplicatedModels() []any {
+	return []any{
+		&Player{},
+		&PlayerAnalysis{},
+		&Analysis{},
+		&DefenderAnalysis{},
+		&MidfielderAnalysis{},
+		&ForwardAnalysis{},
+		&TacticalAnalysis{},
+		&AthleticAnalysis{},
+		&CharacterAnalysis{},
+		&Event{},
+		&EventPlayer{},
+		&WeightProfile{},
+		&ProfileWeight{},
+		&Publication{},
+	}
+}
+
+// Change records that a row was created, updated or deleted.
+type Change struct {
+	// Seq orders the changes of a database. Replicas keep the Seq of the last change they exchanged as a cursor.
+	Seq   int64     `gorm:"primaryKey;autoIncrement"`
+	Table string    `gorm:"column:table_name;index:idx_change_row"`
+	RowID uuid.UUID `gorm:"index:idx_change_row;type:uuid"`
+	// Deleted is set when the row no longer exists. Soft-deleted rows still exist, with their DeletedAt set.
+	Deleted bool
+	// Replica identifies the replica the change was made in, see SyncState.Replica.
+	Replica   string
+	ChangedAt time.Time
+}
+
+// SyncState is a database's own side of syncing with a server. Every database has a single SyncState.
+type SyncState struct {
+	BaseModel
+	// Replica identifies the database among the replicas it syncs with.
+	Replica string
+	// Server is the URL of the server the database last synced with.
+	Server string
+	// Pulled is the Seq, on the server, of the last change pulled from it.
+	Pulled int64
+	// Pushed is the Seq of the last change of this database that the server received.
+	Pushed   int64
+	SyncedAt *time.Time
+}
+
+// SyncConflict records that a row was changed by two replicas at once, and which version was kept. Conflicts are
+// resolved automatically so that replicas agree, and await a decision in the conflict inbox until the Scout either
+// accepts the kept version or restores the discarded one.
+type SyncConflict struct {
+	BaseModel
+	Table string    `gorm:"column:table_name"`
+	RowID uuid.UUID `gorm:"type:uuid"`
+	// Kept and Discarded are the JSON of each version of the row, or empty if that version deleted it.
+	Kept      string
+	Discarded string
+	// DiscardedBy is the replica that made the discarded change.
+	DiscardedBy string
+	ResolvedAt  *time.Time
+}
+
+// LocalSyncState returns the database's SyncState, creating it with a new Replica if it doesn't exist yet.
+func LocalSyncState(db *gorm.DB) (*SyncState, error) {
+	var state SyncState
+	if err := db.Order("created_at").Attrs(SyncState{Replica: uuid.NewString()}).FirstOrCreate(&state).Error; err != nil {
+		return nil, fmt.Errorf("retrieving the sync state failed: %w", err)
+	}
+	return &state, nil
+}
+
+// SyncConflicts returns the conflicts that await a decision, oldest first.
+func SyncConflicts(db *gorm.DB) ([]*SyncConflict, error) {
+	var conflicts []*SyncConflict
+	if err := db.Where("resolved_at IS NULL").Order("created_at").Find(&conflicts).Error; err != nil {
+		return nil, fmt.Errorf("retrieving sync conflicts failed: %w", err)
+	}
+	return conflicts, nil
+}
+
+// SyncConflictByID returns a conflict that awaits a decision.
+func SyncConflictByID(db *gorm.DB, id uuid.UUID) (*SyncConflict, error) {
+	var conflict SyncConflict
+	if err := db.Where("resolved_at IS NULL").First(&conflict, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("retrieving sync conflict %v failed: %w", id, err)
+	}
+	return &conflict, nil
+}
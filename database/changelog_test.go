@@ -0,0 +1,48 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestLocalSyncState(t *testing.T) {
+	db := CreateTestDB(t)
+	state, err := LocalSyncState(db)
+	if err != nil {
+		t.Fatalf("LocalSyncState() failed: %v", err)
+	}
+	if state.Replica == "" {
+		t.Errorf("Expected the database to be given a replica ID")
+	}
+	again, err := LocalSyncState(db)
+	if err != nil {
+		t.Fatalf("LocalSyncState() failed: %v", err)
+	}
+	if again.ID != state.ID || again.Replica != state.Replica {
+		t.Errorf("Expected the same sync state, got %+v and %+v", state, again)
+	}
+}
+
+func TestSyncConflicts(t *testing.T) {
+	db := CreateTestDB(t)
+	now := time.Now()
+	open, resolved := &SyncConflict{Table: "players"}, &SyncConflict{Table: "players", ResolvedAt: &now}
+	for _, c := range []*SyncConflict{open, resolved} {
+		if err := db.Create(c).Error; err != nil {
+			t.Fatalf("Failed to create SyncConflict: %v", err)
+		}
+	}
+	conflicts, err := SyncConflicts(db)
+	if err != nil {
+		t.Fatalf("SyncConflicts() failed: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].ID != open.ID {
+		t.Errorf("Expected only the open conflict, got %+v", conflicts)
+	}
+	if _, err := SyncConflictByID(db, resolved.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("SyncConflictByID() of a resolved conflict returned %v, expected gorm.ErrRecordNotFound", err)
+	}
+}
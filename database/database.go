@@ -2,35 +2,36 @@ package database
 
 import (
 	"fmt"
+	"strings"
+
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 // Load opens the database at the given path. If `path == ""` then a new in-memory database is returned.
 func Load(path string) (*gorm.DB, error) {
+	return load(path, append(Models(), ChangeLogModels()...)...)
+}
+
+// load opens the database at the given path and migrates it to the given models.
+func load(path string, models ...any) (*gorm.DB, error) {
 	// Default to an in-memory database.
 	// https://gorm.io/docs/connecting_to_the_database.html#SQLite
 	if path == "" {
 		path = "file::memory:?cache=shared"
 	}
-	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+		Logger: queryLogger{},
+		// Translate driver errors such as unique constraint violations into gorm's typed errors, e.g.
+		// gorm.ErrDuplicatedKey, so that callers can tell them apart.
+		TranslateError: true,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Auto Migrate the schemas
-	err = db.AutoMigrate(
-		&Player{},
-		&PlayerAnalysis{},
-		&Analysis{},
-		&DefenderAnalysis{},
-		&MidfielderAnalysis{},
-		&ForwardAnalysis{},
-		&TacticalAnalysis{},
-		&AthleticAnalysis{},
-		&CharacterAnalysis{},
-		&Scout{},
-	)
+	err = db.AutoMigrate(models...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate database to current schema: %w", err)
 	}
@@ -38,6 +39,27 @@ func Load(path string) (*gorm.DB, error) {
 	return db, nil
 }
 
+// uriEscaper escapes the characters of a path that have a meaning in an SQLite URI filename.
+var uriEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+// LoadReadOnly opens the existing database at the given path without migrating it, e.g. to read the database of
+// another Scout. Nothing can be written to it.
+func LoadReadOnly(path string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open("file:"+uriEscaper.Replace(path)+"?mode=ro"), &gorm.Config{
+		Logger:         queryLogger{},
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// sqlite only reports a missing file on the first query.
+	if err := db.Exec("SELECT 1 FROM sqlite_master").Error; err != nil {
+		SaveToFile(db)
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
 // SaveToFile saves the database to the same file path used when opening it. In-memory databases cannot be saved to
 // files, but will not return an error.
 func SaveToFile(db *gorm.DB) error {
@@ -1,22 +1,16 @@
 package database
 
 import (
-	"github.com/google/uuid"
-	"gorm.io/gorm"
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
-)
 
-func createTestDB(t *testing.T) *gorm.DB {
-	t.Helper()
-	db, err := Load("")
-	if err != nil {
-		t.Fatalf("Failed to create test database: %v", err)
-	}
-	return db
-}
+	"github.com/google/uuid"
+)
 
 func TestAddPlayer(t *testing.T) {
-	db := createTestDB(t)
+	db := CreateTestDB(t)
 
 	newPlayer := &Player{
 		Name: "Name",
@@ -43,7 +37,7 @@ func TestAddPlayer(t *testing.T) {
 }
 
 func TestUpdatePlayer(t *testing.T) {
-	db := createTestDB(t)
+	db := CreateTestDB(t)
 
 	newPlayer := &Player{
 		Name: "Original Name",
@@ -68,7 +62,7 @@ func TestUpdatePlayer(t *testing.T) {
 }
 
 func TestAddSingleAnalysis(t *testing.T) {
-	db := createTestDB(t)
+	db := CreateTestDB(t)
 
 	newPlayer := &Player{
 		Name: "Name",
@@ -123,7 +117,7 @@ func TestAddSingleAnalysis(t *testing.T) {
 }
 
 func TestAddScout(t *testing.T) {
-	db := createTestDB(t)
+	db := CreateTestDB(t)
 
 	newScout := &Scout{
 		Username: "username",
@@ -144,3 +138,43 @@ func TestAddScout(t *testing.T) {
 		t.Errorf("Expected Scout email to be %q, got %q", newScout.Email, retrievedScout.Email)
 	}
 }
+
+func TestLoadReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scout #1.db")
+	db, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if err := db.Create(&Player{Name: "Name"}).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	if err := SaveToFile(db); err != nil {
+		t.Fatalf("SaveToFile() failed: %v", err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read database: %v", err)
+	}
+
+	db, err = LoadReadOnly(path)
+	if err != nil {
+		t.Fatalf("LoadReadOnly() failed: %v", err)
+	}
+	players, err := AllPlayers(db)
+	if err != nil || len(players) != 1 {
+		t.Errorf("Expected 1 player, got %d (%v)", len(players), err)
+	}
+	if err := db.Create(&Player{Name: "Other"}).Error; err == nil {
+		t.Errorf("Expected writing to a read-only database to fail")
+	}
+	if err := SaveToFile(db); err != nil {
+		t.Fatalf("SaveToFile() failed: %v", err)
+	}
+	if after, err := os.ReadFile(path); err != nil || !bytes.Equal(before, after) {
+		t.Errorf("Expected the database file to be unchanged (%v)", err)
+	}
+
+	if _, err := LoadReadOnly(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Errorf("Expected LoadReadOnly() to fail for a missing database")
+	}
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AnalysisDraft is an Analysis drafted in the browser, possibly while offline, and queued until it can be sent.
+type AnalysisDraft struct {
+	// ClientID is generated by the browser when the draft is created and stays the same however often it is sent.
+	ClientID         uuid.UUID        `json:"clientId"`
+	PlayerID         uuid.UUID        `json:"playerId"`
+	Category         AnalysisCategory `json:"category"`
+	Date             string           `json:"date"`
+	PlayTimeMinutes  *int             `json:"playTimeMinutes"`
+	WeatherCondition string           `json:"weatherCondition"`
+	Venue            string           `json:"venue"`
+	Notes            string           `json:"notes"`
+	// Ratings maps Attribute keys, e.g. "Tactical.Vision", to ratings. Groups without any ratings aren't recorded,
+	// and attributes missing from a recorded group are Unrated.
+	Ratings map[string]int `json:"ratings"`
+}
+
+// ErrInvalidDraft is wrapped by errors about drafts that can't be saved.
+var ErrInvalidDraft = errors.New("invalid analysis draft")
+
+// SaveDraft saves a draft as an Analysis, along with the detailed analyses of the groups it rates. A draft that was
+// already saved is not saved again: its Analysis ID is returned with saved set to false.
+func SaveDraft(db *gorm.DB, draft *AnalysisDraft) (analysisID uuid.UUID, saved bool, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// The draft is claimed before it is saved, so that when the same draft is sent twice at once only one of them
+		// gets to save it.
+		synced := &SyncedDraft{ClientID: draft.ClientID}
+		claim := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "client_id"}}, DoNothing: true}).Create(synced)
+		if claim.Error != nil {
+			return fmt.Errorf("recording draft %v as saved failed: %w", draft.ClientID, claim.Error)
+		}
+		if claim.RowsAffected == 0 {
+			var existing SyncedDraft
+			if err := tx.Where("client_id = ?", draft.ClientID).First(&existing).Error; err != nil {
+				return fmt.Errorf("retrieving saved draft %v failed: %w", draft.ClientID, err)
+			}
+			analysisID = existing.AnalysisID
+			return nil
+		}
+
+		analysis, groups, err := draftAnalysis(tx, draft)
+		if err != nil {
+			return err
+		}
+		for _, g := range RatingGroups {
+			ratings, ok := groups[g]
+			if !ok {
+				continue
+			}
+			if err := tx.Create(ratings.Interface()).Error; err != nil {
+				return fmt.Errorf("saving %s ratings of draft %v failed: %w", g, draft.ClientID, err)
+			}
+			id := idOf(ratings.Interface())
+			reflect.ValueOf(analysis).Elem().FieldByName(string(g) + "AnalysisID").Set(reflect.ValueOf(id))
+		}
+		if err := tx.Create(analysis).Error; err != nil {
+			return fmt.Errorf("saving Analysis of draft %v failed: %w", draft.ClientID, err)
+		}
+		if err := tx.Model(synced).Update("analysis_id", analysis.ID).Error; err != nil {
+			return fmt.Errorf("recording draft %v as saved failed: %w", draft.ClientID, err)
+		}
+		analysisID, saved = analysis.ID, true
+		return nil
+	})
+	return analysisID, saved, err
+}
+
+// draftAnalysis validates a draft and converts it to an Analysis, without its links to detailed analyses, and a
+// pointer to a detailed analysis for each rated group.
+func draftAnalysis(db *gorm.DB, draft *AnalysisDraft) (*Analysis, map[RatingGroup]reflect.Value, error) {
+	if draft.ClientID == uuid.Nil {
+		return nil, nil, fmt.Errorf("%w: the draft has no ID", ErrInvalidDraft)
+	}
+	if _, err := PlayerByID(db, draft.PlayerID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, fmt.Errorf("%w: unknown player %v", ErrInvalidDraft, draft.PlayerID)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	switch draft.Category {
+	case Match, Training, Other:
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown category %q", ErrInvalidDraft, draft.Category)
+	}
+	date, err := ParseDate(draft.Date)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidDraft, err)
+	}
+	if draft.PlayTimeMinutes != nil && *draft.PlayTimeMinutes < 0 {
+		return nil, nil, fmt.Errorf("%w: play time can't be negative", ErrInvalidDraft)
+	}
+
+	groups := map[RatingGroup]reflect.Value{}
+	for key, rating := range draft.Ratings {
+		attribute, err := ParseAttribute(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidDraft, err)
+		}
+		if !IsRated(rating) {
+			return nil, nil, fmt.Errorf("%w: the rating of %v must be between %d and %d", ErrInvalidDraft, attribute, MinRating, MaxRating)
+		}
+		ratings, ok := groups[attribute.Group]
+		if !ok {
+			ratings = unratedGroup(attribute.Group)
+			groups[attribute.Group] = ratings
+		}
+		ratings.Elem().FieldByName(attribute.Name).SetInt(int64(rating))
+	}
+
+	analysis := &Analysis{
+		PlayerID:         draft.PlayerID,
+		Category:         draft.Category,
+		Date:             date.Format(DateFormat),
+		PlayTimeMinutes:  draft.PlayTimeMinutes,
+		WeatherCondition: draft.WeatherCondition,
+		Venue:            draft.Venue,
+		Notes:            draft.Notes,
+	}
+	return analysis, groups, nil
+}
+
+// unratedGroup returns a pointer to a new detailed analysis for a group, with every attribute Unrated.
+func unratedGroup(g RatingGroup) reflect.Value {
+	ratings := reflect.New(ratingGroupTypes[g])
+	for _, a := range GroupAttributes(g) {
+		ratings.Elem().FieldByName(a.Name).SetInt(Unrated)
+	}
+	return ratings
+}
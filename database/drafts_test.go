@@ -0,0 +1,135 @@
+package database
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSaveDraft(t *testing.T) {
+	db := CreateTestDB(t)
+	player := createTestPlayer(t, db, "A", Forward)
+	minutes := 70
+	draft := &AnalysisDraft{
+		ClientID:        uuid.New(),
+		PlayerID:        player.ID,
+		Category:        Match,
+		Date:            "2024-05-01 15:00",
+		PlayTimeMinutes: &minutes,
+		Venue:           "Home",
+		Notes:           "Won every header.",
+		Ratings:         map[string]int{"Tactical.Vision": 7, "Forward.Heading": 0},
+	}
+
+	id, saved, err := SaveDraft(db, draft)
+	if err != nil {
+		t.Fatalf("SaveDraft() failed: %v", err)
+	}
+	if !saved {
+		t.Errorf("Expected a new draft to be saved")
+	}
+	analyses, err := AnalysesForPlayer(db, player.ID)
+	if err != nil {
+		t.Fatalf("AnalysesForPlayer() failed: %v", err)
+	}
+	if len(analyses) != 1 || analyses[0].ID != id {
+		t.Fatalf("Expected the saved analysis, got %v", analyses)
+	}
+	a := analyses[0]
+	if a.Venue != "Home" || a.Notes != "Won every header." || *a.PlayTimeMinutes != 70 || a.HasGroup(AthleticRatings) {
+		t.Errorf("Unexpected analysis %+v", a.Analysis)
+	}
+	if r, ok := a.Rating(Attribute{TacticalRatings, "Vision"}); !ok || r != 7 {
+		t.Errorf("Expected a vision rating of 7, got %d", r)
+	}
+	if r, ok := a.Rating(Attribute{ForwardRatings, "Heading"}); !ok || r != 0 {
+		t.Errorf("Expected a heading rating of 0, got %d", r)
+	}
+	if _, ok := a.Rating(Attribute{TacticalRatings, "Awareness"}); ok {
+		t.Errorf("Expected attributes missing from the draft to be unrated")
+	}
+
+	again, saved, err := SaveDraft(db, draft)
+	if err != nil {
+		t.Fatalf("SaveDraft() failed the second time: %v", err)
+	}
+	if saved || again != id {
+		t.Errorf("Expected the draft to be recognised as saved as %v, got %v (saved %v)", id, again, saved)
+	}
+	var count int64
+	db.Model(&Analysis{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 analysis after saving a draft twice, got %d", count)
+	}
+}
+
+func TestSaveDraftConcurrently(t *testing.T) {
+	db := CreateTestDB(t)
+	player := createTestPlayer(t, db, "A", Forward)
+	draft := &AnalysisDraft{ClientID: uuid.New(), PlayerID: player.ID, Category: Match, Date: "2024-05-01 15:00"}
+
+	// The same draft is often sent again while the first request is in flight, e.g. when the connection comes back.
+	const sends = 8
+	ids := make([]uuid.UUID, sends)
+	saved := make([]bool, sends)
+	errs := make([]error, sends)
+	var wg sync.WaitGroup
+	for i := 0; i < sends; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i], saved[i], errs[i] = SaveDraft(db, draft)
+		}(i)
+	}
+	wg.Wait()
+
+	savedCount := 0
+	for i := 0; i < sends; i++ {
+		if errs[i] != nil {
+			t.Fatalf("SaveDraft() failed: %v", errs[i])
+		}
+		if ids[i] != ids[0] {
+			t.Errorf("Expected every send to return the same analysis, got %v and %v", ids[0], ids[i])
+		}
+		if saved[i] {
+			savedCount++
+		}
+	}
+	if savedCount != 1 {
+		t.Errorf("Expected the draft to be saved once, got %d", savedCount)
+	}
+	var count int64
+	db.Model(&Analysis{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 analysis after sending a draft %d times at once, got %d", sends, count)
+	}
+}
+
+func TestSaveDraftInvalid(t *testing.T) {
+	db := CreateTestDB(t)
+	player := createTestPlayer(t, db, "A", Forward)
+	valid := func() *AnalysisDraft {
+		return &AnalysisDraft{ClientID: uuid.New(), PlayerID: player.ID, Category: Training, Date: "2024-05-01"}
+	}
+	for name, modify := range map[string]func(d *AnalysisDraft){
+		"no ID":          func(d *AnalysisDraft) { d.ClientID = uuid.Nil },
+		"unknown player": func(d *AnalysisDraft) { d.PlayerID = uuid.New() },
+		"bad category":   func(d *AnalysisDraft) { d.Category = "Party" },
+		"bad date":       func(d *AnalysisDraft) { d.Date = "yesterday" },
+		"bad attribute":  func(d *AnalysisDraft) { d.Ratings = map[string]int{"Tactical.Juggling": 5} },
+		"bad rating":     func(d *AnalysisDraft) { d.Ratings = map[string]int{"Tactical.Vision": 11} },
+	} {
+		draft := valid()
+		modify(draft)
+		if _, _, err := SaveDraft(db, draft); !errors.Is(err, ErrInvalidDraft) {
+			t.Errorf("%s: expected ErrInvalidDraft, got %v", name, err)
+		}
+	}
+	var count int64
+	db.Model(&Analysis{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected invalid drafts not to be saved, got %d analyses", count)
+	}
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UpcomingEvent is an Event together with the players the Scout wants to watch there.
+type UpcomingEvent struct {
+	*Event
+	Players []*Player
+}
+
+// UpcomingEvents returns the events from the given time onwards, soonest first.
+func UpcomingEvents(db *gorm.DB, from time.Time, limit int) ([]*UpcomingEvent, error) {
+	var events []*Event
+	result := db.Where("date >= ?", from.Format(DateFormat)).Order("date").Limit(limit).Find(&events)
+	if result.Error != nil {
+		return nil, fmt.Errorf("retrieving upcoming Events failed: %w", result.Error)
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	eventIDs := make([]uuid.UUID, len(events))
+	for i, e := range events {
+		eventIDs[i] = e.ID
+	}
+	var links []*EventPlayer
+	if err := db.Where("event_id IN ?", eventIDs).Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("retrieving players to watch failed: %w", err)
+	}
+	playerIDs := make([]uuid.UUID, len(links))
+	for i, l := range links {
+		playerIDs[i] = l.PlayerID
+	}
+	players, err := PlayersByID(db, playerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	upcoming := make([]*UpcomingEvent, len(events))
+	byID := map[uuid.UUID]*UpcomingEvent{}
+	for i, e := range events {
+		upcoming[i] = &UpcomingEvent{Event: e}
+		byID[e.ID] = upcoming[i]
+	}
+	for _, l := range links {
+		if p, ok := players[l.PlayerID]; ok {
+			byID[l.EventID].Players = append(byID[l.EventID].Players, p)
+		}
+	}
+	return upcoming, nil
+}
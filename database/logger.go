@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/logging"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQuery is how long a query may take before it is logged as a warning.
+const slowQuery = 200 * time.Millisecond
+
+// queryLogger logs queries with the logger of the context they run in, so that they carry the request ID and Scout of
+// the request. Pass the context with db.WithContext. Failed queries are errors, slow queries are warnings and other
+// queries are only logged at the debug level.
+type queryLogger struct{}
+
+var _ gormlogger.Interface = queryLogger{}
+
+// LogMode is ignored; the level is chosen by the slog logger.
+func (l queryLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (queryLogger) Info(ctx context.Context, msg string, args ...any) {
+	logging.FromContext(ctx).InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (queryLogger) Warn(ctx context.Context, msg string, args ...any) {
+	logging.FromContext(ctx).WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (queryLogger) Error(ctx context.Context, msg string, args ...any) {
+	logging.FromContext(ctx).ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	logger := logging.FromContext(ctx)
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	switch {
+	// Missing records are expected, callers check for them.
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level = slog.LevelError
+	case elapsed > slowQuery:
+		level = slog.LevelWarn
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	sql, rows := fc()
+	attrs := []slog.Attr{slog.String("sql", sql), slog.Int64("rows", rows), slog.Duration("duration", elapsed)}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, level, "query", attrs...)
+}
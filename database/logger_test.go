@@ -0,0 +1,37 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/thirdknife/scoutingapp/logging"
+	"gorm.io/gorm"
+)
+
+func TestQueryLogger(t *testing.T) {
+	db := CreateTestDB(t)
+	var out bytes.Buffer
+	logger, err := logging.New(&out, "text", slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	ctx := logging.WithLogger(context.Background(), logger.With("request_id", "req-1"))
+
+	if err := db.WithContext(ctx).First(&Player{}).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Expected no players, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected successful queries and missing records not to be logged, got %q", out.String())
+	}
+
+	if err := db.WithContext(ctx).Exec("SELECT * FROM missing_table").Error; err == nil {
+		t.Fatalf("Expected the query to fail")
+	}
+	if got := out.String(); !strings.Contains(got, "level=ERROR") || !strings.Contains(got, "request_id=req-1") || !strings.Contains(got, "missing_table") {
+		t.Errorf("Expected the failed query to be logged with the request ID, got %q", got)
+	}
+}
@@ -2,6 +2,9 @@ package database
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -13,3 +16,161 @@ func AllPlayers(db *gorm.DB) ([]*Player, error) {
 	}
 	return players, nil
 }
+
+// PlayersByID returns the players with the given IDs, keyed by ID. Unknown IDs are ignored.
+func PlayersByID(db *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]*Player, error) {
+	return findByIDs[Player](db, ids)
+}
+
+// PlayerAnalysesByPlayer returns the PlayerAnalysis of every player that has one, keyed by PlayerID.
+func PlayerAnalysesByPlayer(db *gorm.DB) (map[uuid.UUID]*PlayerAnalysis, error) {
+	var playerAnalyses []*PlayerAnalysis
+	if err := db.Find(&playerAnalyses).Error; err != nil {
+		return nil, fmt.Errorf("retrieving all PlayerAnalyses failed: %w", err)
+	}
+	byPlayer := make(map[uuid.UUID]*PlayerAnalysis, len(playerAnalyses))
+	for _, pa := range playerAnalyses {
+		byPlayer[pa.PlayerID] = pa
+	}
+	return byPlayer, nil
+}
+
+// PositionCount is the number of players recorded for a position. Players without a PlayerAnalysis are counted
+// with an empty Position.
+type PositionCount struct {
+	Position PositionType
+	Count    int
+}
+
+// CountPlayersByPosition returns how many players there are per position, ordered by position.
+func CountPlayersByPosition(db *gorm.DB) ([]PositionCount, error) {
+	var counts []PositionCount
+	result := db.Model(&Player{}).
+		Select("COALESCE(player_analyses.position, '') AS position, COUNT(*) AS count").
+		Joins("LEFT JOIN player_analyses ON player_analyses.player_id = players.id AND player_analyses.deleted_at IS NULL").
+		Group("COALESCE(player_analyses.position, '')").
+		Order("position").
+		Scan(&counts)
+	if result.Error != nil {
+		return nil, fmt.Errorf("counting Players by position failed: %w", result.Error)
+	}
+	return counts, nil
+}
+
+// LastSeen is when a Player was last analysed. Date is empty if they were never analysed.
+type LastSeen struct {
+	PlayerID uuid.UUID
+	Name     string
+	Date     string
+}
+
+// PlayersNotSeenSince returns the players that haven't been analysed since the given time, including players that
+// were never analysed, least recently seen first.
+func PlayersNotSeenSince(db *gorm.DB, since time.Time) ([]LastSeen, error) {
+	var rows []LastSeen
+	result := db.Model(&Player{}).
+		Select("players.id AS player_id, players.name AS name, COALESCE(MAX(analyses.date), '') AS date").
+		Joins("LEFT JOIN analyses ON analyses.player_id = players.id AND analyses.deleted_at IS NULL").
+		Group("players.id").
+		Having("COALESCE(MAX(analyses.date), '') < ?", since.Format(DateFormat)).
+		Order("date, name").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("retrieving Players not seen since %v failed: %w", since, result.Error)
+	}
+	return rows, nil
+}
+
+// PlayerByID returns the player with the given ID. The error wraps gorm.ErrRecordNotFound if there is none.
+func PlayerByID(db *gorm.DB, id uuid.UUID) (*Player, error) {
+	var player Player
+	if err := db.First(&player, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("retrieving Player %v failed: %w", id, err)
+	}
+	return &player, nil
+}
+
+// PlayerAnalysisFor returns the PlayerAnalysis of a player, or nil if the Scout hasn't recorded one.
+func PlayerAnalysisFor(db *gorm.DB, playerID uuid.UUID) (*PlayerAnalysis, error) {
+	var playerAnalyses []*PlayerAnalysis
+	if err := db.Where("player_id = ?", playerID).Limit(1).Find(&playerAnalyses).Error; err != nil {
+		return nil, fmt.Errorf("retrieving PlayerAnalysis for Player %v failed: %w", playerID, err)
+	}
+	if len(playerAnalyses) == 0 {
+		return nil, nil
+	}
+	return playerAnalyses[0], nil
+}
+
+// birthdateFormats are the layouts accepted for PlayerAnalysis.Birthdate. The documented format is mm/dd/yy, but
+// ISO dates are common in existing data.
+var birthdateFormats = []string{"01/02/06", "01/02/2006", time.DateOnly}
+
+// BirthdateTime parses Birthdate.
+func (pa *PlayerAnalysis) BirthdateTime() (time.Time, error) {
+	for _, layout := range birthdateFormats {
+		if t, err := time.Parse(layout, pa.Birthdate); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("birthdate %q is not of the form mm/dd/yy", pa.Birthdate)
+}
+
+// Age returns the age in whole years of the player at the given time.
+func (pa *PlayerAnalysis) Age(now time.Time) (int, error) {
+	birthdate, err := pa.BirthdateTime()
+	if err != nil {
+		return 0, err
+	}
+	// Two digit years are parsed as 1969-2068, but a player can't be born in the future.
+	if birthdate.After(now) && len(pa.Birthdate) == len("mm/dd/yy") {
+		birthdate = birthdate.AddDate(-100, 0, 0)
+	}
+	age := now.Year() - birthdate.Year()
+	if now.Month() < birthdate.Month() || (now.Month() == birthdate.Month() && now.Day() < birthdate.Day()) {
+		age--
+	}
+	if age < 0 {
+		return 0, fmt.Errorf("birthdate %q is in the future", pa.Birthdate)
+	}
+	return age, nil
+}
+
+// PlayerSummary is what is listed about a player in the players table.
+type PlayerSummary struct {
+	*Player
+	// Profile is nil if the Scout hasn't recorded a PlayerAnalysis.
+	Profile *PlayerAnalysis
+	// LastSeen is the date of the latest Analysis, or empty if there is none.
+	LastSeen string
+}
+
+// PlayerSummaries returns a summary of every player.
+func PlayerSummaries(db *gorm.DB) ([]*PlayerSummary, error) {
+	players, err := AllPlayers(db)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := PlayerAnalysesByPlayer(db)
+	if err != nil {
+		return nil, err
+	}
+	var lastSeen []struct {
+		PlayerID uuid.UUID
+		Date     string
+	}
+	result := db.Model(&Analysis{}).Select("player_id, MAX(date) AS date").Group("player_id").Scan(&lastSeen)
+	if result.Error != nil {
+		return nil, fmt.Errorf("retrieving when Players were last seen failed: %w", result.Error)
+	}
+	lastSeenByPlayer := make(map[uuid.UUID]string, len(lastSeen))
+	for _, s := range lastSeen {
+		lastSeenByPlayer[s.PlayerID] = s.Date
+	}
+
+	summaries := make([]*PlayerSummary, len(players))
+	for i, p := range players {
+		summaries[i] = &PlayerSummary{Player: p, Profile: profiles[p.ID], LastSeen: lastSeenByPlayer[p.ID]}
+	}
+	return summaries, nil
+}
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func createTestPlayer(t *testing.T, db *gorm.DB, name string, position PositionType) *Player {
+	t.Helper()
+	player := &Player{Name: name}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	if position != "" {
+		if err := db.Create(&PlayerAnalysis{PlayerID: player.ID, Position: position}).Error; err != nil {
+			t.Fatalf("Failed to create PlayerAnalysis: %v", err)
+		}
+	}
+	return player
+}
+
+func createTestAnalysis(t *testing.T, db *gorm.DB, playerID uuid.UUID, date string, tactical *TacticalAnalysis) *Analysis {
+	t.Helper()
+	analysis := &Analysis{PlayerID: playerID, Category: Match, Date: date}
+	if tactical != nil {
+		if err := db.Create(tactical).Error; err != nil {
+			t.Fatalf("Failed to create TacticalAnalysis: %v", err)
+		}
+		analysis.TacticalAnalysisID = tactical.ID
+	}
+	if err := db.Create(analysis).Error; err != nil {
+		t.Fatalf("Failed to create Analysis: %v", err)
+	}
+	return analysis
+}
+
+func TestCountPlayersByPosition(t *testing.T) {
+	db := CreateTestDB(t)
+	createTestPlayer(t, db, "A", Defender)
+	createTestPlayer(t, db, "B", Defender)
+	createTestPlayer(t, db, "C", Forward)
+	createTestPlayer(t, db, "D", "")
+
+	counts, err := CountPlayersByPosition(db)
+	if err != nil {
+		t.Fatalf("CountPlayersByPosition() failed: %v", err)
+	}
+	want := []PositionCount{{"", 1}, {Defender, 2}, {Forward, 1}}
+	if len(counts) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, counts)
+	}
+	for i := range want {
+		if counts[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, counts)
+		}
+	}
+}
+
+func TestPlayersNotSeenSince(t *testing.T) {
+	db := CreateTestDB(t)
+	recent := createTestPlayer(t, db, "Recent", "")
+	old := createTestPlayer(t, db, "Old", "")
+	never := createTestPlayer(t, db, "Never", "")
+	createTestAnalysis(t, db, recent.ID, "2024-06-01 15:00", nil)
+	createTestAnalysis(t, db, recent.ID, "2023-01-01 15:00", nil)
+	createTestAnalysis(t, db, old.ID, "2024-01-01 15:00", nil)
+
+	got, err := PlayersNotSeenSince(db, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("PlayersNotSeenSince() failed: %v", err)
+	}
+	want := []LastSeen{
+		{PlayerID: never.ID, Name: "Never", Date: ""},
+		{PlayerID: old.ID, Name: "Old", Date: "2024-01-01 15:00"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestUpcomingEvents(t *testing.T) {
+	db := CreateTestDB(t)
+	player := createTestPlayer(t, db, "Watched", "")
+	past := &Event{Name: "Past", Date: "2024-01-01 15:00"}
+	next := &Event{Name: "Next", Date: "2024-05-01 15:00"}
+	later := &Event{Name: "Later", Date: "2024-06-01 15:00"}
+	for _, e := range []*Event{later, past, next} {
+		if err := db.Create(e).Error; err != nil {
+			t.Fatalf("Failed to create Event: %v", err)
+		}
+	}
+	if err := db.Create(&EventPlayer{EventID: next.ID, PlayerID: player.ID}).Error; err != nil {
+		t.Fatalf("Failed to create EventPlayer: %v", err)
+	}
+
+	got, err := UpcomingEvents(db, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 10)
+	if err != nil {
+		t.Fatalf("UpcomingEvents() failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "Next" || got[1].Name != "Later" {
+		t.Fatalf("Expected events Next and Later, got %v", got)
+	}
+	if len(got[0].Players) != 1 || got[0].Players[0].ID != player.ID {
+		t.Errorf("Expected %q to be watched at Next, got %v", player.Name, got[0].Players)
+	}
+}
+
+func TestPlayerAnalysisAge(t *testing.T) {
+	now := time.Date(2024, 7, 7, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		birthdate string
+		want      int
+		wantErr   bool
+	}{
+		{birthdate: "07/07/06", want: 18},
+		{birthdate: "07/08/06", want: 17},
+		{birthdate: "03/15/98", want: 26},
+		{birthdate: "12/31/2010", want: 13},
+		{birthdate: "2000-01-01", want: 24},
+		{birthdate: "", wantErr: true},
+		{birthdate: "next year", wantErr: true},
+		{birthdate: "2030-01-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.birthdate, func(t *testing.T) {
+			pa := &PlayerAnalysis{Birthdate: tt.birthdate}
+			got, err := pa.Age(now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Age() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Age() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlayerByID(t *testing.T) {
+	db := CreateTestDB(t)
+	player := createTestPlayer(t, db, "Name", "")
+
+	got, err := PlayerByID(db, player.ID)
+	if err != nil {
+		t.Fatalf("PlayerByID() failed: %v", err)
+	}
+	if got.Name != player.Name {
+		t.Errorf("Expected player %q, got %q", player.Name, got.Name)
+	}
+	if _, err := PlayerByID(db, uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for an unknown player, got %v", err)
+	}
+}
+
+func TestPlayerSummaries(t *testing.T) {
+	db := CreateTestDB(t)
+	seen := createTestPlayer(t, db, "Seen", Forward)
+	createTestAnalysis(t, db, seen.ID, "2024-03-01 15:00", nil)
+	createTestAnalysis(t, db, seen.ID, "2024-05-01 15:00", nil)
+	unseen := createTestPlayer(t, db, "Unseen", "")
+
+	summaries, err := PlayerSummaries(db)
+	if err != nil {
+		t.Fatalf("PlayerSummaries() failed: %v", err)
+	}
+	byID := map[uuid.UUID]*PlayerSummary{}
+	for _, s := range summaries {
+		byID[s.ID] = s
+	}
+	if len(byID) != 2 {
+		t.Fatalf("Expected 2 summaries, got %d", len(summaries))
+	}
+	if s := byID[seen.ID]; s.LastSeen != "2024-05-01 15:00" || s.Profile == nil || s.Profile.Position != Forward {
+		t.Errorf("Unexpected summary of seen player: %+v", s)
+	}
+	if s := byID[unseen.ID]; s.LastSeen != "" || s.Profile != nil {
+		t.Errorf("Unexpected summary of unseen player: %+v", s)
+	}
+}
@@ -0,0 +1,201 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Ratings are recorded on a scale from MinRating to MaxRating. Unrated is recorded when a Scout didn't give a rating.
+const (
+	MinRating = 0
+	MaxRating = 10
+	Unrated   = -1
+)
+
+// IsRated reports whether v is a rating given by a Scout, rather than Unrated or garbage.
+func IsRated(v int) bool {
+	return v >= MinRating && v <= MaxRating
+}
+
+// RatingGroup names one of the detailed analyses that an Analysis links to.
+type RatingGroup string
+
+const (
+	DefenderRatings   RatingGroup = "Defender"
+	MidfielderRatings RatingGroup = "Midfielder"
+	ForwardRatings    RatingGroup = "Forward"
+	TacticalRatings   RatingGroup = "Tactical"
+	AthleticRatings   RatingGroup = "Athletic"
+	CharacterRatings  RatingGroup = "Character"
+)
+
+// RatingGroups lists every RatingGroup in display order.
+var RatingGroups = []RatingGroup{
+	DefenderRatings,
+	MidfielderRatings,
+	ForwardRatings,
+	TacticalRatings,
+	AthleticRatings,
+	CharacterRatings,
+}
+
+var ratingGroupTypes = map[RatingGroup]reflect.Type{
+	DefenderRatings:   reflect.TypeOf(DefenderAnalysis{}),
+	MidfielderRatings: reflect.TypeOf(MidfielderAnalysis{}),
+	ForwardRatings:    reflect.TypeOf(ForwardAnalysis{}),
+	TacticalRatings:   reflect.TypeOf(TacticalAnalysis{}),
+	AthleticRatings:   reflect.TypeOf(AthleticAnalysis{}),
+	CharacterRatings:  reflect.TypeOf(CharacterAnalysis{}),
+}
+
+// PositionalRatings returns the RatingGroup that is specific to a position. Goalkeepers and unknown positions
+// don't have one, in which case ok is false.
+func PositionalRatings(p PositionType) (group RatingGroup, ok bool) {
+	switch p {
+	case Defender:
+		return DefenderRatings, true
+	case Midfielder:
+		return MidfielderRatings, true
+	case Forward:
+		return ForwardRatings, true
+	}
+	return "", false
+}
+
+// IsPositional reports whether the group only applies to a single position.
+func (g RatingGroup) IsPositional() bool {
+	return g == DefenderRatings || g == MidfielderRatings || g == ForwardRatings
+}
+
+// Attribute identifies a single rated field, such as DefenderAnalysis.Tackling.
+type Attribute struct {
+	Group RatingGroup
+	// Name is the Go field name in the group's analysis struct.
+	Name string
+}
+
+// Key is a stable string form of the attribute, e.g. "Defender.Tackling".
+func (a Attribute) Key() string {
+	return string(a.Group) + "." + a.Name
+}
+
+func (a Attribute) String() string {
+	return a.Key()
+}
+
+// ParseAttribute is the inverse of Attribute.Key.
+func ParseAttribute(key string) (Attribute, error) {
+	group, name, ok := strings.Cut(key, ".")
+	if !ok {
+		return Attribute{}, fmt.Errorf("attribute %q is not of the form Group.Name", key)
+	}
+	for _, a := range GroupAttributes(RatingGroup(group)) {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return Attribute{}, fmt.Errorf("unknown attribute %q", key)
+}
+
+// GroupAttributes lists the attributes of a group in the order they are declared in its analysis struct.
+func GroupAttributes(g RatingGroup) []Attribute {
+	t, ok := ratingGroupTypes[g]
+	if !ok {
+		return nil
+	}
+	var attributes []Attribute
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous || f.Type.Kind() != reflect.Int {
+			continue
+		}
+		attributes = append(attributes, Attribute{Group: g, Name: f.Name})
+	}
+	return attributes
+}
+
+// AllAttributes lists the attributes of every group, ordered as RatingGroups.
+func AllAttributes() []Attribute {
+	var attributes []Attribute
+	for _, g := range RatingGroups {
+		attributes = append(attributes, GroupAttributes(g)...)
+	}
+	return attributes
+}
+
+// AnalysisDetail is an Analysis together with the detailed analyses it links to. Links that were not filled in by
+// the Scout are nil.
+type AnalysisDetail struct {
+	*Analysis
+
+	Defender   *DefenderAnalysis
+	Midfielder *MidfielderAnalysis
+	Forward    *ForwardAnalysis
+	Tactical   *TacticalAnalysis
+	Athletic   *AthleticAnalysis
+	Character  *CharacterAnalysis
+}
+
+// group returns the analysis struct recorded for g, or nil if there is none.
+func (d *AnalysisDetail) group(g RatingGroup) any {
+	switch g {
+	case DefenderRatings:
+		if d.Defender != nil {
+			return d.Defender
+		}
+	case MidfielderRatings:
+		if d.Midfielder != nil {
+			return d.Midfielder
+		}
+	case ForwardRatings:
+		if d.Forward != nil {
+			return d.Forward
+		}
+	case TacticalRatings:
+		if d.Tactical != nil {
+			return d.Tactical
+		}
+	case AthleticRatings:
+		if d.Athletic != nil {
+			return d.Athletic
+		}
+	case CharacterRatings:
+		if d.Character != nil {
+			return d.Character
+		}
+	}
+	return nil
+}
+
+// Records returns the Analysis followed by every detailed analysis linked from it, e.g. to copy them to another
+// database.
+func (d *AnalysisDetail) Records() []any {
+	records := []any{d.Analysis}
+	for _, g := range RatingGroups {
+		if row := d.group(g); row != nil {
+			records = append(records, row)
+		}
+	}
+	return records
+}
+
+// HasGroup reports whether the Scout filled in the given group for this analysis.
+func (d *AnalysisDetail) HasGroup(g RatingGroup) bool {
+	return d.group(g) != nil
+}
+
+// Rating returns the rating recorded for an attribute. ok is false if the group wasn't recorded or the attribute
+// wasn't rated.
+func (d *AnalysisDetail) Rating(a Attribute) (rating int, ok bool) {
+	g := d.group(a.Group)
+	if g == nil {
+		return Unrated, false
+	}
+	f := reflect.ValueOf(g).Elem().FieldByName(a.Name)
+	if !f.IsValid() || f.Kind() != reflect.Int {
+		return Unrated, false
+	}
+	rating = int(f.Int())
+	return rating, IsRated(rating)
+}
@@ -0,0 +1,53 @@
+package database
+
+import "testing"
+
+func TestAllAttributes(t *testing.T) {
+	attributes := AllAttributes()
+	if len(attributes) != 43 {
+		t.Errorf("Expected 43 attributes across all rating groups, got %d", len(attributes))
+	}
+	for _, a := range attributes {
+		got, err := ParseAttribute(a.Key())
+		if err != nil {
+			t.Errorf("ParseAttribute(%q) failed: %v", a.Key(), err)
+		}
+		if got != a {
+			t.Errorf("ParseAttribute(%q) = %v, want %v", a.Key(), got, a)
+		}
+	}
+	if _, err := ParseAttribute("Defender.ID"); err == nil {
+		t.Error("Expected an error parsing a field that isn't a rating")
+	}
+}
+
+func TestLoadAnalysisDetails(t *testing.T) {
+	db := CreateTestDB(t)
+	player := createTestPlayer(t, db, "Name", Defender)
+	defender := &DefenderAnalysis{Tackling: 7, LeftFoot: Unrated}
+	db.Create(defender)
+	withDefender := &Analysis{PlayerID: player.ID, DefenderAnalysisID: defender.ID}
+	db.Create(withDefender)
+	empty := &Analysis{PlayerID: player.ID}
+	db.Create(empty)
+	if db.Error != nil {
+		t.Fatalf("Error during testdata setup: %v", db.Error)
+	}
+
+	details, err := LoadAnalysisDetails(db, []*Analysis{empty, withDefender})
+	if err != nil {
+		t.Fatalf("LoadAnalysisDetails() failed: %v", err)
+	}
+	if details[0].HasGroup(DefenderRatings) {
+		t.Error("Expected the first analysis to have no defender analysis")
+	}
+	if !details[1].HasGroup(DefenderRatings) || details[1].HasGroup(TacticalRatings) {
+		t.Error("Expected the second analysis to only have a defender analysis")
+	}
+	if r, ok := details[1].Rating(Attribute{DefenderRatings, "Tackling"}); !ok || r != 7 {
+		t.Errorf("Expected Tackling to be rated 7, got %d (rated: %v)", r, ok)
+	}
+	if _, ok := details[1].Rating(Attribute{DefenderRatings, "LeftFoot"}); ok {
+		t.Error("Expected LeftFoot to be unrated")
+	}
+}
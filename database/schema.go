@@ -1,10 +1,41 @@
 package database
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// SchemaVersion identifies the current schema. It must be increased whenever data stored by an older version can no
+// longer be read as the current models, e.g. when a field is renamed or its meaning changes. Adding fields or models
+// doesn't need a new version.
+const SchemaVersion = 1
+
+// Models returns a new, empty value of every model in the schema.
+func Models() []any {
+	return []any{
+		&Player{},
+		&PlayerAnalysis{},
+		&Analysis{},
+		&DefenderAnalysis{},
+		&MidfielderAnalysis{},
+		&ForwardAnalysis{},
+		&TacticalAnalysis{},
+		&AthleticAnalysis{},
+		&CharacterAnalysis{},
+		&Scout{},
+		&Event{},
+		&EventPlayer{},
+		&WeightProfile{},
+		&ProfileWeight{},
+		&SyncedDraft{},
+		&ShareLink{},
+		&ShareAccess{},
+		&Publication{},
+	}
+}
+
 type BaseModel struct {
 	gorm.Model
 	ID uuid.UUID `gorm:"primaryKey;type:uuid"`
@@ -23,6 +54,10 @@ type Player struct {
 	// The full name of the player. Keeping it in a single string allows any input, which is better
 	// than trying to deal with the intricacies of separating first and last names, nicknames, etc.
 	Name string
+
+	// Score is the overall score derived from the player's analyses by the scoring package. It is not stored, and is
+	// nil until it has been computed or if there are no ratings to compute it from.
+	Score *float64 `gorm:"-"`
 }
 
 type PositionType string
@@ -34,6 +69,13 @@ const (
 	Forward    PositionType = "Forward"
 )
 
+// Positions lists every PositionType in display order.
+var Positions = []PositionType{Goalkeeper, Defender, Midfielder, Forward}
+
+// DisplayPositions is every position followed by the empty position of players who don't have one, for listings
+// grouped by position.
+var DisplayPositions = append(append([]PositionType{}, Positions...), "")
+
 // PlayerAnalysis represents static information that a Scout might record about a Player.
 // There can only be one PLayerAnalysis per Player, so updates always override existing data.
 type PlayerAnalysis struct {
@@ -86,6 +128,8 @@ type Analysis struct {
 	Date             string // yyyy-mm-dd hh:mm
 	WeatherCondition string
 	Venue            string
+	// Notes is what the Scout wrote about the player's performance, in their own words.
+	Notes string
 }
 
 // All of the following analyses record various attributes of a player with a scale from [0-10]. 10 is best.
@@ -158,4 +202,96 @@ type Scout struct {
 	BaseModel
 	Username string
 	Email    string
+	// Language is the BCP 47 tag of the language the Scout chose for the user interface, e.g. "es". When empty, the
+	// language is chosen by the Scout's browser.
+	Language string
+	// CalendarToken is the secret in the URL of the Scout's calendar feed, which calendar apps fetch without logging
+	// in. It is empty until the feed is first used, see CalendarToken.
+	CalendarToken string
+	// ShareKey is the secret that signs the tokens of share links. It is empty until the first link is created, see
+	// ShareKey.
+	ShareKey []byte
+}
+
+// Event is a match or training session that a Scout plans to attend.
+type Event struct {
+	BaseModel
+
+	// A short description such as "Rovers v United".
+	Name        string
+	Date        string // yyyy-mm-dd hh:mm
+	Venue       string
+	Competition string
+	Notes       string
+	// UID identifies an Event imported from a calendar, so that importing the calendar again updates the Event rather
+	// than adding it twice. It is empty for events planned in the app.
+	UID string `gorm:"index"`
+}
+
+// EventPlayer marks a Player that a Scout wants to watch at an Event.
+type EventPlayer struct {
+	BaseModel
+	EventID  uuid.UUID `gorm:"foreignKey:EventID;type:uuid"`
+	PlayerID uuid.UUID `gorm:"foreignKey:PlayerID;type:uuid"`
+}
+
+// WeightProfile is a named set of weights that lets a team score players by the attributes its coaches value most.
+type WeightProfile struct {
+	BaseModel
+	Name        string
+	Description string
+}
+
+// ProfileWeight is the weight a WeightProfile gives to a single rated attribute. Attributes without a
+// ProfileWeight have no weight at all.
+type ProfileWeight struct {
+	BaseModel
+	WeightProfileID uuid.UUID `gorm:"foreignKey:WeightProfileID;type:uuid"`
+	// Attribute is the Attribute.Key of the rated attribute, e.g. "Defender.Tackling".
+	Attribute string
+	Weight    float64
+}
+
+// SyncedDraft records that an analysis drafted offline in the browser has been saved, so that sending the same
+// draft again doesn't save it twice.
+type SyncedDraft struct {
+	BaseModel
+	// ClientID is the ID the browser gave the draft when it was created.
+	ClientID   uuid.UUID `gorm:"uniqueIndex;type:uuid"`
+	AnalysisID uuid.UUID `gorm:"foreignKey:AnalysisID;type:uuid"`
+}
+
+// ShareLink lets someone without an account, such as an agent, view a Player's profile until the link expires or is
+// revoked.
+type ShareLink struct {
+	BaseModel
+	PlayerID uuid.UUID `gorm:"foreignKey:PlayerID;type:uuid"`
+	// Recipient says who the link was made for, e.g. "Academy director".
+	Recipient string
+	ExpiresAt time.Time
+	// PasswordHash is the bcrypt hash of the password the link asks for. It is empty if the link doesn't ask for one.
+	PasswordHash string
+	// RevokedAt is when the Scout revoked the link, or nil if they haven't.
+	RevokedAt *time.Time
+}
+
+// ShareOutcome is what happened when a ShareLink was opened.
+type ShareOutcome string
+
+const (
+	ShareViewed        ShareOutcome = "viewed"
+	ShareWrongPassword ShareOutcome = "wrongPassword"
+	ShareExpired       ShareOutcome = "expired"
+	ShareRevoked       ShareOutcome = "revoked"
+	// ShareLocked is an attempt refused without checking the password, after too many wrong ones.
+	ShareLocked ShareOutcome = "locked"
+)
+
+// ShareAccess records an attempt to view a ShareLink. CreatedAt is when it happened.
+type ShareAccess struct {
+	BaseModel
+	ShareLinkID uuid.UUID `gorm:"index;type:uuid"`
+	Outcome     ShareOutcome
+	IP          string
+	UserAgent   string
 }
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// LocalScout returns the Scout who owns the database. Every Scout has a database of their own, so there is at most
+// one; it is created if it doesn't exist yet.
+func LocalScout(db *gorm.DB) (*Scout, error) {
+	var scout Scout
+	if err := db.Order("created_at").FirstOrCreate(&scout).Error; err != nil {
+		return nil, fmt.Errorf("retrieving the Scout failed: %w", err)
+	}
+	return &scout, nil
+}
+
+// SetScoutLanguage records the language the Scout chose for the user interface. An empty language lets the Scout's
+// browser choose.
+func SetScoutLanguage(db *gorm.DB, language string) error {
+	scout, err := LocalScout(db)
+	if err != nil {
+		return err
+	}
+	if err := db.Model(scout).Update("language", language).Error; err != nil {
+		return fmt.Errorf("saving the language of the Scout failed: %w", err)
+	}
+	return nil
+}
+
+// CalendarToken returns the secret in the URL of the Scout's calendar feed, creating it if the Scout doesn't have one
+// yet.
+func CalendarToken(db *gorm.DB) (string, error) {
+	scout, err := LocalScout(db)
+	if err != nil {
+		return "", err
+	}
+	if scout.CalendarToken != "" {
+		return scout.CalendarToken, nil
+	}
+	return ResetCalendarToken(db)
+}
+
+// ResetCalendarToken replaces the secret in the URL of the Scout's calendar feed, so that the previous URL stops
+// working, and returns the new one.
+func ResetCalendarToken(db *gorm.DB) (string, error) {
+	scout, err := LocalScout(db)
+	if err != nil {
+		return "", err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return "", fmt.Errorf("generating a calendar token failed: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	if err := db.Model(scout).Update("calendar_token", token).Error; err != nil {
+		return "", fmt.Errorf("saving the calendar token of the Scout failed: %w", err)
+	}
+	return token, nil
+}
+
+// ShareKey returns the secret that signs the tokens of the Scout's share links, creating it if the Scout doesn't have
+// one yet.
+func ShareKey(db *gorm.DB) ([]byte, error) {
+	scout, err := LocalScout(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(scout.ShareKey) > 0 {
+		return scout.ShareKey, nil
+	}
+	key, err := newSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generating a share key failed: %w", err)
+	}
+	if err := db.Model(scout).Update("share_key", key).Error; err != nil {
+		return nil, fmt.Errorf("saving the share key of the Scout failed: %w", err)
+	}
+	return key, nil
+}
+
+// newSecret returns 32 random bytes.
+func newSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
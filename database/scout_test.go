@@ -0,0 +1,50 @@
+package database
+
+import "testing"
+
+func TestLocalScout(t *testing.T) {
+	db := CreateTestDB(t)
+	scout, err := LocalScout(db)
+	if err != nil {
+		t.Fatalf("LocalScout() failed: %v", err)
+	}
+	if err := SetScoutLanguage(db, "es"); err != nil {
+		t.Fatalf("SetScoutLanguage() failed: %v", err)
+	}
+	again, err := LocalScout(db)
+	if err != nil {
+		t.Fatalf("LocalScout() failed: %v", err)
+	}
+	if again.ID != scout.ID {
+		t.Errorf("Expected the same Scout, got %v and %v", scout.ID, again.ID)
+	}
+	if again.Language != "es" {
+		t.Errorf("Expected language es, got %q", again.Language)
+	}
+	var count int64
+	db.Model(&Scout{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected a single Scout, got %d", count)
+	}
+}
+
+func TestCalendarToken(t *testing.T) {
+	db := CreateTestDB(t)
+	token, err := CalendarToken(db)
+	if err != nil {
+		t.Fatalf("CalendarToken() failed: %v", err)
+	}
+	if len(token) < 40 {
+		t.Errorf("Expected a long secret, got %q", token)
+	}
+	if again, err := CalendarToken(db); err != nil || again != token {
+		t.Errorf("Expected the same token again, got %q (%v)", again, err)
+	}
+	reset, err := ResetCalendarToken(db)
+	if err != nil {
+		t.Fatalf("ResetCalendarToken() failed: %v", err)
+	}
+	if current, err := CalendarToken(db); err != nil || reset == token || current != reset {
+		t.Errorf("Expected resetting to replace the token, got %q then %q", token, current)
+	}
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrScoutsClosed is returned when opening a database after Scouts.Close.
+var ErrScoutsClosed = errors.New("scout databases are closed")
+
+// Scouts keeps the database of every Scout who used the app open, so each is only loaded once. Every Scout has their
+// own database file in a shared directory, named after a hash identifying the Scout.
+type Scouts struct {
+	dir string
+
+	mu      sync.Mutex
+	open    map[string]*gorm.DB
+	plugins []gorm.Plugin
+	closed  bool
+}
+
+// NewScouts returns Scouts that keeps databases in dir.
+func NewScouts(dir string) *Scouts {
+	return &Scouts{dir: dir, open: map[string]*gorm.DB{}}
+}
+
+// Dir is the directory the databases are kept in.
+func (s *Scouts) Dir() string {
+	return s.dir
+}
+
+// Use adds gorm plugins to every database opened afterwards.
+func (s *Scouts) Use(plugins ...gorm.Plugin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plugins = append(s.plugins, plugins...)
+}
+
+// Len is the number of open databases.
+func (s *Scouts) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.open)
+}
+
+// teamsHash names the database of every Team, which no Scout can use as their own.
+const teamsHash = "teams"
+
+// Open returns the database of the Scout identified by hash, loading it if it isn't open yet. The workspace of a
+// Team is opened the same way, see Team.Workspace.
+func (s *Scouts) Open(hash string) (*gorm.DB, error) {
+	if hash == "" || hash == teamsHash || filepath.Base(hash) != hash {
+		return nil, fmt.Errorf("invalid Scout hash %q", hash)
+	}
+	return s.openWith(hash, append(Models(), ChangeLogModels()...))
+}
+
+// Teams returns the database of every Team and its members, loading it if it isn't open yet.
+func (s *Scouts) Teams() (*gorm.DB, error) {
+	return s.openWith(teamsHash, TeamModels())
+}
+
+// openWith returns the database named hash, loading it with the given models if it isn't open yet.
+func (s *Scouts) openWith(hash string, models []any) (*gorm.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrScoutsClosed
+	}
+	if db, ok := s.open[hash]; ok {
+		return db, nil
+	}
+	db, err := load(filepath.Join(s.dir, hash+".db"), models...)
+	if err != nil {
+		return nil, fmt.Errorf("loading database of Scout %s failed: %w", hash, err)
+	}
+	for _, plugin := range s.plugins {
+		if err := db.Use(plugin); err != nil {
+			return nil, errors.Join(fmt.Errorf("adding plugin %s to database of Scout %s failed: %w", plugin.Name(), hash, err), SaveToFile(db))
+		}
+	}
+	s.open[hash] = db
+	return db, nil
+}
+
+// Close closes every open database. Databases can't be opened afterwards.
+func (s *Scouts) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var errs []error
+	for hash, db := range s.open {
+		if err := SaveToFile(db); err != nil {
+			errs = append(errs, fmt.Errorf("closing database of Scout %s failed: %w", hash, err))
+		}
+		delete(s.open, hash)
+	}
+	return errors.Join(errs...)
+}
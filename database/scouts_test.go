@@ -0,0 +1,44 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScouts(t *testing.T) {
+	scouts := NewScouts(t.TempDir())
+	db, err := scouts.Open("abc")
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	if err := db.Create(&Player{Name: "A"}).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	again, err := scouts.Open("abc")
+	if err != nil {
+		t.Fatalf("Open() failed the second time: %v", err)
+	}
+	if again != db {
+		t.Errorf("Expected an open database to be reused")
+	}
+	if n := scouts.Len(); n != 1 {
+		t.Errorf("Len() = %d, want 1", n)
+	}
+	if _, err := scouts.Open("../abc"); err == nil {
+		t.Errorf("Expected hashes that aren't file names to be rejected")
+	}
+
+	if err := scouts.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if n := scouts.Len(); n != 0 {
+		t.Errorf("Len() = %d after Close(), want 0", n)
+	}
+	sqlDB, _ := db.DB()
+	if err := sqlDB.Ping(); err == nil {
+		t.Errorf("Expected the database to be closed")
+	}
+	if _, err := scouts.Open("abc"); !errors.Is(err, ErrScoutsClosed) {
+		t.Errorf("Expected ErrScoutsClosed after Close(), got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Active reports whether the link can still be viewed at the given time.
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// ShareLinkByID returns the ShareLink with the given ID, or gorm.ErrRecordNotFound.
+func ShareLinkByID(db *gorm.DB, id uuid.UUID) (*ShareLink, error) {
+	var link ShareLink
+	if err := db.First(&link, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("retrieving ShareLink %s failed: %w", id, err)
+	}
+	return &link, nil
+}
+
+// ShareLinksForPlayer returns the links that share the Player, newest first.
+func ShareLinksForPlayer(db *gorm.DB, playerID uuid.UUID) ([]*ShareLink, error) {
+	var links []*ShareLink
+	if err := db.Where("player_id = ?", playerID).Order("created_at DESC").Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("retrieving ShareLinks failed: %w", err)
+	}
+	return links, nil
+}
+
+// RevokeShareLink stops the link from being viewed. Revoking a link twice keeps the time it was first revoked.
+func RevokeShareLink(db *gorm.DB, link *ShareLink, now time.Time) error {
+	if link.RevokedAt != nil {
+		return nil
+	}
+	if err := db.Model(link).Update("revoked_at", now).Error; err != nil {
+		return fmt.Errorf("revoking ShareLink %s failed: %w", link.ID, err)
+	}
+	return nil
+}
+
+// LogShareAccess records an attempt to view a ShareLink.
+func LogShareAccess(db *gorm.DB, access *ShareAccess) error {
+	if err := db.Create(access).Error; err != nil {
+		return fmt.Errorf("logging access to ShareLink %s failed: %w", access.ShareLinkID, err)
+	}
+	return nil
+}
+
+// CountShareAccesses returns how many attempts to view the ShareLink had the outcome since the given time.
+func CountShareAccesses(db *gorm.DB, linkID uuid.UUID, outcome ShareOutcome, since time.Time) (int64, error) {
+	var count int64
+	err := db.Model(&ShareAccess{}).
+		Where("share_link_id = ? AND outcome = ? AND created_at >= ?", linkID, outcome, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("counting accesses to ShareLink %s failed: %w", linkID, err)
+	}
+	return count, nil
+}
+
+// ShareAccesses returns the access log of each of the given links, newest first.
+func ShareAccesses(db *gorm.DB, linkIDs []uuid.UUID) (map[uuid.UUID][]*ShareAccess, error) {
+	var accesses []*ShareAccess
+	if err := db.Where("share_link_id IN ?", linkIDs).Order("created_at DESC").Find(&accesses).Error; err != nil {
+		return nil, fmt.Errorf("retrieving ShareAccesses failed: %w", err)
+	}
+	byLink := map[uuid.UUID][]*ShareAccess{}
+	for _, a := range accesses {
+		byLink[a.ShareLinkID] = append(byLink[a.ShareLinkID], a)
+	}
+	return byLink, nil
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevokeShareLink(t *testing.T) {
+	db := CreateTestDB(t)
+	now := time.Now()
+	link := &ShareLink{ExpiresAt: now.Add(time.Hour)}
+	if err := db.Create(link).Error; err != nil {
+		t.Fatalf("Failed to create ShareLink: %v", err)
+	}
+	if !link.Active(now) || link.Active(now.Add(2*time.Hour)) {
+		t.Errorf("Expected the link to be active until it expires")
+	}
+
+	if err := RevokeShareLink(db, link, now); err != nil {
+		t.Fatalf("RevokeShareLink() failed: %v", err)
+	}
+	if err := RevokeShareLink(db, link, now.Add(time.Minute)); err != nil {
+		t.Fatalf("RevokeShareLink() failed: %v", err)
+	}
+	got, err := ShareLinkByID(db, link.ID)
+	if err != nil {
+		t.Fatalf("ShareLinkByID() failed: %v", err)
+	}
+	if got.Active(now) || got.RevokedAt == nil || !got.RevokedAt.Equal(now) {
+		t.Errorf("Expected the link to stay revoked from the first time, got %v", got.RevokedAt)
+	}
+}
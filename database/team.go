@@ -0,0 +1,167 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrNotMember is returned when a Scout uses a Team they aren't a member of, or doesn't own a Team they try to manage.
+var ErrNotMember = errors.New("not a member of the team")
+
+// TeamModels returns a new, empty value of every model in the database of Teams, see Scouts.Teams.
+func TeamModels() []any {
+	return []any{
+		&Team{},
+		&Membership{},
+	}
+}
+
+// Team is a group of Scouts, such as the scouts of a club, who share a pool of players in a workspace of their own.
+// Teams and their members are kept in a database of their own rather than in any Scout's database, so that every
+// member finds them.
+type Team struct {
+	BaseModel
+	Name string
+}
+
+// Workspace is the hash of the database that holds the players and analyses the Team's members published, which has
+// the same schema as a Scout's database, see Scouts.Open.
+func (t *Team) Workspace() string {
+	return "team-" + t.ID.String()
+}
+
+// TeamRole is what a member may do in a Team.
+type TeamRole string
+
+const (
+	// TeamOwner members manage who else is a member.
+	TeamOwner TeamRole = "owner"
+	// TeamMember members publish to and read the workspace.
+	TeamMember TeamRole = "member"
+)
+
+// Membership makes a Scout a member of a Team.
+type Membership struct {
+	BaseModel
+	TeamID uuid.UUID `gorm:"uniqueIndex:idx_team_scout;type:uuid"`
+	// Scout is the hash identifying the Scout, see Scouts.Open.
+	Scout string `gorm:"uniqueIndex:idx_team_scout"`
+	// Name is how the Scout is shown to the rest of the Team.
+	Name string
+	Role TeamRole
+}
+
+// Publication records who published a Player or Analysis to a Team's workspace, or whose database it was merged from,
+// and when. Published rows keep the IDs they have in the Scout's own database, which RecordID refers to.
+type Publication struct {
+	BaseModel
+	RecordID uuid.UUID `gorm:"uniqueIndex;type:uuid"`
+	Kind     PublicationKind
+	// Scout is the hash identifying the Scout who last published the record, or the name of the database it was merged
+	// from.
+	Scout       string
+	ScoutName   string
+	PublishedAt time.Time
+}
+
+// PublicationKind is the table a Publication refers to.
+type PublicationKind string
+
+const (
+	PublishedPlayer   PublicationKind = "player"
+	PublishedAnalysis PublicationKind = "analysis"
+)
+
+// CreateTeam creates a Team owned by the Scout identified by scout, who is shown to the Team as name.
+func CreateTeam(db *gorm.DB, teamName, scout, name string) (*Team, error) {
+	team := &Team{Name: teamName}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(team).Error; err != nil {
+			return err
+		}
+		return tx.Create(&Membership{TeamID: team.ID, Scout: scout, Name: name, Role: TeamOwner}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating Team %q failed: %w", teamName, err)
+	}
+	return team, nil
+}
+
+// TeamsOf returns the Teams the Scout identified by scout is a member of, by name.
+func TeamsOf(db *gorm.DB, scout string) ([]*Team, error) {
+	var teams []*Team
+	err := db.Joins("JOIN memberships ON memberships.team_id = teams.id AND memberships.deleted_at IS NULL").
+		Where("memberships.scout = ?", scout).Order("teams.name").Find(&teams).Error
+	if err != nil {
+		return nil, fmt.Errorf("retrieving Teams of Scout %s failed: %w", scout, err)
+	}
+	return teams, nil
+}
+
+// TeamFor returns the Team with the given ID along with the membership of the Scout identified by scout. It returns
+// ErrNotMember if the Team doesn't exist or the Scout isn't a member, so that Teams can't be discovered.
+func TeamFor(db *gorm.DB, teamID uuid.UUID, scout string) (*Team, *Membership, error) {
+	var membership Membership
+	err := db.Where("team_id = ? AND scout = ?", teamID, scout).First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrNotMember
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("retrieving membership of Team %v failed: %w", teamID, err)
+	}
+	var team Team
+	if err := db.First(&team, "id = ?", teamID).Error; err != nil {
+		return nil, nil, fmt.Errorf("retrieving Team %v failed: %w", teamID, err)
+	}
+	return &team, &membership, nil
+}
+
+// TeamMembers returns the members of a Team, owners first.
+func TeamMembers(db *gorm.DB, teamID uuid.UUID) ([]*Membership, error) {
+	var members []*Membership
+	// "owner" sorts after "member", so owners come first in descending order.
+	if err := db.Where("team_id = ?", teamID).Order("role DESC, name").Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("retrieving members of Team %v failed: %w", teamID, err)
+	}
+	return members, nil
+}
+
+// AddTeamMember makes the Scout identified by scout a member of the Team. Adding a member twice returns
+// gorm.ErrDuplicatedKey.
+func AddTeamMember(db *gorm.DB, teamID uuid.UUID, scout, name string) error {
+	if err := db.Create(&Membership{TeamID: teamID, Scout: scout, Name: name, Role: TeamMember}).Error; err != nil {
+		return fmt.Errorf("adding Scout %s to Team %v failed: %w", scout, teamID, err)
+	}
+	return nil
+}
+
+// RemoveTeamMember removes the Scout identified by scout from the Team. What they published stays in the workspace.
+// Owners can't be removed, so that every Team keeps one.
+func RemoveTeamMember(db *gorm.DB, teamID uuid.UUID, scout string) error {
+	// Memberships are deleted for good so that the Scout can be added again.
+	result := db.Unscoped().Where("team_id = ? AND scout = ? AND role <> ?", teamID, scout, TeamOwner).Delete(&Membership{})
+	if result.Error != nil {
+		return fmt.Errorf("removing Scout %s from Team %v failed: %w", scout, teamID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("removing Scout %s from Team %v failed: %w", scout, teamID, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// Publications returns the Publications of the given kind, keyed by RecordID.
+func Publications(db *gorm.DB, kind PublicationKind) (map[uuid.UUID]*Publication, error) {
+	var publications []*Publication
+	if err := db.Where("kind = ?", kind).Find(&publications).Error; err != nil {
+		return nil, fmt.Errorf("retrieving Publications failed: %w", err)
+	}
+	byRecord := map[uuid.UUID]*Publication{}
+	for _, p := range publications {
+		byRecord[p.RecordID] = p
+	}
+	return byRecord, nil
+}
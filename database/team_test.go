@@ -0,0 +1,56 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestTeams(t *testing.T) {
+	scouts := NewScouts(t.TempDir())
+	t.Cleanup(func() { scouts.Close() })
+	db, err := scouts.Teams()
+	if err != nil {
+		t.Fatalf("Teams() failed: %v", err)
+	}
+	if _, err := scouts.Open(teamsHash); err == nil {
+		t.Errorf("Expected the database of Teams not to open as a Scout's")
+	}
+
+	team, err := CreateTeam(db, "Rovers", "ann", "Ann")
+	if err != nil {
+		t.Fatalf("CreateTeam() failed: %v", err)
+	}
+	if _, err := scouts.Open(team.Workspace()); err != nil {
+		t.Errorf("Failed to open the workspace of the Team: %v", err)
+	}
+	if err := AddTeamMember(db, team.ID, "bo", "Bo"); err != nil {
+		t.Fatalf("AddTeamMember() failed: %v", err)
+	}
+	if err := AddTeamMember(db, team.ID, "bo", "Bo"); !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("Adding a member twice returned %v, expected gorm.ErrDuplicatedKey", err)
+	}
+
+	teams, err := TeamsOf(db, "bo")
+	if err != nil || len(teams) != 1 || teams[0].ID != team.ID {
+		t.Errorf("Expected Bo to be in the Team, got %+v (%v)", teams, err)
+	}
+	members, err := TeamMembers(db, team.ID)
+	if err != nil || len(members) != 2 || members[0].Role != TeamOwner {
+		t.Errorf("Expected the owner first, got %+v (%v)", members, err)
+	}
+
+	if err := RemoveTeamMember(db, team.ID, "ann"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Removing the owner returned %v, expected gorm.ErrRecordNotFound", err)
+	}
+	if err := RemoveTeamMember(db, team.ID, "bo"); err != nil {
+		t.Fatalf("RemoveTeamMember() failed: %v", err)
+	}
+	if _, _, err := TeamFor(db, team.ID, "bo"); !errors.Is(err, ErrNotMember) {
+		t.Errorf("TeamFor() a removed member returned %v, expected ErrNotMember", err)
+	}
+	if _, membership, err := TeamFor(db, team.ID, "ann"); err != nil || membership.Role != TeamOwner {
+		t.Errorf("Expected Ann to own the Team, got %+v (%v)", membership, err)
+	}
+}
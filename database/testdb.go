@@ -0,0 +1,24 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// CreateTestDB returns an empty database for a test, which is not shared with other tests, unlike the in-memory
+// database returned by `Load("")`. It is closed when the test ends.
+func CreateTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := Load(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := SaveToFile(db); err != nil {
+			t.Errorf("Failed to close test database: %v", err)
+		}
+	})
+	return db
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxWeight is the largest weight a WeightProfile may give to an attribute.
+const MaxWeight = 10
+
+// Weights maps rated attributes to how much they count towards a score. Attributes that are missing don't count.
+type Weights map[Attribute]float64
+
+// ErrInvalidWeightProfile is wrapped by errors about weight profiles that can't be saved.
+var ErrInvalidWeightProfile = errors.New("invalid weight profile")
+
+// WeightProfileError explains why a weight profile can't be saved. It wraps ErrInvalidWeightProfile.
+type WeightProfileError struct {
+	// Code names the problem: "nameRequired", "nameTaken", "weightRange" or "noWeights".
+	Code string
+	// Name is the profile's name.
+	Name string
+	// Attribute is the attribute whose weight is out of range, for "weightRange".
+	Attribute Attribute
+}
+
+func (e *WeightProfileError) Error() string {
+	switch e.Code {
+	case "nameRequired":
+		return fmt.Sprintf("%v: a name is required", ErrInvalidWeightProfile)
+	case "nameTaken":
+		return fmt.Sprintf("%v: a profile named %q already exists", ErrInvalidWeightProfile, e.Name)
+	case "weightRange":
+		return fmt.Sprintf("%v: the weight of %v must be between 0 and %d", ErrInvalidWeightProfile, e.Attribute, MaxWeight)
+	}
+	return fmt.Sprintf("%v: at least one attribute needs a weight", ErrInvalidWeightProfile)
+}
+
+func (e *WeightProfileError) Unwrap() error {
+	return ErrInvalidWeightProfile
+}
+
+// AllWeightProfiles returns every weight profile ordered by name.
+func AllWeightProfiles(db *gorm.DB) ([]*WeightProfile, error) {
+	var profiles []*WeightProfile
+	if err := db.Order("name").Find(&profiles).Error; err != nil {
+		return nil, fmt.Errorf("retrieving all WeightProfiles failed: %w", err)
+	}
+	return profiles, nil
+}
+
+// WeightProfileByID returns a weight profile along with its weights. The error wraps gorm.ErrRecordNotFound if there
+// is no such profile.
+func WeightProfileByID(db *gorm.DB, id uuid.UUID) (*WeightProfile, Weights, error) {
+	var profile WeightProfile
+	if err := db.First(&profile, "id = ?", id).Error; err != nil {
+		return nil, nil, fmt.Errorf("retrieving WeightProfile %v failed: %w", id, err)
+	}
+	var rows []*ProfileWeight
+	if err := db.Where("weight_profile_id = ?", id).Find(&rows).Error; err != nil {
+		return nil, nil, fmt.Errorf("retrieving weights of WeightProfile %v failed: %w", id, err)
+	}
+	weights := Weights{}
+	for _, row := range rows {
+		attribute, err := ParseAttribute(row.Attribute)
+		if err != nil {
+			// The attribute was removed from the schema since the profile was saved.
+			continue
+		}
+		weights[attribute] = row.Weight
+	}
+	return &profile, weights, nil
+}
+
+// SaveWeightProfile creates the profile, or updates it if it already has an ID, and replaces all of its weights.
+// Zero weights are not stored.
+func SaveWeightProfile(db *gorm.DB, profile *WeightProfile, weights Weights) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if err := validateWeightProfile(db, profile, weights); err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if profile.ID == uuid.Nil {
+			err = tx.Create(profile).Error
+		} else {
+			err = tx.Save(profile).Error
+		}
+		if err != nil {
+			return fmt.Errorf("saving WeightProfile %q failed: %w", profile.Name, err)
+		}
+		if err := tx.Unscoped().Where("weight_profile_id = ?", profile.ID).Delete(&ProfileWeight{}).Error; err != nil {
+			return fmt.Errorf("removing old weights of WeightProfile %q failed: %w", profile.Name, err)
+		}
+		for attribute, weight := range weights {
+			if weight == 0 {
+				continue
+			}
+			row := &ProfileWeight{WeightProfileID: profile.ID, Attribute: attribute.Key(), Weight: weight}
+			if err := tx.Create(row).Error; err != nil {
+				return fmt.Errorf("saving weight for %v failed: %w", attribute, err)
+			}
+		}
+		return nil
+	})
+}
+
+func validateWeightProfile(db *gorm.DB, profile *WeightProfile, weights Weights) error {
+	if profile.Name == "" {
+		return &WeightProfileError{Code: "nameRequired"}
+	}
+	var clashes int64
+	if err := db.Model(&WeightProfile{}).Where("name = ? AND id <> ?", profile.Name, profile.ID).Count(&clashes).Error; err != nil {
+		return fmt.Errorf("checking for WeightProfiles named %q failed: %w", profile.Name, err)
+	}
+	if clashes > 0 {
+		return &WeightProfileError{Code: "nameTaken", Name: profile.Name}
+	}
+	positive := false
+	for attribute, weight := range weights {
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 || weight > MaxWeight {
+			return &WeightProfileError{Code: "weightRange", Name: profile.Name, Attribute: attribute}
+		}
+		positive = positive || weight > 0
+	}
+	if !positive {
+		return &WeightProfileError{Code: "noWeights", Name: profile.Name}
+	}
+	return nil
+}
+
+// DeleteWeightProfile deletes a weight profile and its weights. Like the weights SaveWeightProfile replaces, they are
+// deleted for good, since a profile's weights are only ever read alongside it.
+func DeleteWeightProfile(db *gorm.DB, id uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("weight_profile_id = ?", id).Delete(&ProfileWeight{}).Error; err != nil {
+			return fmt.Errorf("deleting weights of WeightProfile %v failed: %w", id, err)
+		}
+		if err := tx.Delete(&WeightProfile{}, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("deleting WeightProfile %v failed: %w", id, err)
+		}
+		return nil
+	})
+}
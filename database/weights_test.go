@@ -0,0 +1,86 @@
+package database
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSaveWeightProfile(t *testing.T) {
+	db := CreateTestDB(t)
+	pace := Attribute{AthleticRatings, "Pace"}
+	vision := Attribute{TacticalRatings, "Vision"}
+
+	profile := &WeightProfile{Name: " Pressing "}
+	if err := SaveWeightProfile(db, profile, Weights{pace: 3, vision: 1}); err != nil {
+		t.Fatalf("SaveWeightProfile() failed: %v", err)
+	}
+	if profile.Name != "Pressing" {
+		t.Errorf("Expected the name to be trimmed, got %q", profile.Name)
+	}
+
+	// Updating replaces every weight, and zero weights are dropped.
+	if err := SaveWeightProfile(db, profile, Weights{pace: 5, vision: 0}); err != nil {
+		t.Fatalf("SaveWeightProfile() failed to update: %v", err)
+	}
+	_, weights, err := WeightProfileByID(db, profile.ID)
+	if err != nil {
+		t.Fatalf("WeightProfileByID() failed: %v", err)
+	}
+	if len(weights) != 1 || weights[pace] != 5 {
+		t.Errorf("Expected only Pace with a weight of 5, got %v", weights)
+	}
+
+	if err := DeleteWeightProfile(db, profile.ID); err != nil {
+		t.Fatalf("DeleteWeightProfile() failed: %v", err)
+	}
+	profiles, err := AllWeightProfiles(db)
+	if err != nil {
+		t.Fatalf("AllWeightProfiles() failed: %v", err)
+	}
+	if len(profiles) != 0 {
+		t.Errorf("Expected no profiles after deleting, got %v", profiles)
+	}
+	var rows int64
+	if err := db.Unscoped().Model(&ProfileWeight{}).Where("weight_profile_id = ?", profile.ID).Count(&rows).Error; err != nil {
+		t.Fatalf("Failed to count ProfileWeights: %v", err)
+	}
+	if rows != 0 {
+		t.Errorf("Expected the weights to be deleted, %d remain", rows)
+	}
+}
+
+func TestSaveWeightProfileValidation(t *testing.T) {
+	db := CreateTestDB(t)
+	pace := Attribute{AthleticRatings, "Pace"}
+	if err := SaveWeightProfile(db, &WeightProfile{Name: "Taken"}, Weights{pace: 1}); err != nil {
+		t.Fatalf("SaveWeightProfile() failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		profile *WeightProfile
+		weights Weights
+		code    string
+	}{
+		{name: "missing name", profile: &WeightProfile{}, weights: Weights{pace: 1}, code: "nameRequired"},
+		{name: "duplicate name", profile: &WeightProfile{Name: "Taken"}, weights: Weights{pace: 1}, code: "nameTaken"},
+		{name: "negative weight", profile: &WeightProfile{Name: "A"}, weights: Weights{pace: -1}, code: "weightRange"},
+		{name: "weight too large", profile: &WeightProfile{Name: "A"}, weights: Weights{pace: MaxWeight + 1}, code: "weightRange"},
+		{name: "NaN weight", profile: &WeightProfile{Name: "A"}, weights: Weights{pace: math.NaN()}, code: "weightRange"},
+		{name: "infinite weight", profile: &WeightProfile{Name: "A"}, weights: Weights{pace: math.Inf(1)}, code: "weightRange"},
+		{name: "no weights", profile: &WeightProfile{Name: "A"}, weights: Weights{pace: 0}, code: "noWeights"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SaveWeightProfile(db, tt.profile, tt.weights)
+			if !errors.Is(err, ErrInvalidWeightProfile) {
+				t.Errorf("Expected ErrInvalidWeightProfile, got %v", err)
+			}
+			var invalid *WeightProfileError
+			if !errors.As(err, &invalid) || invalid.Code != tt.code {
+				t.Errorf("Expected a WeightProfileError with code %q, got %v", tt.code, err)
+			}
+		})
+	}
+}
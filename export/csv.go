@@ -0,0 +1,152 @@
+// Package export writes players and their analyses in formats used outside of the app, such as spreadsheets.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/playerlist"
+	"gorm.io/gorm"
+)
+
+// PlayerColumns are the columns of the players export.
+var PlayerColumns = []string{
+	"id", "name", "birthdate", "age", "height_cm", "weight_kg", "club", "position", "manager", "telephone", "notes",
+	"score", "last_seen",
+}
+
+// AnalysisColumns are the columns of the analyses export, followed by one column per rated attribute named by its
+// Key, e.g. "Defender.Tackling".
+var AnalysisColumns = []string{
+	"id", "player_id", "player", "date", "category", "play_time_minutes", "venue", "weather", "notes",
+}
+
+// WritePlayersCSV writes one row per player, in the order given, with every field of their profile.
+func WritePlayersCSV(w io.Writer, rows []*playerlist.Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(PlayerColumns); err != nil {
+		return fmt.Errorf("writing players header failed: %w", err)
+	}
+	for _, row := range rows {
+		profile := row.Profile
+		if profile == nil {
+			profile = &database.PlayerAnalysis{}
+		}
+		record := []string{
+			row.ID.String(),
+			text(row.Name),
+			text(profile.Birthdate),
+			optionalInt(row.Age),
+			positiveInt(profile.Height),
+			positiveInt(profile.Weight),
+			text(profile.Club),
+			string(profile.Position),
+			text(profile.ManagerName),
+			text(profile.Telephone),
+			text(profile.Notes),
+			score(row.Score),
+			row.LastSeen,
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("writing player %v failed: %w", row.ID, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteAnalysesCSV writes one row per analysis of the given players, in chronological order, with a column for
+// every rated attribute. Attributes that weren't rated are left empty. Analyses are read in batches and written as
+// they are read.
+func WriteAnalysesCSV(w io.Writer, db *gorm.DB, players []*playerlist.Row) error {
+	names := make(map[uuid.UUID]string, len(players))
+	for _, p := range players {
+		names[p.ID] = p.Name
+	}
+	attributes := database.AllAttributes()
+
+	cw := csv.NewWriter(w)
+	header := append([]string{}, AnalysisColumns...)
+	for _, a := range attributes {
+		header = append(header, a.Key())
+	}
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("writing analyses header failed: %w", err)
+	}
+	keep := func(a *database.Analysis) bool {
+		_, ok := names[a.PlayerID]
+		return ok
+	}
+	err := database.EachAnalysisBatch(db, database.AnalysisBatchSize, keep, func(details []*database.AnalysisDetail) error {
+		for _, d := range details {
+			record := []string{
+				d.ID.String(),
+				d.PlayerID.String(),
+				text(names[d.PlayerID]),
+				d.Date,
+				string(d.Category),
+				optionalInt(d.PlayTimeMinutes),
+				text(d.Venue),
+				text(d.WeatherCondition),
+				text(d.Notes),
+			}
+			for _, a := range attributes {
+				if rating, ok := d.Rating(a); ok {
+					record = append(record, strconv.Itoa(rating))
+				} else {
+					record = append(record, "")
+				}
+			}
+			if err := cw.Write(record); err != nil {
+				return fmt.Errorf("writing analysis %v failed: %w", d.ID, err)
+			}
+		}
+		// Send each batch on, rather than buffering the whole export.
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// text escapes free text that spreadsheets would otherwise run as a formula when the file is opened, by prefixing it
+// with an apostrophe.
+func text(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + s
+	}
+	return s
+}
+
+func optionalInt(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
+
+// positiveInt formats measurements, which are zero when they weren't recorded.
+func positiveInt(i int) string {
+	if i <= 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+func score(s *float64) string {
+	if s == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*s, 'f', 2, 64)
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/playerlist"
+)
+
+func readCSV(t *testing.T, b *bytes.Buffer) [][]string {
+	t.Helper()
+	records, err := csv.NewReader(b).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	return records
+}
+
+// column returns the value of a named column in a record.
+func column(t *testing.T, header, record []string, name string) string {
+	t.Helper()
+	for i, h := range header {
+		if h == name {
+			return record[i]
+		}
+	}
+	t.Fatalf("Missing column %q in %v", name, header)
+	return ""
+}
+
+func TestWritePlayersCSV(t *testing.T) {
+	age := 19
+	scored := 7.25
+	rows := []*playerlist.Row{
+		{
+			PlayerSummary: &database.PlayerSummary{
+				Player: &database.Player{Name: "=HYPERLINK(\"http://example.com\")", Score: &scored},
+				Profile: &database.PlayerAnalysis{
+					Birthdate: "05/06/05", Height: 181, Club: "Rovers", Position: database.Forward, Telephone: "+44 1234",
+				},
+				LastSeen: "2024-06-01 15:00",
+			},
+			Age: &age,
+		},
+		{PlayerSummary: &database.PlayerSummary{Player: &database.Player{Name: "No profile"}}},
+	}
+
+	var b bytes.Buffer
+	if err := WritePlayersCSV(&b, rows); err != nil {
+		t.Fatalf("WritePlayersCSV() failed: %v", err)
+	}
+	records := readCSV(t, &b)
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %v", records)
+	}
+	header, first, second := records[0], records[1], records[2]
+	for name, want := range map[string]string{
+		"name":      "'=HYPERLINK(\"http://example.com\")",
+		"age":       "19",
+		"height_cm": "181",
+		"weight_kg": "",
+		"club":      "Rovers",
+		"position":  "Forward",
+		"telephone": "'+44 1234",
+		"score":     "7.25",
+		"last_seen": "2024-06-01 15:00",
+	} {
+		if got := column(t, header, first, name); got != want {
+			t.Errorf("Column %s = %q, want %q", name, got, want)
+		}
+	}
+	if got := column(t, header, second, "age"); got != "" {
+		t.Errorf("Expected no age without a profile, got %q", got)
+	}
+}
+
+func TestWriteAnalysesCSV(t *testing.T) {
+	db := database.CreateTestDB(t)
+	exported := &database.Player{Name: "Exported"}
+	filtered := &database.Player{Name: "Filtered out"}
+	for _, p := range []*database.Player{exported, filtered} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("Failed to create Player: %v", err)
+		}
+	}
+	tactical := &database.TacticalAnalysis{Vision: 8, Awareness: database.Unrated, MovementOffTheBall: 3}
+	if err := db.Create(tactical).Error; err != nil {
+		t.Fatalf("Failed to create TacticalAnalysis: %v", err)
+	}
+	minutes := 90
+	analyses := []*database.Analysis{
+		{PlayerID: exported.ID, Category: database.Match, Date: "2024-02-01 15:00", TacticalAnalysisID: tactical.ID, PlayTimeMinutes: &minutes, Notes: "=Strong first touch"},
+		{PlayerID: exported.ID, Category: database.Training, Date: "2024-01-01 10:00"},
+		{PlayerID: filtered.ID, Category: database.Match, Date: "2024-01-15 15:00"},
+	}
+	for _, a := range analyses {
+		if err := db.Create(a).Error; err != nil {
+			t.Fatalf("Failed to create Analysis: %v", err)
+		}
+	}
+
+	var b bytes.Buffer
+	rows := []*playerlist.Row{{PlayerSummary: &database.PlayerSummary{Player: exported}}}
+	if err := WriteAnalysesCSV(&b, db, rows); err != nil {
+		t.Fatalf("WriteAnalysesCSV() failed: %v", err)
+	}
+	records := readCSV(t, &b)
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %v", records)
+	}
+	header := records[0]
+	if want := len(AnalysisColumns) + len(database.AllAttributes()); len(header) != want {
+		t.Errorf("Expected %d columns, got %d", want, len(header))
+	}
+	first, second := records[1], records[2]
+	if got := column(t, header, first, "date"); got != "2024-01-01 10:00" {
+		t.Errorf("Expected analyses in chronological order, got %q first", got)
+	}
+	for name, want := range map[string]string{
+		"player":                      "Exported",
+		"category":                    "Match",
+		"play_time_minutes":           "90",
+		"notes":                       "'=Strong first touch",
+		"Tactical.Vision":             "8",
+		"Tactical.Awareness":          "",
+		"Tactical.MovementOffTheBall": "3",
+		"Defender.Tackling":           "",
+	} {
+		if got := column(t, header, second, name); got != want {
+			t.Errorf("Column %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestTextEscapesFormulas(t *testing.T) {
+	for in, want := range map[string]string{"": "", "Rovers": "Rovers", "=1+1": "'=1+1", "@SUM(A1)": "'@SUM(A1)", "-2": "'-2"} {
+		if got := text(in); got != want {
+			t.Errorf("text(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/playerlist"
+	"gorm.io/gorm"
+)
+
+// SummaryColumns are the columns of the summary sheet of a workbook, followed by one column per rated attribute with
+// the mean rating of the players in each position.
+var SummaryColumns = []string{"position", "players", "analyses", "average_score"}
+
+// Sheets of a workbook, in order.
+var sheetNames = []string{"Players", "Analyses", "Summary"}
+
+// Cell styles, indexes into cellXfs in xlsxStyles.
+const (
+	styleDefault = iota
+	styleHeader
+	styleDateTime
+	styleDecimal
+)
+
+// Parts of the workbook that don't depend on its contents.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/worksheets/sheet3.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet3.xml"/>
+<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+	// xlsxStyles defines the cell styles: the default, bold headers, dates with times, and numbers with 2 decimals.
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="4">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>`
+)
+
+// cell is a single cell of a sheet. Empty cells are left out of the sheet.
+type cell struct {
+	text    string
+	number  float64
+	numeric bool
+	style   int
+	empty   bool
+}
+
+func textCell(s string) cell {
+	return cell{text: s, empty: s == ""}
+}
+
+func numberCell(f float64, style int) cell {
+	return cell{number: f, numeric: true, style: style}
+}
+
+func emptyCell() cell {
+	return cell{empty: true}
+}
+
+func optionalIntCell(i *int) cell {
+	if i == nil {
+		return emptyCell()
+	}
+	return numberCell(float64(*i), styleDefault)
+}
+
+// positiveIntCell holds measurements, which are zero when they weren't recorded.
+func positiveIntCell(i int) cell {
+	if i <= 0 {
+		return emptyCell()
+	}
+	return numberCell(float64(i), styleDefault)
+}
+
+func scoreCell(s *float64) cell {
+	if s == nil {
+		return emptyCell()
+	}
+	return numberCell(*s, styleDecimal)
+}
+
+// excelEpoch is day zero of spreadsheet dates.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// dateCell holds a date stored as yyyy-mm-dd hh:mm as a spreadsheet date. Dates that can't be parsed are kept as
+// text.
+func dateCell(date string) cell {
+	t, err := database.ParseDate(date)
+	if err != nil {
+		return textCell(date)
+	}
+	return numberCell(t.Sub(excelEpoch).Hours()/24, styleDateTime)
+}
+
+// columnName is the name of the i-th column, counting from zero: A to Z, then AA, AB and so on.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// sheetWriter writes the rows of a worksheet as they are added, remembering the first error so that callers only
+// need to check once.
+type sheetWriter struct {
+	w       io.Writer
+	rows    int
+	columns int
+	err     error
+}
+
+func (s *sheetWriter) printf(format string, args ...any) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, format, args...)
+	}
+}
+
+func (s *sheetWriter) escaped(text string) {
+	if s.err == nil {
+		s.err = xml.EscapeText(s.w, []byte(text))
+	}
+}
+
+// begin starts the sheet with a header row, which stays in view while scrolling.
+func (s *sheetWriter) begin(header []string) {
+	s.printf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	s.printf(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	s.printf(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	s.printf(`<sheetData>`)
+	s.columns = len(header)
+	cells := make([]cell, len(header))
+	for i, h := range header {
+		cells[i] = cell{text: h, style: styleHeader}
+	}
+	s.row(cells)
+}
+
+func (s *sheetWriter) row(cells []cell) {
+	s.rows++
+	s.printf(`<row r="%d">`, s.rows)
+	for i, c := range cells {
+		if c.empty {
+			continue
+		}
+		ref := columnName(i) + strconv.Itoa(s.rows)
+		style := ""
+		if c.style != styleDefault {
+			style = fmt.Sprintf(` s="%d"`, c.style)
+		}
+		if c.numeric {
+			s.printf(`<c r="%s"%s><v>%s</v></c>`, ref, style, strconv.FormatFloat(c.number, 'f', -1, 64))
+			continue
+		}
+		// Strings are inline rather than shared, so the sheet can be written as rows are read.
+		s.printf(`<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+		s.escaped(c.text)
+		s.printf(`</t></is></c>`)
+	}
+	s.printf(`</row>`)
+}
+
+// end finishes the sheet, filtering on every column of the header.
+func (s *sheetWriter) end() error {
+	s.printf(`</sheetData>`)
+	s.printf(`<autoFilter ref="A1:%s%d"/>`, columnName(s.columns-1), s.rows)
+	s.printf(`</worksheet>`)
+	return s.err
+}
+
+// positionTotals accumulates the averages of the summary sheet for one position.
+type positionTotals struct {
+	players, scored, analyses int
+	score                     float64
+	ratings, counts           map[database.Attribute]int
+}
+
+// WriteWorkbook writes an XLSX workbook of the given players with three sheets: the players, as in WritePlayersCSV;
+// their analyses, as in WriteAnalysesCSV; and a summary of the average score and ratings of the players in each
+// position. Numbers and dates are typed, every sheet keeps its header row in view and can be filtered by any column.
+// Analyses are read in batches and written as they are read.
+func WriteWorkbook(w io.Writer, db *gorm.DB, players []*playerlist.Row) error {
+	zw := zip.NewWriter(w)
+	for _, part := range [][2]string{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		if err := writeZipFile(zw, part[0], part[1]); err != nil {
+			return err
+		}
+	}
+
+	positions := map[database.PositionType]*positionTotals{}
+	for _, p := range database.DisplayPositions {
+		positions[p] = &positionTotals{ratings: map[database.Attribute]int{}, counts: map[database.Attribute]int{}}
+	}
+	var sheets []*sheetWriter
+
+	sheet, err := newSheet(zw, 1)
+	if err != nil {
+		return err
+	}
+	if err := writePlayersSheet(sheet, players, positions); err != nil {
+		return err
+	}
+	sheets = append(sheets, sheet)
+
+	if sheet, err = newSheet(zw, 2); err != nil {
+		return err
+	}
+	if err := writeAnalysesSheet(sheet, db, players, positions); err != nil {
+		return err
+	}
+	sheets = append(sheets, sheet)
+
+	if sheet, err = newSheet(zw, 3); err != nil {
+		return err
+	}
+	if err := writeSummarySheet(sheet, positions); err != nil {
+		return err
+	}
+	sheets = append(sheets, sheet)
+
+	if err := writeZipFile(zw, "xl/workbook.xml", workbookXML(sheets)); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("writing workbook failed: %w", err)
+	}
+	return nil
+}
+
+func writeZipFile(zw *zip.Writer, name, contents string) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("writing %s failed: %w", name, err)
+	}
+	if _, err := io.WriteString(fw, contents); err != nil {
+		return fmt.Errorf("writing %s failed: %w", name, err)
+	}
+	return nil
+}
+
+func newSheet(zw *zip.Writer, number int) (*sheetWriter, error) {
+	name := fmt.Sprintf("xl/worksheets/sheet%d.xml", number)
+	fw, err := zw.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("writing %s failed: %w", name, err)
+	}
+	return &sheetWriter{w: fw}, nil
+}
+
+// workbookXML lists the sheets along with the range of each one's filter, which spreadsheets expect as a hidden name.
+func workbookXML(sheets []*sheetWriter) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	b.WriteString(`<sheets>`)
+	for i, name := range sheetNames {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, name, i+1, i+1)
+	}
+	b.WriteString(`</sheets><definedNames>`)
+	for i, name := range sheetNames {
+		sheet := sheets[i]
+		fmt.Fprintf(&b, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!$A$1:$%s$%d</definedName>`, i, name, columnName(sheet.columns-1), sheet.rows)
+	}
+	b.WriteString(`</definedNames></workbook>`)
+	return b.String()
+}
+
+func writePlayersSheet(sheet *sheetWriter, players []*playerlist.Row, positions map[database.PositionType]*positionTotals) error {
+	sheet.begin(PlayerColumns)
+	for _, row := range players {
+		profile := row.Profile
+		if profile == nil {
+			profile = &database.PlayerAnalysis{}
+		}
+		totals := positions[profile.Position]
+		if totals == nil {
+			totals = positions[""]
+		}
+		totals.players++
+		if row.Score != nil {
+			totals.scored++
+			totals.score += *row.Score
+		}
+		sheet.row([]cell{
+			textCell(row.ID.String()),
+			textCell(row.Name),
+			textCell(profile.Birthdate),
+			optionalIntCell(row.Age),
+			positiveIntCell(profile.Height),
+			positiveIntCell(profile.Weight),
+			textCell(profile.Club),
+			textCell(string(profile.Position)),
+			textCell(profile.ManagerName),
+			textCell(profile.Telephone),
+			textCell(profile.Notes),
+			scoreCell(row.Score),
+			dateCell(row.LastSeen),
+		})
+	}
+	if err := sheet.end(); err != nil {
+		return fmt.Errorf("writing players sheet failed: %w", err)
+	}
+	return nil
+}
+
+func writeAnalysesSheet(sheet *sheetWriter, db *gorm.DB, players []*playerlist.Row, positions map[database.PositionType]*positionTotals) error {
+	byID := make(map[uuid.UUID]*playerlist.Row, len(players))
+	for _, p := range players {
+		byID[p.ID] = p
+	}
+	attributes := database.AllAttributes()
+	header := append([]string{}, AnalysisColumns...)
+	for _, a := range attributes {
+		header = append(header, a.Key())
+	}
+	sheet.begin(header)
+
+	keep := func(a *database.Analysis) bool {
+		_, ok := byID[a.PlayerID]
+		return ok
+	}
+	err := database.EachAnalysisBatch(db, database.AnalysisBatchSize, keep, func(details []*database.AnalysisDetail) error {
+		for _, d := range details {
+			player := byID[d.PlayerID]
+			totals := positions[player.Position()]
+			if totals == nil {
+				totals = positions[""]
+			}
+			totals.analyses++
+			cells := []cell{
+				textCell(d.ID.String()),
+				textCell(d.PlayerID.String()),
+				textCell(player.Name),
+				dateCell(d.Date),
+				textCell(string(d.Category)),
+				optionalIntCell(d.PlayTimeMinutes),
+				textCell(d.Venue),
+				textCell(d.WeatherCondition),
+				textCell(d.Notes),
+			}
+			for _, a := range attributes {
+				rating, ok := d.Rating(a)
+				if !ok {
+					cells = append(cells, emptyCell())
+					continue
+				}
+				totals.ratings[a] += rating
+				totals.counts[a]++
+				cells = append(cells, numberCell(float64(rating), styleDefault))
+			}
+			sheet.row(cells)
+		}
+		return sheet.err
+	})
+	if err != nil {
+		return fmt.Errorf("writing analyses sheet failed: %w", err)
+	}
+	if err := sheet.end(); err != nil {
+		return fmt.Errorf("writing analyses sheet failed: %w", err)
+	}
+	return nil
+}
+
+func writeSummarySheet(sheet *sheetWriter, positions map[database.PositionType]*positionTotals) error {
+	attributes := database.AllAttributes()
+	header := append([]string{}, SummaryColumns...)
+	for _, a := range attributes {
+		header = append(header, a.Key())
+	}
+	sheet.begin(header)
+	for _, p := range database.DisplayPositions {
+		totals := positions[p]
+		if totals.players == 0 {
+			continue
+		}
+		cells := []cell{
+			textCell(string(p)),
+			numberCell(float64(totals.players), styleDefault),
+			numberCell(float64(totals.analyses), styleDefault),
+			emptyCell(),
+		}
+		if totals.scored > 0 {
+			cells[3] = numberCell(totals.score/float64(totals.scored), styleDecimal)
+		}
+		for _, a := range attributes {
+			if totals.counts[a] == 0 {
+				cells = append(cells, emptyCell())
+				continue
+			}
+			cells = append(cells, numberCell(float64(totals.ratings[a])/float64(totals.counts[a]), styleDecimal))
+		}
+		sheet.row(cells)
+	}
+	if err := sheet.end(); err != nil {
+		return fmt.Errorf("writing summary sheet failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/playerlist"
+)
+
+// worksheet is the part of a sheet that tests look at.
+type worksheet struct {
+	Pane struct {
+		State  string `xml:"state,attr"`
+		YSplit int    `xml:"ySplit,attr"`
+	} `xml:"sheetViews>sheetView>pane"`
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+	AutoFilter struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"autoFilter"`
+}
+
+// cells returns the cells of a row keyed by column, as the text of strings or the value of numbers. Numeric cells
+// are marked by a leading "#".
+func (w *worksheet) cells(row int) map[string]string {
+	cells := map[string]string{}
+	for _, c := range w.Rows[row].Cells {
+		column := strings.TrimRight(c.Ref, "0123456789")
+		if c.Type == "inlineStr" {
+			cells[column] = c.Inline
+		} else {
+			cells[column] = "#" + c.Value
+		}
+	}
+	return cells
+}
+
+func readSheet(t *testing.T, zr *zip.Reader, name string) *worksheet {
+	t.Helper()
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("Missing %s: %v", name, err)
+	}
+	defer f.Close()
+	contents, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	var sheet worksheet
+	if err := xml.Unmarshal(contents, &sheet); err != nil {
+		t.Fatalf("%s is not valid XML: %v", name, err)
+	}
+	return &sheet
+}
+
+func TestWriteWorkbook(t *testing.T) {
+	db := database.CreateTestDB(t)
+	forward := &database.Player{Name: "Forward <One>"}
+	defender := &database.Player{Name: "Defender"}
+	for _, p := range []*database.Player{forward, defender} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("Failed to create Player: %v", err)
+		}
+	}
+	for _, ratings := range []int{6, 9} {
+		tactical := &database.TacticalAnalysis{Vision: ratings, Awareness: database.Unrated, MovementOffTheBall: database.Unrated}
+		if err := db.Create(tactical).Error; err != nil {
+			t.Fatalf("Failed to create TacticalAnalysis: %v", err)
+		}
+		analysis := &database.Analysis{PlayerID: forward.ID, Category: database.Match, Date: "2024-01-01 12:00", TacticalAnalysisID: tactical.ID, Notes: "Quick feet"}
+		if err := db.Create(analysis).Error; err != nil {
+			t.Fatalf("Failed to create Analysis: %v", err)
+		}
+	}
+	score := 7.5
+	forward.Score = &score
+	rows := []*playerlist.Row{
+		{PlayerSummary: &database.PlayerSummary{Player: forward, Profile: &database.PlayerAnalysis{Position: database.Forward, Height: 180}}},
+		{PlayerSummary: &database.PlayerSummary{Player: defender, Profile: &database.PlayerAnalysis{Position: database.Defender}}},
+	}
+
+	var b bytes.Buffer
+	if err := WriteWorkbook(&b, db, rows); err != nil {
+		t.Fatalf("WriteWorkbook() failed: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatalf("The workbook is not a zip file: %v", err)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, err := zr.Open(name); err != nil {
+			t.Errorf("Missing %s", name)
+		}
+	}
+
+	players := readSheet(t, zr, "xl/worksheets/sheet1.xml")
+	analyses := readSheet(t, zr, "xl/worksheets/sheet2.xml")
+	summary := readSheet(t, zr, "xl/worksheets/sheet3.xml")
+	for name, sheet := range map[string]*worksheet{"players": players, "analyses": analyses, "summary": summary} {
+		if sheet.Pane.State != "frozen" || sheet.Pane.YSplit != 1 {
+			t.Errorf("Expected the header row of the %s sheet to be frozen, got %+v", name, sheet.Pane)
+		}
+		if len(sheet.Rows) != 3 {
+			t.Errorf("Expected a header and 2 rows in the %s sheet, got %d rows", name, len(sheet.Rows))
+		}
+	}
+	if want := "A1:M3"; players.AutoFilter.Ref != want {
+		t.Errorf("Players filter = %q, want %q", players.AutoFilter.Ref, want)
+	}
+
+	first := players.cells(1)
+	for column, want := range map[string]string{"B": "Forward <One>", "E": "#180", "H": "Forward", "L": "#7.5"} {
+		if first[column] != want {
+			t.Errorf("Players cell %s2 = %q, want %q", column, first[column], want)
+		}
+	}
+	if _, ok := first["F"]; ok {
+		t.Errorf("Expected no weight cell when the weight wasn't recorded")
+	}
+
+	// 2024-01-01 12:00 is day 45292.5 in spreadsheets.
+	if got := analyses.cells(1)["D"]; got != "#45292.5" {
+		t.Errorf("Expected a typed date, got %q", got)
+	}
+	notes := columnName(len(AnalysisColumns) - 1)
+	if header, got := analyses.cells(0)[notes], analyses.cells(1)[notes]; header != "notes" || got != "Quick feet" {
+		t.Errorf("Expected the notes in column %s, got %q with %q", notes, header, got)
+	}
+	visionIndex := 0
+	for i, a := range database.AllAttributes() {
+		if a.Key() == "Tactical.Vision" {
+			visionIndex = i
+		}
+	}
+	vision := columnName(len(AnalysisColumns) + visionIndex)
+	if got := analyses.cells(0)[vision]; got != "Tactical.Vision" {
+		t.Fatalf("Expected column %s to be Tactical.Vision, got %q", vision, got)
+	}
+
+	forwards := summary.cells(2)
+	if forwards["A"] != "Forward" || forwards["B"] != "#1" || forwards["C"] != "#2" || forwards["D"] != "#7.5" {
+		t.Errorf("Unexpected forwards summary %v", forwards)
+	}
+	if got := forwards[columnName(len(SummaryColumns)+visionIndex)]; got != "#7.5" {
+		t.Errorf("Expected the forwards' average vision of 7.5, got %q", got)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package i18n translates the user interface and formats numbers and dates for the language of each Scout.
+//
+// Messages are looked up by key in the catalogues under locales, one JSON file per language, and are fmt format
+// strings. Arguments may be reordered with explicit indexes, e.g. "%[2]d". Messages missing from a catalogue fall back
+// to English.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+	"golang.org/x/text/number"
+)
+
+// English is the language the app falls back to.
+var English = language.English
+
+// Languages lists every language with a catalogue. The first is the fallback.
+var Languages = []language.Tag{English, language.Spanish, language.German}
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// messages holds the catalogue of each language, keyed by message key.
+var messages = mustLoad()
+
+var (
+	messageCatalog = mustBuild()
+	matcher        = language.NewMatcher(Languages)
+)
+
+func mustLoad() map[language.Tag]map[string]string {
+	all := map[language.Tag]map[string]string{}
+	for _, tag := range Languages {
+		data, err := locales.ReadFile(path.Join("locales", tag.String()+".json"))
+		if err != nil {
+			panic(fmt.Sprintf("reading catalogue for %v failed: %v", tag, err))
+		}
+		var m map[string]string
+		if err := json.Unmarshal(data, &m); err != nil {
+			panic(fmt.Sprintf("parsing catalogue for %v failed: %v", tag, err))
+		}
+		all[tag] = m
+	}
+	return all
+}
+
+func mustBuild() catalog.Catalog {
+	b := catalog.NewBuilder(catalog.Fallback(English))
+	for tag, m := range messages {
+		for key, msg := range m {
+			if err := b.SetString(tag, key, msg); err != nil {
+				panic(fmt.Sprintf("adding %q to catalogue for %v failed: %v", key, tag, err))
+			}
+		}
+	}
+	return b
+}
+
+// Match picks the language to use for a Scout. preference is the language the Scout chose, which is empty if they
+// haven't, and acceptLanguage is the Accept-Language header sent by their browser. English is used if neither names
+// a supported language.
+func Match(preference, acceptLanguage string) language.Tag {
+	if tag, err := language.Parse(preference); err == nil && preference != "" {
+		if _, i, confidence := matcher.Match(tag); confidence >= language.High {
+			return Languages[i]
+		}
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return English
+	}
+	if _, i, confidence := matcher.Match(tags...); confidence != language.No {
+		return Languages[i]
+	}
+	return English
+}
+
+// Supported reports whether lang is the BCP 47 tag of one of Languages.
+func Supported(lang string) bool {
+	for _, tag := range Languages {
+		if tag.String() == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// Localizer translates messages and formats values for one language.
+type Localizer struct {
+	Tag     language.Tag
+	printer *message.Printer
+}
+
+// New returns a Localizer for a language, which should be one of Languages.
+func New(tag language.Tag) *Localizer {
+	return &Localizer{Tag: tag, printer: message.NewPrinter(tag, message.Catalog(messageCatalog))}
+}
+
+type localizerKey struct{}
+
+// WithLocalizer returns a copy of ctx that carries the Localizer for the current request.
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerKey{}, l)
+}
+
+// FromContext returns the Localizer carried by ctx, or an English one if there is none.
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := ctx.Value(localizerKey{}).(*Localizer); ok {
+		return l
+	}
+	return New(English)
+}
+
+// T translates the message with the given key, formatting args into it. Numbers are formatted for the language.
+func (l *Localizer) T(key string, args ...any) string {
+	return l.printer.Sprintf(key, args...)
+}
+
+// Number formats a number with the given number of decimals.
+func (l *Localizer) Number(f float64, decimals int) string {
+	return l.printer.Sprint(number.Decimal(f, number.Scale(decimals)))
+}
+
+// Integer formats a whole number.
+func (l *Localizer) Integer(i int) string {
+	return l.printer.Sprint(number.Decimal(i))
+}
+
+// Date formats the day of t, in the language's "format.date" layout.
+func (l *Localizer) Date(t time.Time) string {
+	return l.formatTime(t, "format.date")
+}
+
+// DateTime formats the day and time of t, in the language's "format.datetime" layout.
+func (l *Localizer) DateTime(t time.Time) string {
+	return l.formatTime(t, "format.datetime")
+}
+
+// formatTime formats t with a time layout from the catalogue. Layouts may use the abbreviated month name "Jan",
+// which is translated with the "month.<number>" messages.
+func (l *Localizer) formatTime(t time.Time, layoutKey string) string {
+	formatted := t.Format(l.T(layoutKey))
+	month := t.Format("Jan")
+	return strings.Replace(formatted, month, l.T("month."+strconv.Itoa(int(t.Month()))), 1)
+}
+
+// Attribute is the name of a rated attribute.
+func (l *Localizer) Attribute(a database.Attribute) string {
+	return l.T("attribute." + a.Key())
+}
+
+// Group is the name of a rating group.
+func (l *Localizer) Group(g database.RatingGroup) string {
+	return l.T("group." + string(g))
+}
+
+// Position is the name of a position. Players without a recorded position have an empty PositionType.
+func (l *Localizer) Position(p database.PositionType) string {
+	if p == "" {
+		return l.T("position.unknown")
+	}
+	return l.T("position." + string(p))
+}
+
+// Category is the name of an analysis category.
+func (l *Localizer) Category(c database.AnalysisCategory) string {
+	return l.T("category." + string(c))
+}
+
+// Name is the name of a language in that language, e.g. "Deutsch".
+func Name(tag language.Tag) string {
+	return display.Self.Name(tag)
+}
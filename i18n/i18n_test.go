@@ -0,0 +1,105 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"golang.org/x/text/language"
+)
+
+func TestCataloguesComplete(t *testing.T) {
+	english := messages[English]
+	for _, a := range database.AllAttributes() {
+		if _, ok := english["attribute."+a.Key()]; !ok {
+			t.Errorf("English has no name for attribute %v", a)
+		}
+	}
+	for _, g := range database.RatingGroups {
+		if _, ok := english["group."+string(g)]; !ok {
+			t.Errorf("English has no name for group %v", g)
+		}
+	}
+	for _, p := range database.Positions {
+		if _, ok := english["position."+string(p)]; !ok {
+			t.Errorf("English has no name for position %v", p)
+		}
+	}
+	for _, tag := range Languages[1:] {
+		for key := range english {
+			if _, ok := messages[tag][key]; !ok {
+				t.Errorf("%v has no translation of %q", tag, key)
+			}
+		}
+		for key := range messages[tag] {
+			if _, ok := english[key]; !ok {
+				t.Errorf("%v translates %q, which isn't in the English catalogue", tag, key)
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, test := range []struct {
+		preference, acceptLanguage string
+		want                       language.Tag
+	}{
+		{"", "", English},
+		{"", "de-DE,de;q=0.9,en;q=0.8", language.German},
+		{"", "es-MX", language.Spanish},
+		{"", "fr-FR,es;q=0.5", language.Spanish},
+		{"", "ja", English},
+		{"es", "de", language.Spanish},
+		{"klingon", "de", language.German},
+		{"", "not a header;;", English},
+	} {
+		if got := Match(test.preference, test.acceptLanguage); got != test.want {
+			t.Errorf("Match(%q, %q) = %v, expected %v", test.preference, test.acceptLanguage, got, test.want)
+		}
+	}
+}
+
+func TestLocalizer(t *testing.T) {
+	date := time.Date(2024, time.March, 5, 15, 4, 0, 0, time.UTC)
+	for _, test := range []struct {
+		tag                            language.Tag
+		number, integer, dateTime, msg string
+	}{
+		{English, "1,234.5", "12,345", "5 Mar 2024 15:04", "Page 2 of 3"},
+		{language.Spanish, "1.234,5", "12.345", "5 mar 2024, 15:04", "Página 2 de 3"},
+		{language.German, "1.234,5", "12.345", "5. März 2024, 15:04", "Seite 2 von 3"},
+	} {
+		l := New(test.tag)
+		if got := l.Number(1234.5, 1); got != test.number {
+			t.Errorf("%v: Number() = %q, expected %q", test.tag, got, test.number)
+		}
+		if got := l.Integer(12345); got != test.integer {
+			t.Errorf("%v: Integer() = %q, expected %q", test.tag, got, test.integer)
+		}
+		if got := l.DateTime(date); got != test.dateTime {
+			t.Errorf("%v: DateTime() = %q, expected %q", test.tag, got, test.dateTime)
+		}
+		if got := l.T("players.pageOf", 2, 3); got != test.msg {
+			t.Errorf("%v: T() = %q, expected %q", test.tag, got, test.msg)
+		}
+	}
+
+	l := New(language.German)
+	if got := l.Attribute(database.Attribute{Group: database.AthleticRatings, Name: "Pace"}); got != "Schnelligkeit" {
+		t.Errorf("Expected the German name of Pace, got %q", got)
+	}
+	if got := l.Position(""); got != "Unbekannt" {
+		t.Errorf("Expected players without a position to be Unbekannt, got %q", got)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()).Tag; got != English {
+		t.Errorf("Expected English without a Localizer, got %v", got)
+	}
+	ctx := WithLocalizer(context.Background(), New(language.Spanish))
+	if got := FromContext(ctx).Tag; got != language.Spanish {
+		t.Errorf("Expected Spanish, got %v", got)
+	}
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var text bytes.Buffer
+	logger, err := New(&text, "text", slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("New(text) failed: %v", err)
+	}
+	logger.Debug("hidden")
+	logger.Info("shown", "scout", "abc")
+	if got := text.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "msg=shown scout=abc") {
+		t.Errorf("Unexpected text output %q", got)
+	}
+
+	var js bytes.Buffer
+	logger, err = New(&js, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("New(json) failed: %v", err)
+	}
+	logger.Info("shown", "scout", "abc")
+	var record map[string]any
+	if err := json.Unmarshal(js.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON record, got %q: %v", js.String(), err)
+	}
+	if record["msg"] != "shown" || record["scout"] != "abc" {
+		t.Errorf("Unexpected JSON record %v", record)
+	}
+
+	if _, err := New(&js, "xml", slog.LevelInfo); err == nil {
+		t.Errorf("Expected an unknown format to be rejected")
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if FromContext(ctx) != slog.Default() {
+		t.Errorf("Expected the default logger without a logger in the context")
+	}
+	if id := RequestID(ctx); id != "" {
+		t.Errorf("Expected no request ID, got %q", id)
+	}
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	ctx = WithRequestID(WithLogger(ctx, logger), "123")
+	if FromContext(ctx) != logger {
+		t.Errorf("Expected the logger in the context")
+	}
+	if id := RequestID(ctx); id != "123" {
+		t.Errorf("RequestID() = %q, want 123", id)
+	}
+}
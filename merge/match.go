@@ -0,0 +1,92 @@
+package merge
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MinSimilarity is how similar the names of two players with the same birthdate must be, from 0 to 1, to be
+// suggested as the same player.
+const MinSimilarity = 0.85
+
+// removeAccents turns e.g. "José" into "Jose".
+var removeAccents = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// normalizeName reduces a name to lower case words without accents or punctuation.
+func normalizeName(name string) string {
+	if stripped, _, err := transform.String(removeAccents, name); err == nil {
+		name = stripped
+	}
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// Similarity compares two names from 0, for nothing in common, to 1, for the same name. Case, accents, punctuation
+// and the order of the words don't matter, so "Álvarez, José" and "jose alvarez" are the same name.
+func Similarity(a, b string) float64 {
+	a, b = normalizeName(a), normalizeName(b)
+	return max(ratio(a, b), ratio(sortWords(a), sortWords(b)))
+}
+
+func sortWords(s string) string {
+	words := strings.Fields(s)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// ratio is 1 minus the edit distance between a and b relative to the longer of the two.
+func ratio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein is the number of single character insertions, deletions and substitutions that turn a into b.
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// sameBirthdate reports whether two profiles record the same birthdate. Birthdates that differ only by swapping the
+// day and month match as well, since Scouts enter both mm/dd and dd/mm dates. Profiles without a readable birthdate
+// never match.
+func sameBirthdate(a, b *database.PlayerAnalysis) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	ta, errA := a.BirthdateTime()
+	tb, errB := b.BirthdateTime()
+	if errA != nil || errB != nil {
+		return false
+	}
+	if ta.Equal(tb) {
+		return true
+	}
+	return ta.Year() == tb.Year() && int(ta.Month()) == tb.Day() && ta.Day() == int(tb.Month())
+}
@@ -0,0 +1,304 @@
+// Package merge combines the databases of several Scouts into one, e.g. to consolidate the players a club's scouts
+// recorded separately.
+//
+// Merging happens in two steps. NewPlan loads the players of every source and matches the same player across sources:
+// players with the same ID are the same player, and players with similar names and the same birthdate are suggested
+// as Candidates, which are only merged once confirmed. Merge then writes every player once, with one PlayerAnalysis
+// combined from all sources, and every analysis of every source. Each analysis is credited to the Scout it came from
+// with a database.Publication, and PlayerAnalysis fields that the sources disagree on are reported as Conflicts.
+// Only players and their analyses are merged.
+package merge
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+// Source is the database of one Scout.
+type Source struct {
+	// Name identifies the source in reports, e.g. the file name of the database.
+	Name string
+	DB   *gorm.DB
+}
+
+// PlayerRef is a player as recorded in one source.
+type PlayerRef struct {
+	// Source is the index of the source in Plan.Sources.
+	Source int
+	Player *database.Player
+	// Profile is nil if the source has no PlayerAnalysis for the player.
+	Profile *database.PlayerAnalysis
+}
+
+// Candidate suggests that two players from different sources are the same player.
+type Candidate struct {
+	A, B       PlayerRef
+	Similarity float64
+
+	a, b int
+}
+
+// Plan is how the players of several sources are matched.
+type Plan struct {
+	Sources []Source
+	// Candidates are the suggested matches, most similar first. Only those passed to Confirm are merged.
+	Candidates []Candidate
+
+	refs []PlayerRef
+	// parent links each ref to another of the same player, forming a disjoint-set forest.
+	parent []int
+	// scouts are the Scouts of the sources, nil for sources without one.
+	scouts []*database.Scout
+}
+
+// NewPlan loads the players of every source and matches them.
+func NewPlan(sources []Source) (*Plan, error) {
+	p := &Plan{Sources: sources}
+	byID := map[uuid.UUID]int{}
+	for i, source := range sources {
+		players, err := database.AllPlayers(source.DB)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", source.Name, err)
+		}
+		profiles, err := database.PlayerAnalysesByPlayer(source.DB)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", source.Name, err)
+		}
+		var scouts []*database.Scout
+		if err := source.DB.Order("created_at").Limit(1).Find(&scouts).Error; err != nil {
+			return nil, fmt.Errorf("source %s: retrieving the Scout failed: %w", source.Name, err)
+		}
+		p.scouts = append(p.scouts, nil)
+		if len(scouts) > 0 {
+			p.scouts[i] = scouts[0]
+		}
+		sort.Slice(players, func(a, b int) bool { return players[a].CreatedAt.Before(players[b].CreatedAt) })
+		for _, player := range players {
+			ref := len(p.refs)
+			p.refs = append(p.refs, PlayerRef{Source: i, Player: player, Profile: profiles[player.ID]})
+			p.parent = append(p.parent, ref)
+			if first, ok := byID[player.ID]; ok {
+				p.union(first, ref)
+			} else {
+				byID[player.ID] = ref
+			}
+		}
+	}
+
+	for a := range p.refs {
+		for b := a + 1; b < len(p.refs); b++ {
+			ra, rb := p.refs[a], p.refs[b]
+			if ra.Source == rb.Source || p.find(a) == p.find(b) || !sameBirthdate(ra.Profile, rb.Profile) {
+				continue
+			}
+			if similarity := Similarity(ra.Player.Name, rb.Player.Name); similarity >= MinSimilarity {
+				p.Candidates = append(p.Candidates, Candidate{A: ra, B: rb, Similarity: similarity, a: a, b: b})
+			}
+		}
+	}
+	sort.SliceStable(p.Candidates, func(i, j int) bool { return p.Candidates[i].Similarity > p.Candidates[j].Similarity })
+	return p, nil
+}
+
+// Confirm merges the players of a candidate.
+func (p *Plan) Confirm(c Candidate) {
+	p.union(c.a, c.b)
+}
+
+func (p *Plan) find(i int) int {
+	for p.parent[i] != i {
+		p.parent[i] = p.parent[p.parent[i]]
+		i = p.parent[i]
+	}
+	return p.parent[i]
+}
+
+// union joins the sets of a and b, keeping the earlier ref as the root so that merged players keep the ID and name
+// of the first source.
+func (p *Plan) union(a, b int) {
+	ra, rb := p.find(a), p.find(b)
+	if ra > rb {
+		ra, rb = rb, ra
+	}
+	p.parent[rb] = ra
+}
+
+// groups returns the refs of each player, in the order of their first ref.
+func (p *Plan) groups() [][]PlayerRef {
+	index := map[int]int{}
+	var groups [][]PlayerRef
+	for i, ref := range p.refs {
+		root := p.find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], ref)
+	}
+	return groups
+}
+
+// Conflict is a PlayerAnalysis field that the sources of a merged player disagree on.
+type Conflict struct {
+	PlayerID   uuid.UUID
+	PlayerName string
+	// Field is the name of the PlayerAnalysis field, e.g. "Club".
+	Field string
+	// Values are the differing values, by the player they were recorded for. A source may have several of them when
+	// players it recorded separately were merged. Empty values aren't conflicts and are left out.
+	Values map[PlayerRef]string
+	// Kept is the value written to the merged database, which comes from the most recently updated profile.
+	Kept string
+}
+
+// Report summarises a merge.
+type Report struct {
+	Players int
+	// Merged is how many of the players were recorded in more than one source.
+	Merged   int
+	Analyses int
+	// Duplicates is how many analyses were left out because another source has the same one, with the same ID.
+	Duplicates int
+	Conflicts  []Conflict
+}
+
+// conflictFields are the PlayerAnalysis fields that are compared between sources.
+var conflictFields = []string{"Birthdate", "Height", "Weight", "Club", "Position", "ManagerName", "Telephone", "Notes"}
+
+// Merge writes the players of the plan and all of their analyses to an empty database.
+func Merge(to *gorm.DB, p *Plan) (*Report, error) {
+	report := &Report{}
+	err := to.Transaction(func(tx *gorm.DB) error {
+		// The IDs of the sources are kept, rather than generated by BaseModel.BeforeCreate.
+		tx = tx.Session(&gorm.Session{SkipHooks: true})
+		analysisIDs := map[uuid.UUID]bool{}
+		for _, group := range p.groups() {
+			player := *group[0].Player
+			if err := tx.Create(&player).Error; err != nil {
+				return fmt.Errorf("creating Player %s failed: %w", player.ID, err)
+			}
+			report.Players++
+			if len(group) > 1 {
+				report.Merged++
+			}
+			profile, conflicts := p.mergeProfiles(player.ID, player.Name, group)
+			report.Conflicts = append(report.Conflicts, conflicts...)
+			if profile != nil {
+				if err := tx.Create(profile).Error; err != nil {
+					return fmt.Errorf("creating PlayerAnalysis of Player %s failed: %w", player.ID, err)
+				}
+			}
+
+			for _, ref := range group {
+				analyses, err := database.AnalysesForPlayer(p.Sources[ref.Source].DB, ref.Player.ID)
+				if err != nil {
+					return fmt.Errorf("source %s: %w", p.Sources[ref.Source].Name, err)
+				}
+				for _, a := range analyses {
+					if analysisIDs[a.ID] {
+						report.Duplicates++
+						continue
+					}
+					analysisIDs[a.ID] = true
+					a.PlayerID = player.ID
+					for _, record := range a.Records() {
+						if err := tx.Create(record).Error; err != nil {
+							return fmt.Errorf("creating Analysis %s failed: %w", a.ID, err)
+						}
+					}
+					if err := tx.Create(p.credit(ref.Source, a.Analysis)).Error; err != nil {
+						return fmt.Errorf("crediting Analysis %s failed: %w", a.ID, err)
+					}
+					report.Analyses++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// credit attributes an analysis to the Scout of the source it came from.
+func (p *Plan) credit(source int, a *database.Analysis) *database.Publication {
+	publication := &database.Publication{
+		RecordID:    a.ID,
+		Kind:        database.PublishedAnalysis,
+		Scout:       p.Sources[source].Name,
+		ScoutName:   p.Sources[source].Name,
+		PublishedAt: a.CreatedAt,
+	}
+	publication.ID = uuid.New()
+	if scout := p.scouts[source]; scout != nil && scout.Username != "" {
+		publication.ScoutName = scout.Username
+	}
+	return publication
+}
+
+// mergeProfiles combines the profiles of a player. Every field is taken from the most recently updated profile that
+// has a value for it, and fields with differing values are reported as conflicts.
+func (p *Plan) mergeProfiles(playerID uuid.UUID, name string, group []PlayerRef) (*database.PlayerAnalysis, []Conflict) {
+	var refs []PlayerRef
+	for _, ref := range group {
+		if ref.Profile != nil {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].Profile.UpdatedAt.After(refs[j].Profile.UpdatedAt) })
+
+	merged := *refs[0].Profile
+	merged.PlayerID = playerID
+	target := reflect.ValueOf(&merged).Elem()
+	var conflicts []Conflict
+	for _, field := range conflictFields {
+		values := map[PlayerRef]string{}
+		distinct := map[string]bool{}
+		kept := false
+		for _, ref := range refs {
+			value := reflect.ValueOf(ref.Profile).Elem().FieldByName(field)
+			if value.IsZero() {
+				continue
+			}
+			if !kept {
+				target.FieldByName(field).Set(value)
+				kept = true
+			}
+			values[ref] = fmt.Sprint(value.Interface())
+			distinct[comparedValue(ref.Profile, field)] = true
+		}
+		if len(distinct) > 1 {
+			conflicts = append(conflicts, Conflict{
+				PlayerID:   playerID,
+				PlayerName: name,
+				Field:      field,
+				Values:     values,
+				Kept:       fmt.Sprint(target.FieldByName(field).Interface()),
+			})
+		}
+	}
+	return &merged, conflicts
+}
+
+// comparedValue is the value of a PlayerAnalysis field as compared between sources. Birthdates are compared as
+// dates, so that the same date in different formats isn't a conflict.
+func comparedValue(profile *database.PlayerAnalysis, field string) string {
+	if field == "Birthdate" {
+		if birthdate, err := profile.BirthdateTime(); err == nil {
+			return birthdate.Format(time.DateOnly)
+		}
+	}
+	return fmt.Sprint(reflect.ValueOf(profile).Elem().FieldByName(field).Interface())
+}
@@ -0,0 +1,162 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+// createPlayer creates a player with a profile and one analysis, keeping the given ID unless it is uuid.Nil.
+func createPlayer(t *testing.T, db *gorm.DB, id uuid.UUID, name string, profile database.PlayerAnalysis) *database.Analysis {
+	t.Helper()
+	player := &database.Player{Name: name}
+	tx := db
+	if id != uuid.Nil {
+		player.ID = id
+		tx = db.Session(&gorm.Session{SkipHooks: true})
+	}
+	if err := tx.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	profile.PlayerID = player.ID
+	if err := db.Create(&profile).Error; err != nil {
+		t.Fatalf("Failed to create PlayerAnalysis: %v", err)
+	}
+	analysis := &database.Analysis{PlayerID: player.ID, Date: "2024-01-01 15:00"}
+	if err := db.Create(analysis).Error; err != nil {
+		t.Fatalf("Failed to create Analysis: %v", err)
+	}
+	return analysis
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"José Álvarez", "Jose Alvarez", true},
+		{"Álvarez, José", "jose alvarez", true},
+		{"Jon Smith", "John Smith", true},
+		{"John Smith", "Jane Smythe", false},
+		{"Luis Garcia", "Luis Martinez", false},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b) >= MinSimilarity; got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want similar: %v", tt.a, tt.b, Similarity(tt.a, tt.b), tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	ann, bo := database.CreateTestDB(t), database.CreateTestDB(t)
+	if err := ann.Create(&database.Scout{Username: "Ann"}).Error; err != nil {
+		t.Fatalf("Failed to create Scout: %v", err)
+	}
+
+	// The same player by ID, e.g. published to both Scouts, whose clubs disagree.
+	shared := uuid.New()
+	annShared := createPlayer(t, ann, shared, "Shared", database.PlayerAnalysis{Club: "Rovers", Height: 180})
+	boShared := createPlayer(t, bo, shared, "Shared", database.PlayerAnalysis{Club: "United"})
+	// The same player by name and birthdate, entered separately.
+	annJose := createPlayer(t, ann, uuid.Nil, "José Álvarez", database.PlayerAnalysis{Birthdate: "03/04/05"})
+	boJose := createPlayer(t, bo, uuid.Nil, "Alvarez, Jose", database.PlayerAnalysis{Birthdate: "2005-03-04"})
+	// The same name, but a different player.
+	createPlayer(t, ann, uuid.Nil, "Luis Garcia", database.PlayerAnalysis{Birthdate: "01/01/01"})
+	createPlayer(t, bo, uuid.Nil, "Luis Garcia", database.PlayerAnalysis{Birthdate: "06/07/08"})
+
+	plan, err := NewPlan([]Source{{Name: "ann.db", DB: ann}, {Name: "bo.db", DB: bo}})
+	if err != nil {
+		t.Fatalf("NewPlan() failed: %v", err)
+	}
+	if len(plan.Candidates) != 1 {
+		t.Fatalf("Expected 1 candidate, got %+v", plan.Candidates)
+	}
+	if c := plan.Candidates[0]; c.A.Player.Name != "José Álvarez" || c.B.Player.Name != "Alvarez, Jose" {
+		t.Errorf("Expected José Álvarez to be a candidate, got %s and %s", c.A.Player.Name, c.B.Player.Name)
+	}
+	plan.Confirm(plan.Candidates[0])
+
+	merged := database.CreateTestDB(t)
+	report, err := Merge(merged, plan)
+	if err != nil {
+		t.Fatalf("Merge() failed: %v", err)
+	}
+	if report.Players != 4 || report.Merged != 2 || report.Analyses != 6 {
+		t.Errorf("Expected 4 players, 2 merged and 6 analyses, got %+v", report)
+	}
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %+v", report.Conflicts)
+	}
+	if c := report.Conflicts[0]; c.Field != "Club" || valuesBySource(plan, c)["ann.db"] != "Rovers" || valuesBySource(plan, c)["bo.db"] != "United" {
+		t.Errorf("Expected the club to conflict, got %+v", c)
+	}
+
+	profiles, err := database.PlayerAnalysesByPlayer(merged)
+	if err != nil {
+		t.Fatalf("PlayerAnalysesByPlayer() failed: %v", err)
+	}
+	if p := profiles[shared]; p == nil || p.Height != 180 || p.Club != report.Conflicts[0].Kept {
+		t.Errorf("Expected the merged profile to keep every value, got %+v", p)
+	}
+	analyses, err := database.AnalysesForPlayer(merged, annJose.PlayerID)
+	if err != nil {
+		t.Fatalf("AnalysesForPlayer() failed: %v", err)
+	}
+	if len(analyses) != 2 {
+		t.Errorf("Expected both analyses of José Álvarez, got %d", len(analyses))
+	}
+
+	publications, err := database.Publications(merged, database.PublishedAnalysis)
+	if err != nil {
+		t.Fatalf("Publications() failed: %v", err)
+	}
+	for analysis, want := range map[*database.Analysis]string{annShared: "Ann", boShared: "bo.db", boJose: "bo.db"} {
+		if p := publications[analysis.ID]; p == nil || p.ScoutName != want {
+			t.Errorf("Expected Analysis %s to be credited to %s, got %+v", analysis.ID, want, p)
+		}
+	}
+}
+
+// valuesBySource returns the values of a conflict by the name of their source, for conflicts with one value per source.
+func valuesBySource(plan *Plan, c Conflict) map[string]string {
+	values := map[string]string{}
+	for ref, value := range c.Values {
+		values[plan.Sources[ref.Source].Name] = value
+	}
+	return values
+}
+
+func TestMergeConflictsWithinSource(t *testing.T) {
+	ann, bo := database.CreateTestDB(t), database.CreateTestDB(t)
+	// Bo entered the same player twice, with different clubs.
+	createPlayer(t, ann, uuid.Nil, "José Álvarez", database.PlayerAnalysis{Birthdate: "03/04/05", Club: "Rovers"})
+	createPlayer(t, bo, uuid.Nil, "Jose Alvarez", database.PlayerAnalysis{Birthdate: "03/04/05", Club: "United"})
+	createPlayer(t, bo, uuid.Nil, "Alvarez, Jose", database.PlayerAnalysis{Birthdate: "03/04/05", Club: "City"})
+
+	plan, err := NewPlan([]Source{{Name: "ann.db", DB: ann}, {Name: "bo.db", DB: bo}})
+	if err != nil {
+		t.Fatalf("NewPlan() failed: %v", err)
+	}
+	if len(plan.Candidates) != 2 {
+		t.Fatalf("Expected 2 candidates, got %+v", plan.Candidates)
+	}
+	for _, c := range plan.Candidates {
+		plan.Confirm(c)
+	}
+	report, err := Merge(database.CreateTestDB(t), plan)
+	if err != nil {
+		t.Fatalf("Merge() failed: %v", err)
+	}
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %+v", report.Conflicts)
+	}
+	byName := map[string]string{}
+	for ref, value := range report.Conflicts[0].Values {
+		byName[ref.Player.Name] = value
+	}
+	if len(byName) != 3 || byName["José Álvarez"] != "Rovers" || byName["Jose Alvarez"] != "United" || byName["Alvarez, Jose"] != "City" {
+		t.Errorf("Expected the club of every player, got %v", byName)
+	}
+}
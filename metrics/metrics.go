@@ -0,0 +1,131 @@
+// Package metrics collects Prometheus metrics about requests and Scout databases.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+const namespace = "scoutingapp"
+
+// Metrics are the metrics of one server. They are kept in their own registry rather than the global one, so that
+// several servers can run in the same process, as they do in tests.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.HistogramVec
+	queries  *prometheus.HistogramVec
+	created  *prometheus.CounterVec
+}
+
+// New returns Metrics including the standard Go runtime and process metrics.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to handle HTTP requests, by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "query_duration_seconds",
+			Help:      "Time taken by database queries, by kind of query.",
+			Buckets:   []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"operation"}),
+		created: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "records_created_total",
+			Help:      "Number of players and analyses created.",
+		}, []string{"table"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.queries,
+		m.created,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records how long a request took. route is the route pattern rather than the path, so that the number
+// of series doesn't grow with the number of players.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// OpenDatabases exposes the number of open Scout databases, as reported by count.
+func (m *Metrics) OpenDatabases(count func() int) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "open_databases",
+		Help:      "Number of open Scout databases.",
+	}, func() float64 { return float64(count()) }))
+}
+
+// createdTables are the tables whose created records are counted.
+var createdTables = map[string]bool{"players": true, "analyses": true}
+
+const startKey = "metrics:start"
+
+// Plugin returns a gorm plugin that records the duration of queries and counts created players and analyses.
+func (m *Metrics) Plugin() gorm.Plugin {
+	return plugin{m}
+}
+
+type plugin struct {
+	m *Metrics
+}
+
+func (plugin) Name() string {
+	return "metrics"
+}
+
+// Initialize registers callbacks around every kind of query.
+func (p plugin) Initialize(db *gorm.DB) error {
+	start := func(db *gorm.DB) {
+		db.InstanceSet(startKey, time.Now())
+	}
+	end := func(operation string) func(*gorm.DB) {
+		return func(db *gorm.DB) {
+			if begin, ok := db.InstanceGet(startKey); ok {
+				p.m.queries.WithLabelValues(operation).Observe(time.Since(begin.(time.Time)).Seconds())
+			}
+			if operation == "create" && db.Error == nil && createdTables[db.Statement.Table] {
+				p.m.created.WithLabelValues(db.Statement.Table).Add(float64(db.Statement.RowsAffected))
+			}
+		}
+	}
+	c := db.Callback()
+	for _, err := range []error{
+		c.Create().Before("gorm:create").Register("metrics:before_create", start),
+		c.Create().After("gorm:create").Register("metrics:after_create", end("create")),
+		c.Query().Before("gorm:query").Register("metrics:before_query", start),
+		c.Query().After("gorm:query").Register("metrics:after_query", end("query")),
+		c.Update().Before("gorm:update").Register("metrics:before_update", start),
+		c.Update().After("gorm:update").Register("metrics:after_update", end("update")),
+		c.Delete().Before("gorm:delete").Register("metrics:before_delete", start),
+		c.Delete().After("gorm:delete").Register("metrics:after_delete", end("delete")),
+		c.Row().Before("gorm:row").Register("metrics:before_row", start),
+		c.Row().After("gorm:row").Register("metrics:after_row", end("row")),
+		c.Raw().Before("gorm:raw").Register("metrics:before_raw", start),
+		c.Raw().After("gorm:raw").Register("metrics:after_raw", end("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
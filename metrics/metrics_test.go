@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+func TestPlugin(t *testing.T) {
+	m := New()
+	db, err := database.Load(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { database.SaveToFile(db) })
+	if err := db.Use(m.Plugin()); err != nil {
+		t.Fatalf("Failed to add plugin: %v", err)
+	}
+
+	players := []*database.Player{{Name: "A"}, {Name: "B"}}
+	if err := db.Create(&players).Error; err != nil {
+		t.Fatalf("Failed to create players: %v", err)
+	}
+	if err := db.Create(&database.Analysis{PlayerID: players[0].ID}).Error; err != nil {
+		t.Fatalf("Failed to create analysis: %v", err)
+	}
+	if err := db.Create(&database.WeightProfile{Name: "Not counted"}).Error; err != nil {
+		t.Fatalf("Failed to create weight profile: %v", err)
+	}
+	if err := db.Find(&players).Error; err != nil {
+		t.Fatalf("Failed to find players: %v", err)
+	}
+
+	got := scrape(t, m)
+	for _, want := range []string{
+		`scoutingapp_records_created_total{table="players"} 2`,
+		`scoutingapp_records_created_total{table="analyses"} 1`,
+		`scoutingapp_query_duration_seconds_count{operation="query"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "weight_profiles") {
+		t.Errorf("Expected only players and analyses to be counted")
+	}
+}
+
+func TestRequestsAndOpenDatabases(t *testing.T) {
+	m := New()
+	m.OpenDatabases(func() int { return 3 })
+	m.ObserveRequest("GET", "/players/:id", 200, 10*time.Millisecond)
+
+	got := scrape(t, m)
+	for _, want := range []string{
+		`scoutingapp_open_databases 3`,
+		`scoutingapp_request_duration_seconds_count{method="GET",route="/players/:id",status="200"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, got)
+		}
+	}
+}
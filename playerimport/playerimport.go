@@ -0,0 +1,394 @@
+// Package playerimport imports players and their profiles from CSV files. Importing is done in steps: the file is
+// parsed, its columns are mapped to player fields, every row is validated in a dry run, and only then is the import
+// committed, either completely or not at all.
+package playerimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+// MaxRows is the largest number of players imported from one file.
+const MaxRows = 5000
+
+// Field is a field of a player or their profile that a column can be imported into.
+type Field string
+
+const (
+	Ignore    Field = ""
+	Name      Field = "name"
+	Birthdate Field = "birthdate"
+	Height    Field = "height"
+	Weight    Field = "weight"
+	Club      Field = "club"
+	Position  Field = "position"
+	Manager   Field = "manager"
+	Telephone Field = "telephone"
+	Notes     Field = "notes"
+)
+
+// Fields lists every field in display order.
+var Fields = []Field{Name, Birthdate, Height, Weight, Club, Position, Manager, Telephone, Notes}
+
+// Ranges of the measurements that are accepted. Values outside of them are almost certainly typing mistakes or
+// other units, such as inches.
+const (
+	MinHeight = 100 // centimetres
+	MaxHeight = 230
+	MinWeight = 30 // kgs
+	MaxWeight = 150
+)
+
+// ErrInvalidFile is wrapped by errors caused by files that can't be imported at all.
+var ErrInvalidFile = errors.New("invalid CSV file")
+
+// ErrInvalidRows is returned by Commit when rows failed validation, in which case nothing was imported.
+var ErrInvalidRows = errors.New("some rows are invalid")
+
+// File is a parsed CSV file.
+type File struct {
+	Header []string
+	// Records are the rows after the header. Every record has a value for every column of the header.
+	Records [][]string
+}
+
+// Parse reads a CSV file with a header row. Rows may have fewer values than the header; missing values are empty.
+func Parse(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV file failed: %w", err)
+	}
+	// Spreadsheets often save a byte order mark, which would otherwise end up in the first column name.
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidFile)
+	}
+	if len(records)-1 > MaxRows {
+		return nil, fmt.Errorf("%w: the file has more than %d rows", ErrInvalidFile, MaxRows)
+	}
+	f := &File{Header: records[0]}
+	for _, record := range records[1:] {
+		if isBlank(record) {
+			continue
+		}
+		padded := make([]string, len(f.Header))
+		copy(padded, record)
+		f.Records = append(f.Records, padded)
+	}
+	return f, nil
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Mapping is the field that each column of a file is imported into, by column index.
+type Mapping []Field
+
+// headerNames are the column names recognised by GuessMapping, besides the names of the fields themselves. They
+// include the column names of the CSV export.
+var headerNames = map[string]Field{
+	"player":        Name,
+	"full name":     Name,
+	"dob":           Birthdate,
+	"date of birth": Birthdate,
+	"height_cm":     Height,
+	"height (cm)":   Height,
+	"weight_kg":     Weight,
+	"weight (kg)":   Weight,
+	"team":          Club,
+	"pos":           Position,
+	"manager name":  Manager,
+	"phone":         Telephone,
+	"tel":           Telephone,
+}
+
+// GuessMapping maps columns whose names match a field, ignoring case. Other columns are ignored, as is every column
+// after the first that matches the same field.
+func GuessMapping(header []string) Mapping {
+	m := make(Mapping, len(header))
+	used := map[Field]bool{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		field, ok := headerNames[name]
+		if !ok {
+			for _, f := range Fields {
+				if name == string(f) {
+					field = f
+				}
+			}
+		}
+		if field != Ignore && !used[field] {
+			m[i] = field
+			used[field] = true
+		}
+	}
+	return m
+}
+
+// Validate checks that the mapping fits the file: every column is mapped to a known field, at most once, and the
+// name is mapped.
+func (m Mapping) Validate(f *File) error {
+	if len(m) != len(f.Header) {
+		return fmt.Errorf("%w: the mapping has %d columns but the file has %d", ErrInvalidFile, len(m), len(f.Header))
+	}
+	used := map[Field]bool{}
+	for _, field := range m {
+		if field == Ignore {
+			continue
+		}
+		if !field.valid() {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidFile, field)
+		}
+		if used[field] {
+			return fmt.Errorf("%w: more than one column is mapped to %s", ErrInvalidFile, field)
+		}
+		used[field] = true
+	}
+	if !used[Name] {
+		return fmt.Errorf("%w: no column is mapped to the name", ErrInvalidFile)
+	}
+	return nil
+}
+
+func (f Field) valid() bool {
+	for _, field := range Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Problem is a reason a row can't be imported. Problems are identified by a code so that they can be explained in
+// the Scout's language.
+type Problem struct {
+	Field Field
+	// Code is one of "required", "birthdate", "futureBirthdate", "number", "height", "weight", "position",
+	// "duplicate" or "duplicateInFile".
+	Code  string
+	Value string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s %q", p.Field, p.Code, p.Value)
+}
+
+// Row is the result of importing one row of the file.
+type Row struct {
+	// Line is the line number of the row in the file, counting the header as line 1.
+	Line    int
+	Player  *database.Player
+	Profile *database.PlayerAnalysis
+	// Problems is empty if the row can be imported.
+	Problems []Problem
+	// Skipped is set for duplicates when they are skipped rather than treated as problems.
+	Skipped bool
+}
+
+// Options change how rows are imported.
+type Options struct {
+	// SkipDuplicates skips rows whose player already exists, rather than failing the import.
+	SkipDuplicates bool
+}
+
+// Report is the outcome of a dry run or of a commit.
+type Report struct {
+	Rows []*Row
+	// Imported is the number of rows that are, or would be, imported.
+	Imported int
+	Skipped  int
+	Invalid  int
+}
+
+// Valid reports whether the import can be committed.
+func (r *Report) Valid() bool {
+	return r.Invalid == 0
+}
+
+// DryRun validates every row against the existing players, without saving anything.
+func DryRun(db *gorm.DB, f *File, m Mapping, opts Options, now time.Time) (*Report, error) {
+	if err := m.Validate(f); err != nil {
+		return nil, err
+	}
+	existing, err := database.AllPlayers(db)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, p := range existing {
+		seen[nameKey(p.Name)] = true
+	}
+	inFile := map[string]int{}
+
+	report := &Report{}
+	for i, record := range f.Records {
+		row := convert(record, m, now)
+		row.Line = i + 2
+		key := nameKey(row.Player.Name)
+		switch {
+		case key == "":
+		case seen[key] && opts.SkipDuplicates:
+			row.Skipped = true
+		case seen[key]:
+			row.Problems = append(row.Problems, Problem{Field: Name, Code: "duplicate", Value: row.Player.Name})
+		case inFile[key] != 0:
+			row.Problems = append(row.Problems, Problem{Field: Name, Code: "duplicateInFile", Value: strconv.Itoa(inFile[key])})
+		default:
+			inFile[key] = row.Line
+		}
+		switch {
+		case len(row.Problems) > 0:
+			report.Invalid++
+		case row.Skipped:
+			report.Skipped++
+		default:
+			report.Imported++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+// Commit validates the rows like DryRun and imports them if they are all valid. If any row is invalid nothing is
+// imported, and ErrInvalidRows is returned along with the report explaining why.
+func Commit(db *gorm.DB, f *File, m Mapping, opts Options, now time.Time) (*Report, error) {
+	var report *Report
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		// Validating within the transaction ensures that duplicates are checked against the players being imported
+		// into.
+		if report, err = DryRun(tx, f, m, opts, now); err != nil {
+			return err
+		}
+		if !report.Valid() {
+			return ErrInvalidRows
+		}
+		for _, row := range report.Rows {
+			if row.Skipped {
+				continue
+			}
+			if err := tx.Create(row.Player).Error; err != nil {
+				return fmt.Errorf("creating Player on line %d failed: %w", row.Line, err)
+			}
+			if row.Profile == nil {
+				continue
+			}
+			row.Profile.PlayerID = row.Player.ID
+			if err := tx.Create(row.Profile).Error; err != nil {
+				return fmt.Errorf("creating PlayerAnalysis on line %d failed: %w", row.Line, err)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, ErrInvalidRows) {
+		return report, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// nameKey is how names are compared when looking for duplicates.
+func nameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// convert reads a record into a player and their profile, recording every problem with it. The profile is nil if
+// none of its fields have a value.
+func convert(record []string, m Mapping, now time.Time) *Row {
+	row := &Row{Player: &database.Player{}}
+	profile := &database.PlayerAnalysis{}
+	hasProfile := false
+	for i, field := range m {
+		value := strings.TrimSpace(record[i])
+		if field == Ignore || value == "" {
+			continue
+		}
+		if field != Name {
+			hasProfile = true
+		}
+		switch field {
+		case Name:
+			row.Player.Name = strings.Join(strings.Fields(value), " ")
+		case Birthdate:
+			profile.Birthdate = value
+			if _, err := profile.BirthdateTime(); err != nil {
+				row.Problems = append(row.Problems, Problem{Field: field, Code: "birthdate", Value: value})
+			} else if _, err := profile.Age(now); err != nil {
+				row.Problems = append(row.Problems, Problem{Field: field, Code: "futureBirthdate", Value: value})
+			}
+		case Height:
+			profile.Height = measurement(row, field, value, MinHeight, MaxHeight)
+		case Weight:
+			profile.Weight = measurement(row, field, value, MinWeight, MaxWeight)
+		case Club:
+			profile.Club = value
+		case Position:
+			position, ok := parsePosition(value)
+			if !ok {
+				row.Problems = append(row.Problems, Problem{Field: field, Code: "position", Value: value})
+			}
+			profile.Position = position
+		case Manager:
+			profile.ManagerName = value
+		case Telephone:
+			profile.Telephone = value
+		case Notes:
+			profile.Notes = value
+		}
+	}
+	if row.Player.Name == "" {
+		row.Problems = append(row.Problems, Problem{Field: Name, Code: "required"})
+	}
+	if hasProfile {
+		row.Profile = profile
+	}
+	return row
+}
+
+// measurement parses a whole number within [min, max], recording a problem with the row otherwise.
+func measurement(row *Row, field Field, value string, min, max int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		row.Problems = append(row.Problems, Problem{Field: field, Code: "number", Value: value})
+		return 0
+	}
+	if n < min || n > max {
+		row.Problems = append(row.Problems, Problem{Field: field, Code: string(field), Value: value})
+		return 0
+	}
+	return n
+}
+
+// parsePosition accepts the name of a position in any case.
+func parsePosition(value string) (database.PositionType, bool) {
+	for _, p := range database.Positions {
+		if strings.EqualFold(value, string(p)) {
+			return p, true
+		}
+	}
+	return "", false
+}
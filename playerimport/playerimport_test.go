@@ -0,0 +1,187 @@
+package playerimport
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/export"
+	"github.com/thirdknife/scoutingapp/playerlist"
+	"gorm.io/gorm"
+)
+
+var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func parse(t *testing.T, s string) *File {
+	t.Helper()
+	f, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	return f
+}
+
+func countPlayers(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&database.Player{}).Count(&n).Error; err != nil {
+		t.Fatalf("Failed to count players: %v", err)
+	}
+	return n
+}
+
+func TestParse(t *testing.T) {
+	f := parse(t, "\ufeffName,Club\nA,Rovers\n\n,\nB\n")
+	if len(f.Header) != 2 || f.Header[0] != "Name" {
+		t.Errorf("Expected the byte order mark to be removed from the header, got %q", f.Header)
+	}
+	if len(f.Records) != 2 || f.Records[1][0] != "B" || f.Records[1][1] != "" {
+		t.Errorf("Expected blank rows to be dropped and short rows padded, got %q", f.Records)
+	}
+
+	for _, s := range []string{"", "Name\n\"unterminated\n", "Name\n" + strings.Repeat("x\n", MaxRows+1)} {
+		if _, err := Parse(strings.NewReader(s)); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("Expected ErrInvalidFile for %.20q, got %v", s, err)
+		}
+	}
+}
+
+func TestGuessMapping(t *testing.T) {
+	got := GuessMapping([]string{"Full Name", "DOB", "height_cm", "Shirt", "Position", "name"})
+	want := Mapping{Name, Birthdate, Height, Ignore, Position, Ignore}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("GuessMapping() = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestImportExportedPlayers(t *testing.T) {
+	db := database.CreateTestDB(t)
+	player := &database.Player{Name: "Exported"}
+	profile := &database.PlayerAnalysis{Club: "FC Test", Notes: "Left-footed"}
+	var b bytes.Buffer
+	rows := []*playerlist.Row{{PlayerSummary: &database.PlayerSummary{Player: player, Profile: profile}}}
+	if err := export.WritePlayersCSV(&b, rows); err != nil {
+		t.Fatalf("WritePlayersCSV() failed: %v", err)
+	}
+	f, err := Parse(&b)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	report, err := DryRun(db, f, GuessMapping(f.Header), Options{}, now)
+	if err != nil {
+		t.Fatalf("DryRun() failed: %v", err)
+	}
+	if len(report.Rows) != 1 || report.Rows[0].Profile == nil {
+		t.Fatalf("Expected the exported player with a profile, got %+v", report.Rows)
+	}
+	got := report.Rows[0]
+	if got.Player.Name != "Exported" || got.Profile.Club != "FC Test" || got.Profile.Notes != "Left-footed" {
+		t.Errorf("Unexpected import of the export: %+v %+v", got.Player, got.Profile)
+	}
+}
+
+func TestMappingValidate(t *testing.T) {
+	f := parse(t, "a,b\n")
+	for _, m := range []Mapping{{Name}, {Club, Ignore}, {Name, Name}, {Name, "shoe size"}} {
+		if err := m.Validate(f); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("Expected mapping %v to be rejected, got %v", m, err)
+		}
+	}
+	if err := (Mapping{Name, Club}).Validate(f); err != nil {
+		t.Errorf("Expected a valid mapping, got %v", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	db := database.CreateTestDB(t)
+	if err := db.Create(&database.Player{Name: "Existing Player"}).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	f := parse(t, `name,birthdate,height,position
+Valid,05/06/05,181,forward
+,01/01/00,,
+Bad Date,31/12/2005,,
+Future,2030-01-01,,
+Tall,,251,
+Short,,abc,
+Unknown Position,,,Sweeper
+existing  player,,,
+Twice,,,
+twice,,,
+`)
+	report, err := DryRun(db, f, GuessMapping(f.Header), Options{}, now)
+	if err != nil {
+		t.Fatalf("DryRun() failed: %v", err)
+	}
+	want := map[int]string{
+		2:  "",
+		3:  "required",
+		4:  "birthdate",
+		5:  "futureBirthdate",
+		6:  "height",
+		7:  "number",
+		8:  "position",
+		9:  "duplicate",
+		10: "",
+		11: "duplicateInFile",
+	}
+	for _, row := range report.Rows {
+		var code string
+		if len(row.Problems) > 0 {
+			code = row.Problems[0].Code
+		}
+		if code != want[row.Line] {
+			t.Errorf("Line %d has problems %v, want %q", row.Line, row.Problems, want[row.Line])
+		}
+	}
+	if report.Imported != 2 || report.Invalid != 8 || report.Valid() {
+		t.Errorf("Unexpected report totals: imported %d, invalid %d", report.Imported, report.Invalid)
+	}
+	valid := report.Rows[0]
+	if valid.Profile == nil || valid.Profile.Position != database.Forward || valid.Profile.Height != 181 {
+		t.Errorf("Unexpected profile %+v", valid.Profile)
+	}
+	if report.Rows[8].Profile != nil {
+		t.Errorf("Expected no profile for a row with only a name")
+	}
+	if n := countPlayers(t, db); n != 1 {
+		t.Errorf("Expected a dry run not to save anything, got %d players", n)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	db := database.CreateTestDB(t)
+	if err := db.Create(&database.Player{Name: "Existing"}).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+
+	f := parse(t, "name,club\nNew,Rovers\nExisting,United\nBroken,\n")
+	invalid := parse(t, "name,height\nNew,181\nBroken,5\n")
+	if _, err := Commit(db, invalid, GuessMapping(invalid.Header), Options{}, now); !errors.Is(err, ErrInvalidRows) {
+		t.Fatalf("Expected ErrInvalidRows, got %v", err)
+	}
+	if n := countPlayers(t, db); n != 1 {
+		t.Fatalf("Expected nothing to be imported from an invalid file, got %d players", n)
+	}
+
+	report, err := Commit(db, f, GuessMapping(f.Header), Options{SkipDuplicates: true}, now)
+	if err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+	if report.Imported != 2 || report.Skipped != 1 {
+		t.Errorf("Expected 2 imported and 1 skipped, got %d and %d", report.Imported, report.Skipped)
+	}
+	if n := countPlayers(t, db); n != 3 {
+		t.Errorf("Expected 3 players after the import, got %d", n)
+	}
+	profile, err := database.PlayerAnalysisFor(db, report.Rows[0].Player.ID)
+	if err != nil || profile == nil || profile.Club != "Rovers" {
+		t.Errorf("Expected the profile to be imported, got %+v, %v", profile, err)
+	}
+}
@@ -0,0 +1,337 @@
+// Package playerlist filters, sorts and pages the players table. The state of the table is kept in the URL query so
+// that any view of it can be bookmarked.
+package playerlist
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+// Column is a column of the players table.
+type Column string
+
+const (
+	Name     Column = "name"
+	Age      Column = "age"
+	Position Column = "position"
+	Club     Column = "club"
+	Score    Column = "score"
+	LastSeen Column = "lastSeen"
+)
+
+// Columns lists every column in display order.
+var Columns = []Column{Name, Age, Position, Club, Score, LastSeen}
+
+func (c Column) valid() bool {
+	for _, column := range Columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	DefaultPageSize = 25
+	MaxPageSize     = 200
+)
+
+// Query is the state of the players table.
+type Query struct {
+	Sort       Column
+	Descending bool
+	// Page is 1-based.
+	Page     int
+	PageSize int
+	// Columns are the columns to show. The name column is always shown.
+	Columns []Column
+	// Profile is the ID of the weight profile that scores are computed with, or empty for default scoring.
+	Profile string
+	// Search only keeps players whose name or club contains it, ignoring case.
+	Search string
+	// Position only keeps players in that position if it isn't empty.
+	Position database.PositionType
+}
+
+// DefaultQuery is the state of the players table when the URL doesn't say otherwise.
+func DefaultQuery() Query {
+	return Query{Sort: Name, Page: 1, PageSize: DefaultPageSize, Columns: Columns}
+}
+
+// defaults is DefaultQuery for a weight profile. Players are ranked best first when a profile is selected.
+func defaults(profile string) Query {
+	q := DefaultQuery()
+	q.Profile = profile
+	if profile != "" {
+		q.Sort, q.Descending = Score, true
+	}
+	return q
+}
+
+// ParseQuery reads the table state from a URL query. Invalid values are replaced by their defaults, so that stale
+// bookmarks keep working.
+func ParseQuery(v url.Values) Query {
+	q := defaults(v.Get("profile"))
+	if c := Column(v.Get("sort")); c.valid() {
+		q.Sort = c
+		q.Descending = v.Get("dir") == "desc"
+	}
+	if page, err := strconv.Atoi(v.Get("page")); err == nil && page > 0 {
+		q.Page = page
+	}
+	if size, err := strconv.Atoi(v.Get("size")); err == nil && size > 0 {
+		q.PageSize = min(size, MaxPageSize)
+	}
+	// Columns are comma separated in links, but the column chooser submits one value per checkbox.
+	if v.Has("cols") {
+		q.Columns = []Column{Name}
+		for _, c := range Columns {
+			if c != Name && containsColumn(v["cols"], c) {
+				q.Columns = append(q.Columns, c)
+			}
+		}
+	}
+	q.Search = strings.TrimSpace(v.Get("q"))
+	q.Position = database.PositionType(v.Get("position"))
+	return q
+}
+
+// Values is the inverse of ParseQuery. Values that match the defaults are left out to keep URLs short.
+func (q Query) Values() url.Values {
+	v := url.Values{}
+	d := defaults(q.Profile)
+	if q.Sort != d.Sort || q.Descending != d.Descending {
+		v.Set("sort", string(q.Sort))
+		if q.Descending {
+			v.Set("dir", "desc")
+		}
+	}
+	if q.Page > 1 {
+		v.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.PageSize != d.PageSize {
+		v.Set("size", strconv.Itoa(q.PageSize))
+	}
+	if !sameColumns(q.Columns, d.Columns) {
+		cols := make([]string, len(q.Columns))
+		for i, c := range q.Columns {
+			cols[i] = string(c)
+		}
+		v.Set("cols", strings.Join(cols, ","))
+	}
+	if q.Profile != "" {
+		v.Set("profile", q.Profile)
+	}
+	if q.Search != "" {
+		v.Set("q", q.Search)
+	}
+	if q.Position != "" {
+		v.Set("position", string(q.Position))
+	}
+	return v
+}
+
+// URL returns the address of the table in this state.
+func (q Query) URL(path string) string {
+	if encoded := q.Values().Encode(); encoded != "" {
+		return path + "?" + encoded
+	}
+	return path
+}
+
+// SortedBy returns the query sorted by a column. Sorting by the current column again reverses the direction. The
+// table goes back to the first page.
+func (q Query) SortedBy(c Column) Query {
+	if q.Sort == c {
+		q.Descending = !q.Descending
+	} else {
+		q.Sort = c
+		// Scores are most useful best first, and other columns alphabetically or youngest first.
+		q.Descending = c == Score
+	}
+	q.Page = 1
+	return q
+}
+
+// OnPage returns the query for another page.
+func (q Query) OnPage(page int) Query {
+	q.Page = page
+	return q
+}
+
+// Shows reports whether a column is visible.
+func (q Query) Shows(c Column) bool {
+	for _, column := range q.Columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+func containsColumn(values []string, c Column) bool {
+	for _, value := range values {
+		for _, column := range strings.Split(value, ",") {
+			if Column(column) == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameColumns(a, b []Column) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Row is a player listed in the table.
+type Row struct {
+	*database.PlayerSummary
+	// Age is nil if the player has no valid birthdate.
+	Age *int
+}
+
+// Position is the recorded position of the player, or empty if there is none.
+func (r *Row) Position() database.PositionType {
+	if r.Profile == nil {
+		return ""
+	}
+	return r.Profile.Position
+}
+
+// Club is the recorded club of the player, or empty if there is none.
+func (r *Row) Club() string {
+	if r.Profile == nil {
+		return ""
+	}
+	return r.Profile.Club
+}
+
+// Page is one page of the filtered and sorted table.
+type Page struct {
+	Query Query
+	Rows  []*Row
+	// Total is the number of players matching the filters, across all pages.
+	Total int
+	Pages int
+}
+
+// Filter returns the rows matching the query's filters, sorted as requested. Players must already be scored.
+func Filter(q Query, players []*database.PlayerSummary, now time.Time) []*Row {
+	search := strings.ToLower(q.Search)
+	var rows []*Row
+	for _, p := range players {
+		row := &Row{PlayerSummary: p}
+		if q.Position != "" && row.Position() != q.Position {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(p.Name), search) && !strings.Contains(strings.ToLower(row.Club()), search) {
+			continue
+		}
+		if p.Profile != nil {
+			if age, err := p.Profile.Age(now); err == nil {
+				row.Age = &age
+			}
+		}
+		rows = append(rows, row)
+	}
+	sortRows(q, rows)
+	return rows
+}
+
+// Apply filters, sorts and pages the players. Players must already be scored. Pages past the end are moved back to
+// the last page.
+func Apply(q Query, players []*database.PlayerSummary, now time.Time) Page {
+	rows := Filter(q, players, now)
+	page := Page{Query: q, Total: len(rows), Pages: max(1, (len(rows)+q.PageSize-1)/q.PageSize)}
+	page.Query.Page = min(q.Page, page.Pages)
+	start := (page.Query.Page - 1) * q.PageSize
+	page.Rows = rows[start:min(start+q.PageSize, len(rows))]
+	return page
+}
+
+// sortRows sorts by the query's column. Rows without a value for the column always go last, and ties are broken by
+// name.
+func sortRows(q Query, rows []*Row) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if c := compare(q.Sort, a, b); c != 0 {
+			if c == missing || c == -missing {
+				return c < 0
+			}
+			if q.Descending {
+				return c > 0
+			}
+			return c < 0
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+}
+
+// missing is returned by compare when exactly one of the rows has no value. It sorts rows without a value last
+// regardless of the direction.
+const missing = 2
+
+// compare returns -1, 0 or 1 comparing a and b by the column, or ±missing when one of them has no value.
+func compare(c Column, a, b *Row) int {
+	switch c {
+	case Age:
+		if a.Age == nil || b.Age == nil {
+			return compareMissing(a.Age == nil, b.Age == nil)
+		}
+		return compareOrdered(*a.Age, *b.Age)
+	case Position:
+		return compareStrings(string(a.Position()), string(b.Position()))
+	case Club:
+		return compareStrings(a.Club(), b.Club())
+	case Score:
+		if a.Score == nil || b.Score == nil {
+			return compareMissing(a.Score == nil, b.Score == nil)
+		}
+		return compareOrdered(*a.Score, *b.Score)
+	case LastSeen:
+		return compareStrings(a.LastSeen, b.LastSeen)
+	}
+	return compareStrings(a.Name, b.Name)
+}
+
+// compareStrings compares ignoring case, treating empty strings as missing.
+func compareStrings(a, b string) int {
+	if a == "" || b == "" {
+		return compareMissing(a == "", b == "")
+	}
+	return compareOrdered(strings.ToLower(a), strings.ToLower(b))
+}
+
+func compareMissing(aMissing, bMissing bool) int {
+	switch {
+	case aMissing && bMissing:
+		return 0
+	case aMissing:
+		return missing
+	}
+	return -missing
+}
+
+func compareOrdered[T int | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
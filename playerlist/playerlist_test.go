@@ -0,0 +1,181 @@
+package playerlist
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func summary(name, club string, position database.PositionType, birthdate string, score *float64, lastSeen string) *database.PlayerSummary {
+	s := &database.PlayerSummary{Player: &database.Player{Name: name, Score: score}, LastSeen: lastSeen}
+	if club != "" || position != "" || birthdate != "" {
+		s.Profile = &database.PlayerAnalysis{Club: club, Position: position, Birthdate: birthdate}
+	}
+	return s
+}
+
+func score(f float64) *float64 {
+	return &f
+}
+
+func names(rows []*Row) []string {
+	var names []string
+	for _, r := range rows {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseQueryDefaults(t *testing.T) {
+	q := ParseQuery(url.Values{"sort": {"bogus"}, "page": {"-1"}, "size": {"x"}})
+	d := DefaultQuery()
+	if q.Sort != d.Sort || q.Descending || q.Page != 1 || q.PageSize != DefaultPageSize || !sameColumns(q.Columns, Columns) {
+		t.Errorf("Expected defaults for invalid values, got %+v", q)
+	}
+	if encoded := q.Values().Encode(); encoded != "" {
+		t.Errorf("Expected defaults to be left out of the URL, got %q", encoded)
+	}
+
+	q = ParseQuery(url.Values{"profile": {"p"}})
+	if q.Sort != Score || !q.Descending {
+		t.Errorf("Expected players to be ranked by score when a profile is selected, got %+v", q)
+	}
+	if got := q.URL("/players"); got != "/players?profile=p" {
+		t.Errorf("Expected /players?profile=p, got %s", got)
+	}
+}
+
+func TestQueryRoundTrip(t *testing.T) {
+	for _, raw := range []string{
+		"sort=age&dir=desc&page=3&size=10",
+		"cols=name,club,score&position=Forward&q=united",
+		"profile=p&sort=name",
+		"profile=p&sort=score",
+	} {
+		v, _ := url.ParseQuery(raw)
+		q := ParseQuery(v)
+		if got := ParseQuery(q.Values()); got.URL("/players") != q.URL("/players") {
+			t.Errorf("%s: expected %s after a round trip, got %s", raw, q.URL("/players"), got.URL("/players"))
+		}
+		if q.Values().Encode() != v.Encode() {
+			t.Errorf("%s: expected the same values back, got %s", raw, q.Values().Encode())
+		}
+	}
+}
+
+func TestParseQueryColumns(t *testing.T) {
+	// The column chooser submits one value per checkbox, plus the name column.
+	q := ParseQuery(url.Values{"cols": {"name", "score", "age", "bogus"}})
+	want := []Column{Name, Age, Score}
+	if !sameColumns(q.Columns, want) {
+		t.Errorf("Expected %v, got %v", want, q.Columns)
+	}
+	if q.Shows(Club) || !q.Shows(Score) {
+		t.Errorf("Unexpected visible columns %v", q.Columns)
+	}
+	if got := q.Values().Get("cols"); got != "name,age,score" {
+		t.Errorf("Expected cols=name,age,score, got %s", got)
+	}
+}
+
+func TestSortedBy(t *testing.T) {
+	q := DefaultQuery().OnPage(4)
+	q = q.SortedBy(Age)
+	if q.Sort != Age || q.Descending || q.Page != 1 {
+		t.Errorf("Expected ascending age on the first page, got %+v", q)
+	}
+	if q = q.SortedBy(Age); !q.Descending {
+		t.Errorf("Expected sorting by the same column to reverse the direction")
+	}
+	if q = q.SortedBy(Score); q.Sort != Score || !q.Descending {
+		t.Errorf("Expected scores to be sorted best first, got %+v", q)
+	}
+}
+
+func TestApplySorting(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	players := []*database.PlayerSummary{
+		summary("Cole", "Rovers", database.Forward, "01/02/2000", score(3), "2024-05-01 15:00"),
+		summary("abbott", "United", database.Defender, "01/02/2004", score(4), "2024-01-01 15:00"),
+		summary("Baker", "", "", "", nil, ""),
+	}
+	for _, test := range []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"abbott", "Baker", "Cole"}},
+		{"sort=name&dir=desc", []string{"Cole", "Baker", "abbott"}},
+		{"sort=age", []string{"abbott", "Cole", "Baker"}},
+		{"sort=age&dir=desc", []string{"Cole", "abbott", "Baker"}},
+		{"sort=club", []string{"Cole", "abbott", "Baker"}},
+		{"sort=position", []string{"abbott", "Cole", "Baker"}},
+		{"sort=score&dir=desc", []string{"abbott", "Cole", "Baker"}},
+		{"sort=score", []string{"Cole", "abbott", "Baker"}},
+		{"sort=lastSeen&dir=desc", []string{"Cole", "abbott", "Baker"}},
+	} {
+		v, _ := url.ParseQuery(test.query)
+		page := Apply(ParseQuery(v), players, now)
+		if got := names(page.Rows); !equal(got, test.want) {
+			t.Errorf("%q: expected %v, got %v", test.query, test.want, got)
+		}
+	}
+}
+
+func TestApplyFilters(t *testing.T) {
+	now := time.Now()
+	players := []*database.PlayerSummary{
+		summary("Cole", "Rovers", database.Forward, "", nil, ""),
+		summary("Abbott", "United", database.Defender, "", nil, ""),
+		summary("Rover", "", database.Forward, "", nil, ""),
+	}
+	page := Apply(ParseQuery(url.Values{"q": {"rover"}}), players, now)
+	if got, want := names(page.Rows), []string{"Cole", "Rover"}; !equal(got, want) {
+		t.Errorf("Expected search to match names and clubs %v, got %v", want, got)
+	}
+	page = Apply(ParseQuery(url.Values{"position": {"Defender"}}), players, now)
+	if got, want := names(page.Rows), []string{"Abbott"}; !equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestApplyPagination(t *testing.T) {
+	var players []*database.PlayerSummary
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		players = append(players, summary(name, "", "", "", nil, ""))
+	}
+	q := ParseQuery(url.Values{"size": {"2"}, "page": {"2"}})
+	page := Apply(q, players, time.Now())
+	if page.Total != 5 || page.Pages != 3 {
+		t.Errorf("Expected 5 players on 3 pages, got %d on %d", page.Total, page.Pages)
+	}
+	if got, want := names(page.Rows), []string{"C", "D"}; !equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	page = Apply(q.OnPage(9), players, time.Now())
+	if page.Query.Page != 3 {
+		t.Errorf("Expected a page past the end to show the last page, got page %d", page.Query.Page)
+	}
+	if got, want := names(page.Rows), []string{"E"}; !equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	page = Apply(q, nil, time.Now())
+	if page.Pages != 1 || len(page.Rows) != 0 {
+		t.Errorf("Expected a single empty page, got %+v", page)
+	}
+}
@@ -0,0 +1,188 @@
+// Offline drafting of analyses. Drafts are kept in localStorage until /api/drafts confirms they were saved, so
+// nothing is lost when there is no signal at the ground. Every page loads this script to register the service worker
+// and send any queued drafts once the connection returns.
+(function () {
+  "use strict";
+
+  var storageKey = "scouting.drafts";
+  // batchSize matches maxDraftsPerSync on the server.
+  var batchSize = 100;
+
+  function loadQueue() {
+    try {
+      return JSON.parse(localStorage.getItem(storageKey)) || [];
+    } catch (e) {
+      return [];
+    }
+  }
+
+  function saveQueue(queue) {
+    localStorage.setItem(storageKey, JSON.stringify(queue));
+    render();
+  }
+
+  function csrfToken(doc) {
+    var meta = doc.querySelector('meta[name="csrf-token"]');
+    return meta ? meta.content : "";
+  }
+
+  // freshToken fetches a new CSRF token in case the page was served from the cache with an outdated one.
+  function freshToken() {
+    return fetch("/drafts", { cache: "no-store", credentials: "same-origin" })
+      .then(function (response) { return response.text(); })
+      .then(function (html) { return csrfToken(new DOMParser().parseFromString(html, "text/html")); });
+  }
+
+  function post(drafts, token) {
+    return fetch("/api/drafts", {
+      method: "POST",
+      credentials: "same-origin",
+      headers: { "Content-Type": "application/json", "X-CSRF-Token": token },
+      body: JSON.stringify({ drafts: drafts }),
+    });
+  }
+
+  var syncing = false;
+
+  function sync() {
+    var queue = loadQueue().filter(function (d) { return !d.error; });
+    if (syncing || queue.length === 0 || !navigator.onLine) {
+      return;
+    }
+    syncing = true;
+    var drafts = queue.slice(0, batchSize).map(function (d) { return d.draft; });
+    var more = false;
+    post(drafts, csrfToken(document))
+      .then(function (response) {
+        if (response.status === 403) {
+          return freshToken().then(function (token) { return post(drafts, token); });
+        }
+        return response;
+      })
+      .then(function (response) {
+        if (!response.ok) {
+          throw new Error("sync failed with status " + response.status);
+        }
+        return response.json();
+      })
+      .then(function (body) {
+        var results = {};
+        body.results.forEach(function (r) { results[r.clientId] = r; });
+        // Saved and duplicate drafts are done with. Rejected drafts stay queued, with the reason, until removed.
+        saveQueue(loadQueue().filter(function (d) {
+          var r = results[d.draft.clientId];
+          if (r && r.status === "invalid") {
+            d.error = r.error;
+          }
+          return !r || r.status === "invalid";
+        }));
+        more = queue.length > batchSize;
+      })
+      .catch(function () {
+        // Still offline, or the server is unavailable. The drafts stay queued for the next attempt.
+      })
+      .then(function () {
+        syncing = false;
+        render();
+        if (more) {
+          sync();
+        }
+      });
+  }
+
+  function newID() {
+    if (window.crypto && crypto.randomUUID) {
+      return crypto.randomUUID();
+    }
+    var bytes = crypto.getRandomValues(new Uint8Array(16));
+    bytes[6] = (bytes[6] & 0x0f) | 0x40;
+    bytes[8] = (bytes[8] & 0x3f) | 0x80;
+    var hex = Array.prototype.map.call(bytes, function (b) { return (b + 0x100).toString(16).slice(1); }).join("");
+    return [hex.slice(0, 8), hex.slice(8, 12), hex.slice(12, 16), hex.slice(16, 20), hex.slice(20)].join("-");
+  }
+
+  function draftFromForm(form) {
+    var draft = {
+      clientId: newID(),
+      playerId: form.elements.playerId.value,
+      category: form.elements.category.value,
+      // datetime-local inputs use "yyyy-mm-ddThh:mm", while analyses are stored as "yyyy-mm-dd hh:mm".
+      date: form.elements.date.value.replace("T", " "),
+      playTimeMinutes: form.elements.playTimeMinutes.value === "" ? null : Number(form.elements.playTimeMinutes.value),
+      venue: form.elements.venue.value,
+      weatherCondition: form.elements.weatherCondition.value,
+      notes: form.elements.notes.value,
+      ratings: {},
+    };
+    form.querySelectorAll("input[data-rating]").forEach(function (input) {
+      if (input.value !== "") {
+        draft.ratings[input.name] = Number(input.value);
+      }
+    });
+    var player = form.elements.playerId.selectedOptions[0];
+    return { draft: draft, playerName: player ? player.textContent : "" };
+  }
+
+  function render() {
+    var list = document.querySelector("[data-drafts-queue]");
+    var status = document.querySelector("[data-drafts-status]");
+    if (!list || !status) {
+      return;
+    }
+    var queue = loadQueue();
+    list.replaceChildren();
+    queue.forEach(function (d) {
+      var item = document.createElement("li");
+      item.textContent = d.playerName + ", " + d.draft.date + (d.error ? " (" + status.dataset.rejected + " " + d.error + ")" : "");
+      if (d.error) {
+        var remove = document.createElement("button");
+        remove.type = "button";
+        remove.textContent = status.dataset.discard;
+        remove.addEventListener("click", function () {
+          saveQueue(loadQueue().filter(function (other) { return other.draft.clientId !== d.draft.clientId; }));
+        });
+        item.append(" ", remove);
+      }
+      list.append(item);
+    });
+    // Messages are translated by the server, see DraftAnalysis.
+    if (queue.length === 0) {
+      status.textContent = status.dataset.empty;
+    } else if (!navigator.onLine) {
+      status.textContent = status.dataset.offline;
+    } else {
+      status.textContent = syncing ? status.dataset.sending : status.dataset.waiting.replace("{count}", queue.length);
+    }
+  }
+
+  function init() {
+    var form = document.querySelector("[data-drafts-form]");
+    if (form) {
+      form.addEventListener("submit", function (event) {
+        event.preventDefault();
+        var queue = loadQueue();
+        queue.push(draftFromForm(form));
+        saveQueue(queue);
+        form.reset();
+        sync();
+      });
+    }
+    var button = document.querySelector("[data-drafts-sync]");
+    if (button) {
+      button.addEventListener("click", sync);
+    }
+    render();
+    sync();
+  }
+
+  if ("serviceWorker" in navigator) {
+    navigator.serviceWorker.register("/sw.js");
+  }
+  window.addEventListener("online", sync);
+  window.addEventListener("offline", render);
+  if (document.readyState === "loading") {
+    document.addEventListener("DOMContentLoaded", init);
+  } else {
+    init();
+  }
+})();
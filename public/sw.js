@@ -0,0 +1,80 @@
+// Service worker for pitch-side use without a connection. It is served from /sw.js so that it controls every page.
+//
+// Pages are fetched from the network when possible and from the cache otherwise, so scouts see the latest data when
+// online and the last version they visited when not. Static assets rarely change and are served from the cache first.
+"use strict";
+
+var cacheName = "scouting-v3";
+
+// precached are the pages and assets needed to draft analyses offline, even if they were never visited.
+var precached = [
+  "/drafts",
+  "/manifest.webmanifest",
+  "/public/styles.css",
+  "/public/js/htmx.min.js",
+  "/public/js/drafts.js",
+  "/public/icons/soccer-football-svgrepo-com.svg",
+];
+
+self.addEventListener("install", function (event) {
+  event.waitUntil(
+    caches.open(cacheName).then(function (cache) {
+      // Assets are added one by one so that a missing one, e.g. before `make assets` was run, doesn't stop the rest.
+      return Promise.all(precached.map(function (url) {
+        return cache.add(url).catch(function () {});
+      }));
+    }).then(function () { return self.skipWaiting(); })
+  );
+});
+
+self.addEventListener("activate", function (event) {
+  event.waitUntil(
+    caches.keys().then(function (names) {
+      return Promise.all(names.filter(function (name) { return name !== cacheName; }).map(function (name) {
+        return caches.delete(name);
+      }));
+    }).then(function () { return self.clients.claim(); })
+  );
+});
+
+function networkFirst(request) {
+  return fetch(request).then(function (response) {
+    if (response.ok) {
+      var copy = response.clone();
+      caches.open(cacheName).then(function (cache) { cache.put(request, copy); });
+    }
+    return response;
+  }).catch(function () {
+    return caches.match(request).then(function (cached) {
+      if (cached) {
+        return cached;
+      }
+      // Unvisited pages fall back to the drafting form, which is always available.
+      if (request.mode === "navigate") {
+        return caches.match("/drafts");
+      }
+      return Response.error();
+    });
+  });
+}
+
+function cacheFirst(request) {
+  return caches.match(request).then(function (cached) {
+    return cached || fetch(request).then(function (response) {
+      if (response.ok) {
+        var copy = response.clone();
+        caches.open(cacheName).then(function (cache) { cache.put(request, copy); });
+      }
+      return response;
+    });
+  });
+}
+
+self.addEventListener("fetch", function (event) {
+  var url = new URL(event.request.url);
+  // Only reads from this server are cached. Drafts are queued by drafts.js, not here.
+  if (event.request.method !== "GET" || url.origin !== self.location.origin || url.pathname.startsWith("/api/")) {
+    return;
+  }
+  event.respondWith(url.pathname.startsWith("/public/") ? cacheFirst(event.request) : networkFirst(event.request));
+});
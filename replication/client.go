@@ -0,0 +1,242 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+// Remote is the server a replica syncs with.
+type Remote interface {
+	// Pull returns the server's changes after since, except those made by replica.
+	Pull(ctx context.Context, replica string, since int64) (*Batch, error)
+	// Push sends a replica's own changes to the server.
+	Push(ctx context.Context, push *Push) (*PushResult, error)
+}
+
+// Result summarises a sync.
+type Result struct {
+	Pushed int
+	Pulled int
+	// Conflicts is how many rows had been changed on both sides, on either.
+	Conflicts int
+}
+
+// Sync pushes the changes made in db since it last synced with remote, then pulls those made on the server.
+func Sync(ctx context.Context, db *gorm.DB, remote Remote, now time.Time) (*Result, error) {
+	state, err := database.LocalSyncState(db)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	for {
+		batch, err := changes(db.Where("replica = ?", state.Replica), state.Pushed, BatchSize)
+		if err != nil {
+			return nil, err
+		}
+		pushed := &PushResult{}
+		if len(batch.Records) > 0 {
+			pushed, err = remote.Push(ctx, &Push{Replica: state.Replica, Base: state.Pulled, Records: batch.Records})
+			if err != nil {
+				return nil, fmt.Errorf("pushing changes after %d failed: %w", state.Pushed, err)
+			}
+			result.Pushed += len(batch.Records)
+			result.Conflicts += len(pushed.Conflicts)
+		}
+		// The server found the conflicts, but the Scout may decide on them here too.
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, conflict := range pushed.Conflicts {
+				if err := conflict.record(tx); err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(state).Update("pushed", batch.Cursor).Error; err != nil {
+				return fmt.Errorf("saving the sync state failed: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		state.Pushed = batch.Cursor
+		if !batch.More {
+			break
+		}
+	}
+	for {
+		batch, err := remote.Pull(ctx, state.Replica, state.Pulled)
+		if err != nil {
+			return nil, fmt.Errorf("pulling changes after %d failed: %w", state.Pulled, err)
+		}
+		applied, err := apply(db, batch.Records, state.Pushed, state.Replica)
+		if err != nil {
+			return nil, err
+		}
+		result.Pulled += applied.Applied
+		result.Conflicts += len(applied.Conflicts)
+		state.Pulled = batch.Cursor
+		if err := db.Model(state).Update("pulled", state.Pulled).Error; err != nil {
+			return nil, fmt.Errorf("saving the sync state failed: %w", err)
+		}
+		if !batch.More {
+			break
+		}
+	}
+	if err := db.Model(state).Update("synced_at", now).Error; err != nil {
+		return nil, fmt.Errorf("saving the sync state failed: %w", err)
+	}
+	return result, nil
+}
+
+// ErrForbiddenServer is wrapped by errors about servers on loopback or private addresses, which clients only connect
+// to if they are allowed to.
+var ErrForbiddenServer = errors.New("forbidden server")
+
+// Client is a Remote served over HTTP by the server package, under /api/sync.
+type Client struct {
+	base *url.URL
+	http *http.Client
+}
+
+// NewClient returns a client of the server at the given URL, e.g. "https://scouting.example.com". Unless private is
+// set, the client refuses to connect to loopback, private and link-local addresses, including those a host name
+// resolves to, so that the URL can't be used to reach services on the server's own network.
+func NewClient(server string, private bool) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(server, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Hostname() == "" {
+		return nil, fmt.Errorf("invalid server URL %q", server)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !private {
+		if addr, err := netip.ParseAddr(base.Hostname()); (err == nil && !public(addr)) || base.Hostname() == "localhost" {
+			return nil, fmt.Errorf("%w: %s", ErrForbiddenServer, base.Hostname())
+		}
+		// Addresses are checked as they are dialled, which covers host names and redirects. A proxy would be dialled
+		// instead of the server, so none is used.
+		dialer := &net.Dialer{Timeout: 30 * time.Second, Control: dialPublic}
+		transport.DialContext = dialer.DialContext
+		transport.Proxy = nil
+	}
+	// The jar keeps the CSRF cookie that pushes must echo back.
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{base: base, http: &http.Client{Jar: jar, Timeout: time.Minute, Transport: transport}}, nil
+}
+
+// dialPublic refuses connections to addresses that aren't public.
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenServer, address)
+	}
+	if !public(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenServer, addrPort.Addr())
+	}
+	return nil
+}
+
+// public reports whether an address is on the internet rather than the host or its local network.
+func public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddresses.Contains(addr)
+}
+
+// sharedAddresses are used by carrier-grade NAT, behind which they are as local as private addresses.
+var sharedAddresses = netip.MustParsePrefix("100.64.0.0/10")
+
+// Pull implements Remote.
+func (c *Client) Pull(ctx context.Context, replica string, since int64) (*Batch, error) {
+	query := url.Values{"replica": {replica}, "since": {strconv.FormatInt(since, 10)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url("/api/sync/changes?"+query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	var batch Batch
+	return &batch, c.do(req, &batch)
+}
+
+// Push implements Remote.
+func (c *Client) Push(ctx context.Context, push *Push) (*PushResult, error) {
+	token, err := c.csrfToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(push)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url("/api/sync/changes"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-Token", token)
+	var result PushResult
+	return &result, c.do(req, &result)
+}
+
+// csrfToken returns the token the server expects with pushes, asking the server for one if the client has none.
+func (c *Client) csrfToken(ctx context.Context) (string, error) {
+	if token := c.cookie("_csrf"); token != "" {
+		return token, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url("/api/sync"), nil)
+	if err != nil {
+		return "", err
+	}
+	if err := c.do(req, nil); err != nil {
+		return "", err
+	}
+	if token := c.cookie("_csrf"); token != "" {
+		return token, nil
+	}
+	return "", fmt.Errorf("%s sent no CSRF token", c.base)
+}
+
+func (c *Client) cookie(name string) string {
+	for _, cookie := range c.http.Jar.Cookies(c.base) {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func (c *Client) url(path string) string {
+	return c.base.String() + path
+}
+
+// do sends a request and decodes its JSON response into v, unless v is nil.
+func (c *Client) do(req *http.Request, v any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s returned %s", req.Method, req.URL.Path, resp.Status)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("reading the response to %s %s failed: %w", req.Method, req.URL.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package replication
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// originKey is the gorm setting that credits changes to the replica they were pulled or pushed from, rather than
+	// the local one.
+	originKey = "replication:origin"
+	// rowsKey holds the IDs of the rows an update or delete is about to change.
+	rowsKey = "replication:rows"
+	// logBatchSize is how many rows are looked up or logged at a time.
+	logBatchSize = 500
+)
+
+// origin is the replica a change was first made in, and when.
+type origin struct {
+	replica   string
+	changedAt time.Time
+}
+
+// Plugin returns a gorm plugin that logs a database.Change for every row of a replicated table that is created,
+// updated or deleted. Changes made with raw SQL aren't logged.
+func Plugin() gorm.Plugin {
+	return plugin{}
+}
+
+type plugin struct{}
+
+func (plugin) Name() string {
+	return "replication"
+}
+
+// Initialize registers callbacks that log the rows changed by every create, update and delete.
+func (plugin) Initialize(db *gorm.DB) error {
+	tables, err := replicatedTables(db)
+	if err != nil {
+		return err
+	}
+	// The rows an update or delete changes are collected beforehand, since deleted rows can't be found afterwards.
+	collect := func(db *gorm.DB) {
+		if _, ok := tables[db.Statement.Table]; !ok || db.Error != nil {
+			return
+		}
+		ids := rowIDs(db)
+		if len(ids) == 0 {
+			query := db.Session(&gorm.Session{NewDB: true}).Unscoped().Table(db.Statement.Table)
+			if where, ok := db.Statement.Clauses["WHERE"]; ok {
+				query = query.Clauses(where.Expression)
+			}
+			if err := query.Pluck("id", &ids).Error; err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+		db.InstanceSet(rowsKey, ids)
+	}
+	log := func(db *gorm.DB) {
+		// Statements that changed nothing, e.g. creates that did nothing on conflict, aren't logged.
+		if _, ok := tables[db.Statement.Table]; !ok || db.Error != nil || db.Statement.RowsAffected == 0 {
+			return
+		}
+		ids := rowIDs(db)
+		if collected, ok := db.InstanceGet(rowsKey); ok {
+			ids = collected.([]uuid.UUID)
+		}
+		if err := logChanges(db, ids); err != nil {
+			db.AddError(err)
+		}
+	}
+	c := db.Callback()
+	for _, err := range []error{
+		c.Create().After("gorm:create").Register("replication:after_create", log),
+		c.Update().Before("gorm:update").Register("replication:before_update", collect),
+		c.Update().After("gorm:update").Register("replication:after_update", log),
+		c.Delete().Before("gorm:delete").Register("replication:before_delete", collect),
+		c.Delete().After("gorm:delete").Register("replication:after_delete", log),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowIDs returns the IDs of the models a statement was given, e.g. the players passed to Create.
+func rowIDs(db *gorm.DB) []uuid.UUID {
+	if db.Statement.Schema == nil || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return nil
+	}
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	var ids []uuid.UUID
+	add := func(v reflect.Value) {
+		if value, zero := field.ValueOf(db.Statement.Context, v); !zero {
+			if id, ok := value.(uuid.UUID); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	switch v := reflect.Indirect(db.Statement.ReflectValue); v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			add(reflect.Indirect(v.Index(i)))
+		}
+	case reflect.Struct:
+		add(v)
+	}
+	return ids
+}
+
+// logChanges logs a change for each of the rows of the statement's table, in the same transaction. Rows that no longer
+// exist are logged as deleted.
+func logChanges(db *gorm.DB, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tx := db.Session(&gorm.Session{NewDB: true})
+	exists := map[uuid.UUID]bool{}
+	// SQLite limits the number of parameters of a query, so rows are looked up in batches.
+	for start := 0; start < len(ids); start += logBatchSize {
+		var existing []uuid.UUID
+		batch := ids[start:min(start+logBatchSize, len(ids))]
+		if err := tx.Unscoped().Table(db.Statement.Table).Where("id IN ?", batch).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		for _, id := range existing {
+			exists[id] = true
+		}
+	}
+
+	from := origin{changedAt: time.Now().UTC()}
+	if o, ok := db.Get(originKey); ok {
+		from = o.(origin)
+	} else {
+		state, err := database.LocalSyncState(tx)
+		if err != nil {
+			return err
+		}
+		from.replica = state.Replica
+	}
+	changes := make([]*database.Change, 0, len(ids))
+	logged := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if logged[id] {
+			continue
+		}
+		logged[id] = true
+		changes = append(changes, &database.Change{
+			Table:     db.Statement.Table,
+			RowID:     id,
+			Deleted:   !exists[id],
+			Replica:   from.replica,
+			ChangedAt: from.changedAt,
+		})
+	}
+	return tx.Omit(clause.Associations).CreateInBatches(&changes, logBatchSize).Error
+}
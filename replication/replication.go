@@ -0,0 +1,325 @@
+// Package replication syncs copies, or replicas, of a Scout's database, such as one on a laptop used offline and the
+// one on the central server.
+//
+// Plugin logs every row of the tables in database.ReplicatedModels that is created, updated or deleted as a database.Change,
+// numbered by its Seq. A replica syncs by pushing its own changes since the last one the server received, then
+// pulling the server's changes since the last one it pulled; both cursors are kept in its database.SyncState. Each
+// change is sent with the row as it is now, so replicas only ever exchange whole rows.
+//
+// A row changed by both sides since they last synced is a conflict. The change made last wins, or that of the
+// replica with the greater ID if both were made at once, so every replica keeps the same version whichever detects the
+// conflict. The other version is kept as a database.SyncConflict in the conflict inbox of the replica that detected
+// it, and of the replica that pushed the change if that was the server, where the Scout can restore it with Resolve.
+package replication
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+// BatchSize is how many changes are pulled or pushed at a time.
+const BatchSize = 200
+
+// ErrUnknownTable is wrapped by errors about changes to tables that aren't replicated.
+var ErrUnknownTable = errors.New("unknown table")
+
+// Record is a change along with the row it left, as exchanged between replicas.
+type Record struct {
+	Seq       int64     `json:"seq"`
+	Table     string    `json:"table"`
+	RowID     uuid.UUID `json:"rowId"`
+	Deleted   bool      `json:"deleted,omitempty"`
+	Replica   string    `json:"replica"`
+	ChangedAt time.Time `json:"changedAt"`
+	// Row is the JSON of the row, or empty if it was deleted.
+	Row json.RawMessage `json:"row,omitempty"`
+}
+
+// Batch is a page of changes, holding only the last change of each row.
+type Batch struct {
+	Records []Record `json:"records"`
+	// Cursor is the Seq of the last change in the batch, or the cursor the batch was asked for if there are none.
+	Cursor int64 `json:"cursor"`
+	// More is set if there are changes after Cursor.
+	More bool `json:"more"`
+}
+
+// Push is a replica's own changes, sent to the server.
+type Push struct {
+	Replica string `json:"replica"`
+	// Base is the Seq, on the server, of the last change the replica pulled. Changes on the server after it are
+	// concurrent with the pushed ones.
+	Base    int64    `json:"base"`
+	Records []Record `json:"records"`
+}
+
+// PushResult is what the server did with a Push.
+type PushResult struct {
+	Applied int `json:"applied"`
+	// Conflicts are the pushed rows that had also been changed on the server. They are in the inbox of both sides.
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// Conflict is a row changed by two replicas, as recorded in a database.SyncConflict.
+type Conflict struct {
+	Table string    `json:"table"`
+	RowID uuid.UUID `json:"rowId"`
+	// Kept and Discarded are the JSON of each version of the row, or empty if that version deleted it.
+	Kept        string `json:"kept"`
+	Discarded   string `json:"discarded"`
+	DiscardedBy string `json:"discardedBy"`
+}
+
+// record adds the conflict to the inbox of db.
+func (c Conflict) record(db *gorm.DB) error {
+	conflict := &database.SyncConflict{Table: c.Table, RowID: c.RowID, Kept: c.Kept, Discarded: c.Discarded, DiscardedBy: c.DiscardedBy}
+	if err := db.Create(conflict).Error; err != nil {
+		return fmt.Errorf("recording conflict on row %v of table %s failed: %w", c.RowID, c.Table, err)
+	}
+	return nil
+}
+
+// replicatedTables maps the name of every table in database.ReplicatedModels to its model.
+func replicatedTables(db *gorm.DB) (map[string]any, error) {
+	tables := map[string]any{}
+	for _, model := range database.ReplicatedModels() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("reading the table of %T failed: %w", model, err)
+		}
+		tables[stmt.Schema.Table] = model
+	}
+	return tables, nil
+}
+
+// newRow returns a pointer to a new, empty row of a table.
+func newRow(tables map[string]any, table string) (any, error) {
+	model, ok := tables[table]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTable, table)
+	}
+	return reflect.New(reflect.TypeOf(model).Elem()).Interface(), nil
+}
+
+// rowJSON returns the JSON of a row, including soft-deleted rows, or nil if it doesn't exist.
+func rowJSON(db *gorm.DB, tables map[string]any, table string, id uuid.UUID) (json.RawMessage, error) {
+	row, err := newRow(tables, table)
+	if err != nil {
+		return nil, err
+	}
+	result := db.Unscoped().Limit(1).Find(row, "id = ?", id)
+	if result.Error != nil {
+		return nil, fmt.Errorf("retrieving row %v of table %s failed: %w", id, table, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return json.Marshal(row)
+}
+
+// Pull returns the changes made after since, except those made by replica, which already has them.
+func Pull(db *gorm.DB, replica string, since int64, limit int) (*Batch, error) {
+	return changes(db.Where("replica <> ?", replica), since, limit)
+}
+
+// changes returns a batch of the changes after since, each with the row as it is now.
+func changes(db *gorm.DB, since int64, limit int) (*Batch, error) {
+	tables, err := replicatedTables(db)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	// Changes logged for tables that are no longer replicated are skipped.
+	var log []*database.Change
+	if err := db.Where("seq > ? AND table_name IN ?", since, names).Order("seq").Limit(limit + 1).Find(&log).Error; err != nil {
+		return nil, fmt.Errorf("retrieving changes after %d failed: %w", since, err)
+	}
+	batch := &Batch{Cursor: since}
+	if len(log) > limit {
+		log, batch.More = log[:limit], true
+	}
+	// Only the last change of each row is sent, since every change is sent with the row as it is now.
+	last := map[uuid.UUID]int{}
+	for i, change := range log {
+		last[change.RowID] = i
+	}
+	for i, change := range log {
+		batch.Cursor = change.Seq
+		if last[change.RowID] != i {
+			continue
+		}
+		row, err := rowJSON(db.Session(&gorm.Session{NewDB: true}), tables, change.Table, change.RowID)
+		if err != nil {
+			return nil, err
+		}
+		batch.Records = append(batch.Records, Record{
+			Seq:       change.Seq,
+			Table:     change.Table,
+			RowID:     change.RowID,
+			Deleted:   row == nil,
+			Replica:   change.Replica,
+			ChangedAt: change.ChangedAt,
+			Row:       row,
+		})
+	}
+	return batch, nil
+}
+
+// Receive applies changes pushed by a replica, as the server. Changes claiming to be made after now are taken to be
+// made now, so that a replica can't win every future conflict by pushing changes from the future.
+func Receive(db *gorm.DB, push *Push, now time.Time) (*PushResult, error) {
+	records := make([]Record, len(push.Records))
+	for i, record := range push.Records {
+		if record.ChangedAt.After(now) {
+			record.ChangedAt = now.UTC()
+		}
+		records[i] = record
+	}
+	return apply(db, records, push.Base, "")
+}
+
+// apply writes records to db in a single transaction. A record conflicts with the last change to its row after base
+// by another replica; local limits these to changes made by the given replica, i.e. those not pushed yet.
+func apply(db *gorm.DB, records []Record, base int64, local string) (*PushResult, error) {
+	tables, err := replicatedTables(db)
+	if err != nil {
+		return nil, err
+	}
+	result := &PushResult{}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, record := range records {
+			concurrent := tx.Where("table_name = ? AND row_id = ? AND seq > ? AND replica <> ?", record.Table, record.RowID, base, record.Replica)
+			if local != "" {
+				concurrent = concurrent.Where("replica = ?", local)
+			}
+			var latest []*database.Change
+			if err := concurrent.Order("seq DESC").Limit(1).Find(&latest).Error; err != nil {
+				return fmt.Errorf("retrieving changes to row %v of table %s failed: %w", record.RowID, record.Table, err)
+			}
+			if len(latest) == 0 {
+				if err := write(tx, tables, record); err != nil {
+					return err
+				}
+				result.Applied++
+				continue
+			}
+
+			current, err := rowJSON(tx, tables, record.Table, record.RowID)
+			if err != nil {
+				return err
+			}
+			conflict := Conflict{Table: record.Table, RowID: record.RowID}
+			if !wins(record, latest[0]) {
+				conflict.Kept, conflict.Discarded, conflict.DiscardedBy = string(current), string(record.Row), record.Replica
+				if err := conflict.record(tx); err != nil {
+					return err
+				}
+				result.Conflicts = append(result.Conflicts, conflict)
+				continue
+			}
+			conflict.Kept, conflict.Discarded, conflict.DiscardedBy = string(record.Row), string(current), latest[0].Replica
+			if err := conflict.record(tx); err != nil {
+				return err
+			}
+			result.Conflicts = append(result.Conflicts, conflict)
+			if local != "" {
+				// The local changes lost, so they mustn't be pushed.
+				superseded := tx.Where("table_name = ? AND row_id = ? AND seq > ? AND replica = ?", record.Table, record.RowID, base, local)
+				if err := superseded.Delete(&database.Change{}).Error; err != nil {
+					return fmt.Errorf("discarding changes to row %v of table %s failed: %w", record.RowID, record.Table, err)
+				}
+			}
+			if err := write(tx, tables, record); err != nil {
+				return err
+			}
+			result.Applied++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// wins reports whether a record wins a conflict with a change to the same row: the change made last wins, and the
+// change of the replica with the greater ID if both were made at the same time.
+func wins(record Record, change *database.Change) bool {
+	if !record.ChangedAt.Equal(change.ChangedAt) {
+		return record.ChangedAt.After(change.ChangedAt)
+	}
+	return record.Replica > change.Replica
+}
+
+// write saves the row of a record, or deletes it, crediting the change to the replica that made it.
+func write(tx *gorm.DB, tables map[string]any, record Record) error {
+	row, err := newRow(tables, record.Table)
+	if err != nil {
+		return err
+	}
+	// IDs are kept, rather than generated by BaseModel.BeforeCreate.
+	tx = tx.Set(originKey, origin{replica: record.Replica, changedAt: record.ChangedAt}).
+		Session(&gorm.Session{SkipHooks: true}).Unscoped()
+	if record.Deleted || len(record.Row) == 0 {
+		if err := tx.Delete(row, "id = ?", record.RowID).Error; err != nil {
+			return fmt.Errorf("deleting row %v of table %s failed: %w", record.RowID, record.Table, err)
+		}
+		return nil
+	}
+	if err := json.Unmarshal(record.Row, row); err != nil {
+		return fmt.Errorf("reading row %v of table %s failed: %w", record.RowID, record.Table, err)
+	}
+	if id, _ := reflect.ValueOf(row).Elem().FieldByName("ID").Interface().(uuid.UUID); id != record.RowID {
+		return fmt.Errorf("row %v of table %s has the ID %v", record.RowID, record.Table, id)
+	}
+	if err := tx.Save(row).Error; err != nil {
+		return fmt.Errorf("saving row %v of table %s failed: %w", record.RowID, record.Table, err)
+	}
+	return nil
+}
+
+// Resolve takes the decision on a conflict. Unless restore is set, the kept version stays. Otherwise the discarded
+// version is restored as a new change, which is synced to other replicas like any other.
+func Resolve(db *gorm.DB, conflict *database.SyncConflict, restore bool, now time.Time) error {
+	tables, err := replicatedTables(db)
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if restore {
+			state, err := database.LocalSyncState(tx)
+			if err != nil {
+				return err
+			}
+			row, err := newRow(tables, conflict.Table)
+			if err != nil {
+				return err
+			}
+			// Restoring a deletion deletes the row, and restoring a row saves it with its ID.
+			local := tx.Set(originKey, origin{replica: state.Replica, changedAt: now.UTC()}).
+				Session(&gorm.Session{SkipHooks: true}).Unscoped()
+			if conflict.Discarded == "" {
+				err = local.Delete(row, "id = ?", conflict.RowID).Error
+			} else if err = json.Unmarshal([]byte(conflict.Discarded), row); err == nil {
+				err = local.Save(row).Error
+			}
+			if err != nil {
+				return fmt.Errorf("restoring row %v of table %s failed: %w", conflict.RowID, conflict.Table, err)
+			}
+		}
+		if err := tx.Model(conflict).Update("resolved_at", now).Error; err != nil {
+			return fmt.Errorf("resolving sync conflict %v failed: %w", conflict.ID, err)
+		}
+		return nil
+	})
+}
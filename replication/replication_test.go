@@ -0,0 +1,271 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+// createTestDB returns a database of its own, which logs its changes.
+func createTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := database.CreateTestDB(t)
+	if err := db.Use(Plugin()); err != nil {
+		t.Fatalf("Failed to use plugin: %v", err)
+	}
+	return db
+}
+
+// direct is a Remote in the same process.
+type direct struct {
+	db *gorm.DB
+}
+
+func (d direct) Pull(ctx context.Context, replica string, since int64) (*Batch, error) {
+	return Pull(d.db, replica, since, BatchSize)
+}
+
+func (d direct) Push(ctx context.Context, push *Push) (*PushResult, error) {
+	return Receive(d.db, push, time.Now())
+}
+
+func sync(t *testing.T, db, server *gorm.DB) *Result {
+	t.Helper()
+	result, err := Sync(context.Background(), db, direct{server}, time.Now())
+	if err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+	return result
+}
+
+func playerName(t *testing.T, db *gorm.DB, player *database.Player) string {
+	t.Helper()
+	var players []*database.Player
+	if err := db.Find(&players, "id = ?", player.ID).Error; err != nil {
+		t.Fatalf("Failed to retrieve Player: %v", err)
+	}
+	if len(players) == 0 {
+		return ""
+	}
+	return players[0].Name
+}
+
+func TestSync(t *testing.T) {
+	device, server := createTestDB(t), createTestDB(t)
+	offline := &database.Player{Name: "Offline"}
+	if err := device.Create(offline).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	if err := device.Create(&database.Analysis{PlayerID: offline.ID, Date: "2024-01-01 15:00"}).Error; err != nil {
+		t.Fatalf("Failed to create Analysis: %v", err)
+	}
+	online := &database.Player{Name: "Online"}
+	if err := server.Create(online).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+
+	result := sync(t, device, server)
+	if result.Pushed != 2 || result.Pulled != 1 || result.Conflicts != 0 {
+		t.Errorf("Expected 2 changes pushed and 1 pulled, got %+v", result)
+	}
+	for _, db := range []*gorm.DB{device, server} {
+		if playerName(t, db, offline) != "Offline" || playerName(t, db, online) != "Online" {
+			t.Errorf("Expected both replicas to have both players")
+		}
+	}
+	analyses, err := database.AnalysesForPlayer(server, offline.ID)
+	if err != nil || len(analyses) != 1 {
+		t.Errorf("Expected the analysis to be pushed, got %d: %v", len(analyses), err)
+	}
+	if result := sync(t, device, server); result.Pushed != 0 || result.Pulled != 0 {
+		t.Errorf("Expected nothing to sync again, got %+v", result)
+	}
+
+	// Updates made with conditions and deletes are synced too.
+	if err := server.Model(&database.Player{}).Where("name = ?", "Online").Update("name", "Renamed").Error; err != nil {
+		t.Fatalf("Failed to update Player: %v", err)
+	}
+	if err := device.Unscoped().Delete(&database.Player{}, "id = ?", offline.ID).Error; err != nil {
+		t.Fatalf("Failed to delete Player: %v", err)
+	}
+	sync(t, device, server)
+	if got := playerName(t, device, online); got != "Renamed" {
+		t.Errorf("Expected the rename to be pulled, got %q", got)
+	}
+	if got := playerName(t, server, offline); got != "" {
+		t.Errorf("Expected the deletion to be pushed, got %q", got)
+	}
+}
+
+func TestSyncConflict(t *testing.T) {
+	laptop, tablet, server := createTestDB(t), createTestDB(t), createTestDB(t)
+	player := &database.Player{Name: "Original"}
+	if err := server.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	sync(t, laptop, server)
+	sync(t, tablet, server)
+
+	// Both devices rename the player before syncing. The tablet renames it last, so its name wins.
+	rename := func(db *gorm.DB, name string) {
+		t.Helper()
+		if err := db.Model(player).Update("name", name).Error; err != nil {
+			t.Fatalf("Failed to update Player: %v", err)
+		}
+	}
+	rename(laptop, "Laptop")
+	rename(tablet, "Tablet")
+	sync(t, tablet, server)
+	if result := sync(t, laptop, server); result.Conflicts != 1 {
+		t.Errorf("Expected 1 conflict, got %+v", result)
+	}
+	sync(t, tablet, server)
+	for _, db := range []*gorm.DB{laptop, tablet, server} {
+		if got := playerName(t, db, player); got != "Tablet" {
+			t.Errorf("Expected every replica to keep the last name, got %q", got)
+		}
+	}
+
+	conflicts, err := database.SyncConflicts(server)
+	if err != nil {
+		t.Fatalf("SyncConflicts() failed: %v", err)
+	}
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0].Discarded, "Laptop") {
+		t.Fatalf("Expected the laptop's name in the conflict inbox, got %+v", conflicts)
+	}
+	// The laptop pushed the losing change, so the conflict is in its inbox too.
+	pushed, err := database.SyncConflicts(laptop)
+	if err != nil {
+		t.Fatalf("SyncConflicts() failed: %v", err)
+	}
+	if len(pushed) != 1 || pushed[0].RowID != player.ID || !strings.Contains(pushed[0].Kept, "Tablet") {
+		t.Errorf("Expected the conflict in the laptop's inbox, got %+v", pushed)
+	}
+	if tablets, err := database.SyncConflicts(tablet); err != nil || len(tablets) != 0 {
+		t.Errorf("Expected no conflicts on the tablet, got %d: %v", len(tablets), err)
+	}
+	if err := Resolve(server, conflicts[0], true, time.Now()); err != nil {
+		t.Fatalf("Resolve() failed: %v", err)
+	}
+	sync(t, laptop, server)
+	sync(t, tablet, server)
+	for _, db := range []*gorm.DB{laptop, tablet, server} {
+		if got := playerName(t, db, player); got != "Laptop" {
+			t.Errorf("Expected every replica to have the restored name, got %q", got)
+		}
+	}
+	if conflicts, err := database.SyncConflicts(server); err != nil || len(conflicts) != 0 {
+		t.Errorf("Expected the conflict to be resolved, got %d: %v", len(conflicts), err)
+	}
+}
+
+func TestSyncKeepsSecretsLocal(t *testing.T) {
+	device, server := createTestDB(t), createTestDB(t)
+	scout := &database.Scout{Username: "Ann", ShareKey: []byte("device key"), CalendarToken: "device token"}
+	if err := device.Create(scout).Error; err != nil {
+		t.Fatalf("Failed to create Scout: %v", err)
+	}
+	link := &database.ShareLink{ExpiresAt: time.Now().Add(time.Hour)}
+	if err := device.Create(link).Error; err != nil {
+		t.Fatalf("Failed to create ShareLink: %v", err)
+	}
+	if err := device.Create(&database.Player{Name: "Shared"}).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+
+	if result := sync(t, device, server); result.Pushed != 1 {
+		t.Errorf("Expected only the player to be pushed, got %+v", result)
+	}
+	var scouts, links int64
+	server.Model(&database.Scout{}).Count(&scouts)
+	server.Model(&database.ShareLink{}).Count(&links)
+	if scouts != 0 || links != 0 {
+		t.Errorf("Expected the Scout and share link to stay on the device, got %d and %d on the server", scouts, links)
+	}
+
+	// A replica can't push them either.
+	push := &Push{Replica: "device", Records: []Record{{Table: "scouts", RowID: scout.ID, Replica: "device", ChangedAt: time.Now(), Row: []byte(`{}`)}}}
+	if _, err := Receive(server, push, time.Now()); !errors.Is(err, ErrUnknownTable) {
+		t.Errorf("Expected pushing a Scout to fail with ErrUnknownTable, got %v", err)
+	}
+}
+
+func TestReceiveClampsChangedAt(t *testing.T) {
+	server := createTestDB(t)
+	player := &database.Player{Name: "Server"}
+	if err := server.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	player.Name = "Future"
+	row, err := json.Marshal(player)
+	if err != nil {
+		t.Fatalf("Failed to marshal Player: %v", err)
+	}
+
+	// The device pulled the player before changing it.
+	var base int64
+	if err := server.Model(&database.Change{}).Select("MAX(seq)").Scan(&base).Error; err != nil {
+		t.Fatalf("Failed to retrieve the last Change: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	push := &Push{Replica: "device", Base: base, Records: []Record{{Table: "players", RowID: player.ID, Replica: "device", ChangedAt: now.AddDate(10, 0, 0), Row: row}}}
+	if _, err := Receive(server, push, now); err != nil {
+		t.Fatalf("Receive() failed: %v", err)
+	}
+	var change database.Change
+	if err := server.Where("row_id = ? AND replica = ?", player.ID, "device").First(&change).Error; err != nil {
+		t.Fatalf("Failed to retrieve Change: %v", err)
+	}
+	if !change.ChangedAt.Equal(now) {
+		t.Errorf("Expected the change to be logged at %v, got %v", now, change.ChangedAt)
+	}
+}
+
+func TestWins(t *testing.T) {
+	now := time.Now()
+	change := &database.Change{Replica: "b", ChangedAt: now}
+	tests := []struct {
+		record Record
+		want   bool
+	}{
+		{Record{Replica: "a", ChangedAt: now.Add(time.Second)}, true},
+		{Record{Replica: "c", ChangedAt: now.Add(-time.Second)}, false},
+		{Record{Replica: "c", ChangedAt: now}, true},
+		{Record{Replica: "a", ChangedAt: now}, false},
+	}
+	for _, tt := range tests {
+		if got := wins(tt.record, change); got != tt.want {
+			t.Errorf("wins(%+v) = %v, want %v", tt.record, got, tt.want)
+		}
+	}
+}
+
+func TestNewClientRefusesLocalServers(t *testing.T) {
+	for _, server := range []string{"http://127.0.0.1:8080", "http://localhost", "http://10.0.0.1", "http://[::1]", "http://[fe80::1]", "http://169.254.169.254", "http://100.64.0.1"} {
+		if _, err := NewClient(server, false); !errors.Is(err, ErrForbiddenServer) {
+			t.Errorf("NewClient(%q) returned %v, want ErrForbiddenServer", server, err)
+		}
+		if _, err := NewClient(server, true); err != nil {
+			t.Errorf("NewClient(%q) of a peer failed: %v", server, err)
+		}
+	}
+	if _, err := NewClient("ftp://scouting.example.com", true); err == nil {
+		t.Errorf("Expected NewClient() to refuse other schemes than http and https")
+	}
+	// Host names are checked as they are dialled.
+	if err := dialPublic("tcp", "192.168.1.10:443", nil); !errors.Is(err, ErrForbiddenServer) {
+		t.Errorf("dialPublic() of a private address returned %v, want ErrForbiddenServer", err)
+	}
+	if err := dialPublic("tcp", "[::ffff:127.0.0.1]:80", nil); !errors.Is(err, ErrForbiddenServer) {
+		t.Errorf("dialPublic() of a mapped loopback address returned %v, want ErrForbiddenServer", err)
+	}
+	if err := dialPublic("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dialPublic() of a public address failed: %v", err)
+	}
+}
@@ -0,0 +1,379 @@
+// Package report produces printable scouting reports about a player as PDF documents, drawn entirely in Go.
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/thirdknife/scoutingapp/charts"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/i18n"
+	"github.com/thirdknife/scoutingapp/scoring"
+)
+
+// DefaultLatest is how many of the latest analyses a report lists, unless asked for another number.
+const DefaultLatest = 5
+
+// Page layout, in millimetres on A4 paper.
+const (
+	margin     = 15
+	lineHeight = 5
+	// radarSize is the width and height of the box each radar chart is drawn in.
+	radarSize = 70
+	// radarRings is the number of concentric grid lines, as in the SVG charts.
+	radarRings = 5
+)
+
+// Report is everything printed in a player's report.
+type Report struct {
+	// Player must have its Score computed.
+	Player *database.Player
+	// Profile is nil if the Scout hasn't recorded a PlayerAnalysis.
+	Profile *database.PlayerAnalysis
+	// Analyses are every analysis of the player in chronological order. Ratings are averaged over all of them, but
+	// only the Latest are listed.
+	Analyses []*database.AnalysisDetail
+	// Latest is how many of the latest analyses are listed. Zero lists none.
+	Latest int
+	// Scout is who recorded the analyses.
+	Scout *database.Scout
+	// Generated is when the report was produced. Ages are computed as of this time.
+	Generated time.Time
+}
+
+// LatestAnalyses returns up to n of the analyses, newest first. analyses must be in chronological order.
+func LatestAnalyses(analyses []*database.AnalysisDetail, n int) []*database.AnalysisDetail {
+	n = max(0, min(n, len(analyses)))
+	latest := make([]*database.AnalysisDetail, n)
+	for i := range latest {
+		latest[i] = analyses[len(analyses)-1-i]
+	}
+	return latest
+}
+
+// WritePDF writes the report as a PDF document in the language of l.
+//
+// Reports use the fonts built into every PDF reader, which cover the Latin-1 characters of the supported languages.
+// Other characters are replaced.
+func WritePDF(w io.Writer, r *Report, l *i18n.Localizer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.SetCreationDate(r.Generated)
+	pdf.SetModificationDate(r.Generated)
+
+	p := &writer{pdf: pdf, l: l, tr: pdf.UnicodeTranslatorFromDescriptor(""), report: r}
+	pdf.SetTitle(p.tr(r.Player.Name), false)
+	pdf.SetAuthor(p.tr(p.scoutName()), false)
+	pdf.SetFooterFunc(p.footer)
+	pdf.AddPage()
+
+	p.header()
+	p.profile()
+	p.averages()
+	p.analyses()
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("writing report of Player %v failed: %w", r.Player.ID, err)
+	}
+	return nil
+}
+
+// writer draws the sections of a report. fpdf remembers the first error, which is returned by Output.
+type writer struct {
+	pdf    *fpdf.Fpdf
+	l      *i18n.Localizer
+	report *Report
+	// tr converts UTF-8 text to the encoding of the built-in fonts.
+	tr func(string) string
+}
+
+func (p *writer) font(style string, size float64) {
+	p.pdf.SetFont("Helvetica", style, size)
+}
+
+// cell writes a single line of text in a cell of the given width. A zero width extends the cell to the right margin.
+func (p *writer) cell(width, height float64, text, align string, newLine bool) {
+	ln := 0
+	if newLine {
+		ln = 1
+	}
+	p.pdf.CellFormat(width, height, p.tr(text), "", ln, align, false, 0, "")
+}
+
+// paragraph writes text that wraps at the right margin.
+func (p *writer) paragraph(text string) {
+	p.pdf.MultiCell(0, lineHeight, p.tr(text), "", "L", false)
+}
+
+func (p *writer) scoutName() string {
+	if p.report.Scout == nil || p.report.Scout.Username == "" {
+		return p.l.T("unknown")
+	}
+	return p.report.Scout.Username
+}
+
+// spaceLeft is the height left on the current page, above the bottom margin.
+func (p *writer) spaceLeft() float64 {
+	_, pageHeight := p.pdf.GetPageSize()
+	return pageHeight - margin - p.pdf.GetY()
+}
+
+// ensureSpace starts a new page unless height fits on the current one.
+func (p *writer) ensureSpace(height float64) {
+	if p.spaceLeft() < height {
+		p.pdf.AddPage()
+	}
+}
+
+// heading starts a section, on a new page if there isn't room for the heading and a few lines after it.
+func (p *writer) heading(text string) {
+	p.ensureSpace(8 + 5*lineHeight)
+	p.pdf.Ln(lineHeight)
+	p.font("B", 14)
+	p.pdf.CellFormat(0, 8, p.tr(text), "B", 1, "L", false, 0, "")
+	p.pdf.Ln(2)
+}
+
+// footer attributes every page to the Scout.
+func (p *writer) footer() {
+	p.pdf.SetY(-margin)
+	p.font("", 8)
+	attribution := p.l.T("report.scout", p.scoutName()) + " - " + p.l.T("report.generated", p.l.DateTime(p.report.Generated))
+	p.cell(0, lineHeight, attribution, "L", false)
+	p.cell(0, lineHeight, p.l.T("report.page", p.pdf.PageNo()), "R", false)
+}
+
+func (p *writer) header() {
+	p.font("", 10)
+	p.cell(0, lineHeight, p.l.T("report.title"), "L", true)
+	p.font("B", 20)
+	p.cell(0, 10, p.report.Player.Name, "L", true)
+	p.font("", 12)
+	p.cell(0, 7, p.l.T("profile.score", p.score(p.report.Player.Score)), "L", true)
+	p.font("", 10)
+	p.cell(0, lineHeight, p.l.T("report.scout", p.scoutName()), "L", true)
+}
+
+func (p *writer) score(score *float64) string {
+	if score == nil {
+		return "-"
+	}
+	return p.l.Number(*score, 1)
+}
+
+func (p *writer) profile() {
+	p.heading(p.l.T("report.profile"))
+	profile := p.report.Profile
+	if profile == nil {
+		p.font("", 10)
+		p.paragraph(p.l.T("profile.noProfile"))
+		return
+	}
+	age := p.l.T("unknown")
+	if a, err := profile.Age(p.report.Generated); err == nil {
+		age = p.l.Integer(a)
+	}
+	birthdate := profile.Birthdate
+	if b, err := profile.BirthdateTime(); err == nil {
+		birthdate = p.l.Date(b)
+	}
+	for _, row := range [][2]string{
+		{p.l.T("profile.age"), age},
+		{p.l.T("profile.birthdate"), birthdate},
+		{p.l.T("profile.position"), p.l.Position(profile.Position)},
+		{p.l.T("profile.club"), profile.Club},
+		{p.l.T("profile.height"), p.l.T("profile.heightValue", profile.Height)},
+		{p.l.T("profile.weight"), p.l.T("profile.weightValue", profile.Weight)},
+		{p.l.T("profile.manager"), profile.ManagerName},
+		{p.l.T("profile.telephone"), profile.Telephone},
+	} {
+		p.font("B", 10)
+		p.cell(40, lineHeight+1, row[0], "L", false)
+		p.font("", 10)
+		p.cell(0, lineHeight+1, row[1], "L", true)
+	}
+	if profile.Notes != "" {
+		p.pdf.Ln(2)
+		p.font("", 10)
+		p.paragraph(profile.Notes)
+	}
+}
+
+// averages draws a radar chart and a table of the average ratings of every group the player was rated on.
+func (p *writer) averages() {
+	players := []charts.PlayerAverages{{Name: p.report.Player.Name, Averages: scoring.Averages(p.report.Analyses)}}
+	groups := charts.RatedGroups(players)
+	if len(groups) == 0 {
+		return
+	}
+	p.heading(p.l.T("profile.averageRatings"))
+	for _, group := range groups {
+		p.ensureSpace(radarSize)
+		top := p.pdf.GetY()
+		p.radar(charts.GroupRadar(group, players, p.l), margin, top)
+
+		// The table is drawn to the right of the chart.
+		left := float64(margin + radarSize + 5)
+		p.pdf.SetXY(left, top+lineHeight)
+		p.font("B", 9)
+		p.cell(60, lineHeight+1, p.l.T("report.attribute"), "L", false)
+		p.cell(20, lineHeight+1, p.l.T("report.average"), "R", false)
+		p.cell(20, lineHeight+1, p.l.T("report.samples"), "R", true)
+		p.font("", 9)
+		for _, a := range database.GroupAttributes(group) {
+			p.pdf.SetX(left)
+			avg := players[0].Averages[a]
+			mean := "-"
+			if avg.Rated() {
+				mean = p.l.Number(avg.Mean, 1)
+			}
+			p.cell(60, lineHeight, p.l.Attribute(a), "L", false)
+			p.cell(20, lineHeight, mean, "R", false)
+			p.cell(20, lineHeight, p.l.Integer(avg.Samples), "R", true)
+		}
+		p.pdf.SetY(top + radarSize + 2)
+	}
+}
+
+// radar draws a radar chart in the square of radarSize with its top left corner at x, y. It follows the layout of
+// charts.Radar.WriteSVG: the first axis points straight up, the rest follow clockwise, and missing values are drawn at
+// the centre.
+func (p *writer) radar(radar *charts.Radar, x, y float64) {
+	p.font("B", 9)
+	p.pdf.SetXY(x, y)
+	p.cell(radarSize, lineHeight, radar.Title, "C", false)
+
+	cx, cy := x+radarSize/2, y+radarSize/2+3
+	// Leave room around the chart for the axis labels.
+	radius := radarSize/2 - 12.0
+	point := func(i int, d float64) fpdf.PointType {
+		angle := 2*math.Pi*float64(i)/float64(len(radar.Axes)) - math.Pi/2
+		return fpdf.PointType{X: cx + d*math.Cos(angle), Y: cy + d*math.Sin(angle)}
+	}
+
+	p.pdf.SetLineWidth(0.2)
+	p.pdf.SetDrawColor(209, 213, 219)
+	for ring := 1; ring <= radarRings; ring++ {
+		var points []fpdf.PointType
+		for i := range radar.Axes {
+			points = append(points, point(i, radius*float64(ring)/radarRings))
+		}
+		p.pdf.Polygon(points, "D")
+	}
+	p.font("", 6)
+	p.pdf.SetTextColor(55, 65, 81)
+	for i, axis := range radar.Axes {
+		end := point(i, radius)
+		p.pdf.Line(cx, cy, end.X, end.Y)
+		label := point(i, radius+2)
+		width := p.pdf.GetStringWidth(p.tr(axis))
+		switch {
+		case math.Abs(label.X-cx) < 0.5:
+			label.X -= width / 2
+		case label.X < cx:
+			label.X -= width
+		}
+		p.pdf.Text(label.X, label.Y+1, p.tr(axis))
+	}
+	p.pdf.SetTextColor(0, 0, 0)
+
+	p.pdf.SetLineWidth(0.5)
+	for i, s := range radar.Series {
+		red, green, blue := rgb(charts.Color(i))
+		p.pdf.SetDrawColor(red, green, blue)
+		p.pdf.SetFillColor(red, green, blue)
+		var points []fpdf.PointType
+		for j, v := range s.Values {
+			if math.IsNaN(v) || v < 0 {
+				v = 0
+			}
+			points = append(points, point(j, radius*math.Min(v, radar.Max)/radar.Max))
+		}
+		p.pdf.SetAlpha(0.2, "Normal")
+		p.pdf.Polygon(points, "F")
+		p.pdf.SetAlpha(1, "Normal")
+		p.pdf.Polygon(points, "D")
+		for j, v := range s.Values {
+			if !math.IsNaN(v) {
+				p.pdf.Circle(points[j].X, points[j].Y, 0.7, "F")
+			}
+		}
+	}
+	p.pdf.SetDrawColor(0, 0, 0)
+	p.pdf.SetLineWidth(0.2)
+}
+
+// rgb parses a colour of the form "#rrggbb".
+func rgb(color string) (red, green, blue int) {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)
+}
+
+// analyses lists the latest analyses, newest first, with their notes and ratings.
+func (p *writer) analyses() {
+	if p.report.Latest <= 0 {
+		return
+	}
+	latest := LatestAnalyses(p.report.Analyses, p.report.Latest)
+	p.heading(p.l.T("report.latestAnalyses"))
+	if len(latest) == 0 {
+		p.font("", 10)
+		p.paragraph(p.l.T("profile.noAnalyses"))
+		return
+	}
+	for _, a := range latest {
+		p.ensureSpace(4 * lineHeight)
+		p.font("B", 11)
+		p.cell(0, lineHeight+1, p.dateTime(a.Date)+" - "+p.l.Category(a.Category), "L", true)
+		p.font("", 10)
+		p.paragraph(strings.Join([]string{
+			p.l.T("profile.venue", a.Venue),
+			p.l.T("profile.weather", a.WeatherCondition),
+			p.l.T("profile.playTime", p.playTime(a.PlayTimeMinutes)),
+		}, " "))
+		if a.Notes != "" {
+			p.paragraph(p.l.T("profile.notes", a.Notes))
+		}
+		p.font("", 9)
+		for _, g := range database.RatingGroups {
+			if !a.HasGroup(g) {
+				continue
+			}
+			var ratings []string
+			for _, attribute := range database.GroupAttributes(g) {
+				if r, ok := a.Rating(attribute); ok {
+					ratings = append(ratings, fmt.Sprintf("%s %d", p.l.Attribute(attribute), r))
+				}
+			}
+			if len(ratings) > 0 {
+				p.paragraph(p.l.Group(g) + ": " + strings.Join(ratings, ", "))
+			}
+		}
+		p.pdf.Ln(2)
+	}
+}
+
+// dateTime formats a date stored as yyyy-mm-dd hh:mm. Dates that can't be parsed are shown as they are.
+func (p *writer) dateTime(date string) string {
+	parsed, err := database.ParseDate(date)
+	if err != nil {
+		return date
+	}
+	return p.l.DateTime(parsed)
+}
+
+func (p *writer) playTime(minutes *int) string {
+	if minutes == nil {
+		return p.l.T("playTime.notRecorded")
+	}
+	if *minutes == 0 {
+		return p.l.T("playTime.bench")
+	}
+	return p.l.T("playTime.minutes", *minutes)
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/i18n"
+	"golang.org/x/text/language"
+)
+
+func analysesOn(dates ...string) []*database.AnalysisDetail {
+	var analyses []*database.AnalysisDetail
+	for _, date := range dates {
+		analyses = append(analyses, &database.AnalysisDetail{Analysis: &database.Analysis{Date: date}})
+	}
+	return analyses
+}
+
+func TestLatestAnalyses(t *testing.T) {
+	analyses := analysesOn("2024-01-01", "2024-02-01", "2024-03-01")
+	for _, test := range []struct {
+		n    int
+		want []string
+	}{
+		{2, []string{"2024-03-01", "2024-02-01"}},
+		{5, []string{"2024-03-01", "2024-02-01", "2024-01-01"}},
+		{0, nil},
+		{-1, nil},
+	} {
+		var got []string
+		for _, a := range LatestAnalyses(analyses, test.n) {
+			got = append(got, a.Date)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("LatestAnalyses(%d) = %v, expected %v", test.n, got, test.want)
+		}
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	score := 7.5
+	minutes := 90
+	var analyses []*database.AnalysisDetail
+	// Enough analyses to need several pages.
+	for day := 1; day <= 28; day++ {
+		analyses = append(analyses, &database.AnalysisDetail{
+			Analysis: &database.Analysis{
+				Date: fmt.Sprintf("2024-02-%02d 15:00", day), Category: database.Match, PlayTimeMinutes: &minutes,
+				Venue: "Estadio José Zorrilla", Notes: "Pressed well, tired after the hour.",
+			},
+			Tactical: &database.TacticalAnalysis{Vision: 7, Awareness: 8, MovementOffTheBall: database.Unrated},
+			Athletic: &database.AthleticAnalysis{Pace: 9, Sharpness: 6, Mobility: 7, BodyStrength: 5, WorkRate: 8},
+		})
+	}
+
+	for _, test := range []struct {
+		name   string
+		report *Report
+	}{
+		{"empty", &Report{Player: &database.Player{Name: "Nobody"}, Latest: DefaultLatest}},
+		{"full", &Report{
+			Player: &database.Player{Name: "Müller", Score: &score},
+			Profile: &database.PlayerAnalysis{
+				Birthdate: "05/06/05", Height: 181, Club: "Rovers", Position: database.Forward, Notes: "Two-footed.",
+			},
+			Analyses:  analyses,
+			Latest:    len(analyses),
+			Scout:     &database.Scout{Username: "scout"},
+			Generated: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WritePDF(&b, test.report, i18n.New(language.German)); err != nil {
+				t.Fatalf("WritePDF() failed: %v", err)
+			}
+			pdf := b.String()
+			if !strings.HasPrefix(pdf, "%PDF-") || !strings.HasSuffix(strings.TrimSpace(pdf), "%%EOF") {
+				t.Errorf("Expected a PDF document, got %.40q...", pdf)
+			}
+			if !strings.Contains(pdf, "/Title") {
+				t.Errorf("Expected the report to have a title")
+			}
+		})
+	}
+}
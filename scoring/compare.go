@@ -0,0 +1,168 @@
+package scoring
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+const (
+	// MinComparedPlayers and MaxComparedPlayers bound how many players can be compared side by side.
+	MinComparedPlayers = 2
+	MaxComparedPlayers = 4
+)
+
+// Filter selects the analyses that feed averages. The zero value selects every analysis.
+type Filter struct {
+	// From and To bound the analysis date, From inclusive and To exclusive. Zero values are unbounded.
+	From, To time.Time
+	// Category selects a single kind of analysis if it isn't empty.
+	Category database.AnalysisCategory
+}
+
+// ParseFilter builds a Filter from user input. from and to are inclusive dates in yyyy-mm-dd format, and any of the
+// arguments may be empty.
+func ParseFilter(from, to, category string) (Filter, error) {
+	var f Filter
+	var err error
+	if from != "" {
+		if f.From, err = time.Parse(time.DateOnly, from); err != nil {
+			return Filter{}, fmt.Errorf("start date %q is not of the form yyyy-mm-dd", from)
+		}
+	}
+	if to != "" {
+		if f.To, err = time.Parse(time.DateOnly, to); err != nil {
+			return Filter{}, fmt.Errorf("end date %q is not of the form yyyy-mm-dd", to)
+		}
+		f.To = f.To.AddDate(0, 0, 1)
+	}
+	switch c := database.AnalysisCategory(category); c {
+	case "", database.Other, database.Match, database.Training:
+		f.Category = c
+	default:
+		return Filter{}, fmt.Errorf("unknown analysis category %q", category)
+	}
+	return f, nil
+}
+
+// Match reports whether the analysis is selected by the filter. Analyses without a valid date are only selected if
+// the filter has no date bounds.
+func (f Filter) Match(a *database.Analysis) bool {
+	if f.Category != "" && a.Category != f.Category {
+		return false
+	}
+	if f.From.IsZero() && f.To.IsZero() {
+		return true
+	}
+	date, err := database.ParseDate(a.Date)
+	if err != nil {
+		return false
+	}
+	return !date.Before(f.From) && (f.To.IsZero() || date.Before(f.To))
+}
+
+// Apply returns the analyses selected by the filter.
+func (f Filter) Apply(analyses []*database.AnalysisDetail) []*database.AnalysisDetail {
+	var selected []*database.AnalysisDetail
+	for _, a := range analyses {
+		if f.Match(a.Analysis) {
+			selected = append(selected, a)
+		}
+	}
+	return selected
+}
+
+// Average is the mean rating of a single attribute.
+type Average struct {
+	Mean float64
+	// Samples is the number of analyses in which the attribute was rated.
+	Samples int
+	// PlayTimeMinutes is the total play time recorded for those analyses.
+	PlayTimeMinutes int
+}
+
+// Rated reports whether the attribute was rated at all.
+func (a Average) Rated() bool {
+	return a.Samples > 0
+}
+
+// Averages computes the mean of every attribute that was rated in at least one of the analyses. Unlike Score, every
+// analysis counts equally.
+func Averages(analyses []*database.AnalysisDetail) map[database.Attribute]Average {
+	averages := map[database.Attribute]Average{}
+	totals := map[database.Attribute]int{}
+	for _, a := range analyses {
+		for _, attribute := range database.AllAttributes() {
+			r, ok := a.Rating(attribute)
+			if !ok {
+				continue
+			}
+			avg := averages[attribute]
+			avg.Samples++
+			if a.PlayTimeMinutes != nil {
+				avg.PlayTimeMinutes += *a.PlayTimeMinutes
+			}
+			averages[attribute] = avg
+			totals[attribute] += r
+		}
+	}
+	for attribute, avg := range averages {
+		avg.Mean = float64(totals[attribute]) / float64(avg.Samples)
+		averages[attribute] = avg
+	}
+	return averages
+}
+
+// Comparison lines up the average ratings of several players.
+type Comparison struct {
+	Players []*database.Player
+	Filter  Filter
+	// Rows holds one row per attribute that at least one of the players was rated on, in AllAttributes order.
+	Rows []ComparisonRow
+}
+
+// ComparisonRow holds the averages of one attribute for each player.
+type ComparisonRow struct {
+	Attribute database.Attribute
+	// Averages are in the same order as Comparison.Players.
+	Averages []Average
+	// Best marks the players with the highest average. More than one player is marked if they are tied.
+	Best []bool
+}
+
+// Compare lines up the averages of the given players computed from the analyses selected by filter. analyses holds
+// the analyses of each player in the same order as players.
+func Compare(players []*database.Player, analyses [][]*database.AnalysisDetail, filter Filter) (*Comparison, error) {
+	if len(players) < MinComparedPlayers || len(players) > MaxComparedPlayers {
+		return nil, fmt.Errorf("between %d and %d players can be compared, got %d", MinComparedPlayers, MaxComparedPlayers, len(players))
+	}
+	averages := make([]map[database.Attribute]Average, len(players))
+	for i := range players {
+		averages[i] = Averages(filter.Apply(analyses[i]))
+	}
+
+	comparison := &Comparison{Players: players, Filter: filter}
+	for _, attribute := range database.AllAttributes() {
+		row := ComparisonRow{
+			Attribute: attribute,
+			Averages:  make([]Average, len(players)),
+			Best:      make([]bool, len(players)),
+		}
+		best := -1.0
+		for i := range players {
+			row.Averages[i] = averages[i][attribute]
+			if row.Averages[i].Rated() && row.Averages[i].Mean > best {
+				best = row.Averages[i].Mean
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		for i, avg := range row.Averages {
+			row.Best[i] = avg.Rated() && avg.Mean == best
+		}
+		comparison.Rows = append(comparison.Rows, row)
+	}
+	return comparison, nil
+}
@@ -0,0 +1,84 @@
+package scoring
+
+import (
+	"testing"
+
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter("2024-01-01", "2024-01-31", "Match")
+	if err != nil {
+		t.Fatalf("ParseFilter() failed: %v", err)
+	}
+	tests := []struct {
+		analysis *database.Analysis
+		want     bool
+	}{
+		{analysis: &database.Analysis{Category: database.Match, Date: "2024-01-01 10:00"}, want: true},
+		{analysis: &database.Analysis{Category: database.Match, Date: "2024-01-31 21:00"}, want: true},
+		{analysis: &database.Analysis{Category: database.Match, Date: "2024-02-01 00:00"}, want: false},
+		{analysis: &database.Analysis{Category: database.Match, Date: "2023-12-31 23:59"}, want: false},
+		{analysis: &database.Analysis{Category: database.Training, Date: "2024-01-15 10:00"}, want: false},
+		{analysis: &database.Analysis{Category: database.Match, Date: ""}, want: false},
+	}
+	for _, tt := range tests {
+		if got := f.Match(tt.analysis); got != tt.want {
+			t.Errorf("Match(%+v) = %v, want %v", tt.analysis, got, tt.want)
+		}
+	}
+
+	for _, bad := range [][3]string{{"01/01/2024", "", ""}, {"", "tomorrow", ""}, {"", "", "Friendly"}} {
+		if _, err := ParseFilter(bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("Expected ParseFilter(%q) to fail", bad)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := &database.Player{Name: "A"}
+	b := &database.Player{Name: "B"}
+	vision := database.Attribute{Group: database.TacticalRatings, Name: "Vision"}
+	awareness := database.Attribute{Group: database.TacticalRatings, Name: "Awareness"}
+	analyses := [][]*database.AnalysisDetail{
+		{
+			{Analysis: &database.Analysis{Category: database.Match, PlayTimeMinutes: minutes(90)}, Tactical: &database.TacticalAnalysis{Vision: 6, Awareness: 7, MovementOffTheBall: database.Unrated}},
+			{Analysis: &database.Analysis{Category: database.Match, PlayTimeMinutes: minutes(30)}, Tactical: &database.TacticalAnalysis{Vision: 8, Awareness: database.Unrated, MovementOffTheBall: database.Unrated}},
+			// Filtered out by category.
+			{Analysis: &database.Analysis{Category: database.Training}, Tactical: &database.TacticalAnalysis{Vision: 0, Awareness: 0, MovementOffTheBall: 0}},
+		},
+		{
+			{Analysis: &database.Analysis{Category: database.Match}, Tactical: &database.TacticalAnalysis{Vision: 7, Awareness: 9, MovementOffTheBall: database.Unrated}},
+		},
+	}
+
+	got, err := Compare([]*database.Player{a, b}, analyses, Filter{Category: database.Match})
+	if err != nil {
+		t.Fatalf("Compare() failed: %v", err)
+	}
+	if len(got.Rows) != 2 {
+		t.Fatalf("Expected rows for Vision and Awareness only, got %+v", got.Rows)
+	}
+	for _, row := range got.Rows {
+		switch row.Attribute {
+		case vision:
+			want := []Average{{Mean: 7, Samples: 2, PlayTimeMinutes: 120}, {Mean: 7, Samples: 1}}
+			if row.Averages[0] != want[0] || row.Averages[1] != want[1] {
+				t.Errorf("Vision averages = %+v, want %+v", row.Averages, want)
+			}
+			if !row.Best[0] || !row.Best[1] {
+				t.Errorf("Expected both players to be best at Vision, got %v", row.Best)
+			}
+		case awareness:
+			if row.Best[0] || !row.Best[1] {
+				t.Errorf("Expected only B to be best at Awareness, got %v", row.Best)
+			}
+		default:
+			t.Errorf("Unexpected row for %v", row.Attribute)
+		}
+	}
+
+	if _, err := Compare([]*database.Player{a}, analyses[:1], Filter{}); err == nil {
+		t.Error("Expected comparing a single player to fail")
+	}
+}
@@ -0,0 +1,245 @@
+// Package scoring derives an overall score for a player from the analyses Scouts recorded about them.
+package scoring
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultHalfLife is how long it takes for an analysis to count half as much as one recorded today.
+	DefaultHalfLife = 180 * 24 * time.Hour
+	// FullMatchMinutes is the play time at which an analysis gets its full weight.
+	FullMatchMinutes = 90
+	// minPlayTimeWeight is the weight of an analysis of a player who barely played, or was on the bench.
+	minPlayTimeWeight = 0.1
+)
+
+// Score is the overall score of a player.
+type Score struct {
+	// Value is on the same scale as the ratings it is derived from.
+	Value float64
+	// Ratings is the number of individual ratings the score is based on. Zero means there was nothing to score.
+	Ratings int
+	// Analyses is the number of analyses that contributed at least one rating.
+	Analyses int
+}
+
+// Rated reports whether there were any ratings to derive the score from.
+func (s Score) Rated() bool {
+	return s.Ratings > 0
+}
+
+// Engine computes scores relative to a point in time. The zero value is not usable, see New.
+type Engine struct {
+	// Now is the time recency is measured from.
+	Now time.Time
+	// HalfLife controls how quickly older analyses lose weight.
+	HalfLife time.Duration
+	// Weights controls how much each attribute counts, typically loaded from a database.WeightProfile. Every
+	// attribute counts equally if Weights is nil.
+	Weights database.Weights
+}
+
+// New returns an Engine with the default settings that measures recency from now.
+func New(now time.Time) *Engine {
+	return &Engine{Now: now, HalfLife: DefaultHalfLife}
+}
+
+// Groups returns the rating groups that are relevant for a position: the position's own group along with the
+// tactical, athletic and character groups. Every group is relevant if the position is unknown.
+func Groups(position database.PositionType) []database.RatingGroup {
+	var groups []database.RatingGroup
+	positional, hasPositional := database.PositionalRatings(position)
+	for _, g := range database.RatingGroups {
+		switch {
+		case !g.IsPositional():
+			groups = append(groups, g)
+		case position == "" || (hasPositional && g == positional):
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// Score computes the overall score of a player who plays in the given position.
+//
+// Each analysis is scored as the weighted mean of its rated, position-appropriate attributes; unrated attributes are
+// skipped. The overall score is the weighted mean of those analysis scores, where recent analyses and analyses with
+// more play time count for more.
+func (e *Engine) Score(position database.PositionType, analyses []*database.AnalysisDetail) Score {
+	var t tally
+	for _, a := range analyses {
+		e.add(&t, position, a)
+	}
+	return t.score()
+}
+
+// tally accumulates the analyses of a player one at a time, so that players can be scored without holding all of
+// their analyses at once.
+type tally struct {
+	total, weight float64
+	ratings       int
+	analyses      int
+}
+
+// add adds an analysis to the tally of a player who plays in the given position.
+func (e *Engine) add(t *tally, position database.PositionType, a *database.AnalysisDetail) {
+	var sum, attributeWeights float64
+	count := 0
+	for _, g := range Groups(position) {
+		for _, attribute := range database.GroupAttributes(g) {
+			w := e.attributeWeight(attribute)
+			if w <= 0 {
+				continue
+			}
+			if r, ok := a.Rating(attribute); ok {
+				sum += w * float64(r)
+				attributeWeights += w
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return
+	}
+	weight := e.recencyWeight(a.Analysis) * playTimeWeight(a.Analysis)
+	t.total += weight * sum / attributeWeights
+	t.weight += weight
+	t.ratings += count
+	t.analyses++
+}
+
+func (t *tally) score() Score {
+	score := Score{Ratings: t.ratings, Analyses: t.analyses}
+	if t.weight > 0 {
+		score.Value = t.total / t.weight
+	}
+	return score
+}
+
+func (e *Engine) attributeWeight(a database.Attribute) float64 {
+	if e.Weights == nil {
+		return 1
+	}
+	return e.Weights[a]
+}
+
+// recencyWeight halves the weight of an analysis for every HalfLife that passed since it was recorded. Analyses
+// without a valid date aren't discounted, as there is no way to tell how old they are.
+func (e *Engine) recencyWeight(a *database.Analysis) float64 {
+	date, err := database.ParseDate(a.Date)
+	if err != nil || e.HalfLife <= 0 {
+		return 1
+	}
+	age := e.Now.Sub(date)
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(e.HalfLife))
+}
+
+// playTimeWeight scales the weight of an analysis by the fraction of a full match that the player was on the pitch.
+// Analyses without a recorded play time, such as most training sessions, get the full weight.
+func playTimeWeight(a *database.Analysis) float64 {
+	if a.PlayTimeMinutes == nil {
+		return 1
+	}
+	return math.Max(minPlayTimeWeight, math.Min(1, float64(*a.PlayTimeMinutes)/FullMatchMinutes))
+}
+
+// ScorePlayers sets the Score of each player from their analyses. Players without usable ratings keep a nil Score.
+// Analyses are loaded a batch at a time, so memory only grows with the number of players.
+func (e *Engine) ScorePlayers(db *gorm.DB, players []*database.Player) error {
+	profiles, err := database.PlayerAnalysesByPlayer(db)
+	if err != nil {
+		return err
+	}
+	positions := map[uuid.UUID]database.PositionType{}
+	for id, profile := range profiles {
+		positions[id] = profile.Position
+	}
+	tallies := make(map[uuid.UUID]*tally, len(players))
+	for _, p := range players {
+		tallies[p.ID] = &tally{}
+	}
+	keep := func(a *database.Analysis) bool {
+		_, ok := tallies[a.PlayerID]
+		return ok
+	}
+	err = database.EachAnalysisBatch(db, database.AnalysisBatchSize, keep, func(details []*database.AnalysisDetail) error {
+		for _, a := range details {
+			e.add(tallies[a.PlayerID], positions[a.PlayerID], a)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range players {
+		p.Score = nil
+		if s := tallies[p.ID].score(); s.Rated() {
+			p.Score = &s.Value
+		}
+	}
+	return nil
+}
+
+// SetScore sets the Score of a single player from their analyses.
+func (e *Engine) SetScore(p *database.Player, position database.PositionType, analyses []*database.AnalysisDetail) {
+	p.Score = nil
+	if s := e.Score(position, analyses); s.Rated() {
+		p.Score = &s.Value
+	}
+}
+
+// TopByPosition returns up to perPosition players with the highest score for each position, best first. Players
+// who couldn't be scored are left out, and players without a recorded position are listed under "".
+func (e *Engine) TopByPosition(db *gorm.DB, perPosition int) (map[database.PositionType][]*database.Player, error) {
+	players, err := database.AllPlayers(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.ScorePlayers(db, players); err != nil {
+		return nil, err
+	}
+	profiles, err := database.PlayerAnalysesByPlayer(db)
+	if err != nil {
+		return nil, err
+	}
+
+	top := map[database.PositionType][]*database.Player{}
+	for _, p := range players {
+		if p.Score == nil {
+			continue
+		}
+		var position database.PositionType
+		if profile, ok := profiles[p.ID]; ok {
+			position = profile.Position
+		}
+		top[position] = append(top[position], p)
+	}
+	for position, ranked := range top {
+		Rank(ranked)
+		if len(ranked) > perPosition {
+			top[position] = ranked[:perPosition]
+		}
+	}
+	return top, nil
+}
+
+// Rank sorts players by Score, best first. Players without a Score are moved to the end.
+func Rank(players []*database.Player) {
+	sort.SliceStable(players, func(i, j int) bool {
+		a, b := players[i].Score, players[j].Score
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a > *b
+	})
+}
@@ -0,0 +1,163 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+var now = time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+func minutes(m int) *int {
+	return &m
+}
+
+func analysis(date string, playTime *int) *database.Analysis {
+	return &database.Analysis{Date: date, PlayTimeMinutes: playTime}
+}
+
+func TestScoreSkipsUnratedAndIrrelevantAttributes(t *testing.T) {
+	analyses := []*database.AnalysisDetail{
+		{
+			Analysis: analysis("2024-07-01 12:00", nil),
+			Tactical: &database.TacticalAnalysis{Vision: 8, Awareness: 6, MovementOffTheBall: database.Unrated},
+			// Forward ratings are not relevant to a defender.
+			Forward: &database.ForwardAnalysis{BallControl: 1},
+		},
+		{
+			// Nothing relevant was rated, so this analysis doesn't count.
+			Analysis: analysis("2024-07-01 12:00", nil),
+			Athletic: &database.AthleticAnalysis{Pace: database.Unrated, Sharpness: database.Unrated, Mobility: database.Unrated, BodyStrength: database.Unrated, WorkRate: database.Unrated},
+		},
+	}
+
+	got := New(now).Score(database.Defender, analyses)
+	want := Score{Value: 7, Ratings: 2, Analyses: 1}
+	if got != want {
+		t.Errorf("Score() = %+v, want %+v", got, want)
+	}
+}
+
+func TestScoreWeighting(t *testing.T) {
+	tests := []struct {
+		name     string
+		analyses []*database.AnalysisDetail
+		want     float64
+	}{
+		{
+			name: "older analyses count for less",
+			analyses: []*database.AnalysisDetail{
+				{Analysis: analysis("2024-07-01 12:00", nil), Tactical: &database.TacticalAnalysis{Vision: 9, Awareness: 9, MovementOffTheBall: 9}},
+				// One half-life ago, so it counts half as much.
+				{Analysis: analysis(now.Add(-DefaultHalfLife).Format(database.DateFormat), nil), Tactical: &database.TacticalAnalysis{Vision: 3, Awareness: 3, MovementOffTheBall: 3}},
+			},
+			want: 7,
+		},
+		{
+			name: "less play time counts for less",
+			analyses: []*database.AnalysisDetail{
+				{Analysis: analysis("2024-07-01 12:00", minutes(90)), Tactical: &database.TacticalAnalysis{Vision: 9, Awareness: 9, MovementOffTheBall: 9}},
+				{Analysis: analysis("2024-07-01 12:00", minutes(45)), Tactical: &database.TacticalAnalysis{Vision: 3, Awareness: 3, MovementOffTheBall: 3}},
+			},
+			want: 7,
+		},
+		{
+			name: "no analyses",
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(now).Score(database.Midfielder, tt.analyses)
+			if math.Abs(got.Value-tt.want) > 1e-9 {
+				t.Errorf("Score() = %v, want %v", got.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroups(t *testing.T) {
+	tests := []struct {
+		position database.PositionType
+		want     int
+	}{
+		{position: database.Defender, want: 4},
+		{position: database.Goalkeeper, want: 3},
+		{position: "", want: 6},
+	}
+	for _, tt := range tests {
+		if got := Groups(tt.position); len(got) != tt.want {
+			t.Errorf("Groups(%q) = %v, want %d groups", tt.position, got, tt.want)
+		}
+	}
+}
+
+func TestTopByPosition(t *testing.T) {
+	db := database.CreateTestDB(t)
+	create := func(name string, vision int) *database.Player {
+		p := &database.Player{Name: name}
+		db.Create(p)
+		db.Create(&database.PlayerAnalysis{PlayerID: p.ID, Position: database.Midfielder})
+		if vision != database.Unrated {
+			tactical := &database.TacticalAnalysis{Vision: vision, Awareness: database.Unrated, MovementOffTheBall: database.Unrated}
+			db.Create(tactical)
+			db.Create(&database.Analysis{PlayerID: p.ID, Date: "2024-07-01 12:00", TacticalAnalysisID: tactical.ID})
+		}
+		return p
+	}
+	create("Good", 6)
+	better := create("Better", 8)
+	create("Unrated", database.Unrated)
+	if db.Error != nil {
+		t.Fatalf("Error during testdata setup: %v", db.Error)
+	}
+
+	top, err := New(now).TopByPosition(db, 1)
+	if err != nil {
+		t.Fatalf("TopByPosition() failed: %v", err)
+	}
+	got := top[database.Midfielder]
+	if len(got) != 1 || got[0].ID != better.ID {
+		t.Fatalf("Expected only %q to be returned, got %v", better.Name, got)
+	}
+	if *got[0].Score != 8 {
+		t.Errorf("Expected a score of 8, got %v", *got[0].Score)
+	}
+}
+
+func TestScoreWithWeights(t *testing.T) {
+	analyses := []*database.AnalysisDetail{{
+		Analysis: analysis("2024-07-01 12:00", nil),
+		Athletic: &database.AthleticAnalysis{Pace: 9, Sharpness: 5, Mobility: 1, BodyStrength: 1, WorkRate: database.Unrated},
+	}}
+	engine := New(now)
+	engine.Weights = database.Weights{
+		{Group: database.AthleticRatings, Name: "Pace"}:      3,
+		{Group: database.AthleticRatings, Name: "Sharpness"}: 1,
+		// WorkRate isn't rated, so its weight is ignored.
+		{Group: database.AthleticRatings, Name: "WorkRate"}: 10,
+	}
+
+	got := engine.Score(database.Forward, analyses)
+	want := Score{Value: 8, Ratings: 2, Analyses: 1}
+	if got != want {
+		t.Errorf("Score() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRank(t *testing.T) {
+	score := func(s float64) *float64 { return &s }
+	players := []*database.Player{
+		{Name: "Unscored"},
+		{Name: "Low", Score: score(2)},
+		{Name: "High", Score: score(9)},
+	}
+	Rank(players)
+	for i, want := range []string{"High", "Low", "Unscored"} {
+		if players[i].Name != want {
+			t.Errorf("Expected %q at position %d, got %q", want, i, players[i].Name)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+// registerAPIRoutes serves the JSON API under /api.
+func registerAPIRoutes(e *echo.Echo) {
+	api := e.Group("/api")
+
+	api.GET("/players", func(c echo.Context) error {
+		db := scoutDB(c)
+		players, _, err := rankedPlayers(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		return c.JSON(http.StatusOK, players)
+	})
+
+	api.GET("/players/:id", func(c echo.Context) error {
+		db := scoutDB(c)
+		details, err := loadPlayerDetails(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		return c.JSON(http.StatusOK, details)
+	})
+
+	api.GET("/compare", func(c echo.Context) error {
+		db := scoutDB(c)
+		comparison, err := comparePlayers(db, compareForm(c))
+		if err != nil {
+			return fmt.Errorf("comparing players: %w", err)
+		}
+		return c.JSON(http.StatusOK, comparison)
+	})
+
+	api.GET("/weight-profiles", func(c echo.Context) error {
+		db := scoutDB(c)
+		profiles, err := database.AllWeightProfiles(db)
+		if err != nil {
+			return fmt.Errorf("fetching weight profiles: %w", err)
+		}
+		return c.JSON(http.StatusOK, profiles)
+	})
+
+	api.GET("/weight-profiles/:id", func(c echo.Context) error {
+		db := scoutDB(c)
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusNotFound, localizer(c).T("weights.notFound"))
+		}
+		profile, weights, err := database.WeightProfileByID(db, id)
+		if err != nil {
+			return fmt.Errorf("fetching weight profile: %w", err)
+		}
+		byKey := map[string]float64{}
+		for attribute, weight := range weights {
+			byKey[attribute.Key()] = weight
+		}
+		return c.JSON(http.StatusOK, struct {
+			*database.WeightProfile
+			Weights map[string]float64
+		}{profile, byKey})
+	})
+}
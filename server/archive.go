@@ -0,0 +1,51 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/thirdknife/scoutingapp/archive"
+)
+
+// maxArchiveSize limits the size of uploaded archives.
+const maxArchiveSize = "64M"
+
+// registerArchiveRoutes serves downloading an archive of all of the Scout's data, and restoring one from the
+// settings page.
+func registerArchiveRoutes(e *echo.Echo) {
+	e.GET("/export/archive.zip", func(c echo.Context) error {
+		now := time.Now()
+		startDownload(c, "application/zip", fmt.Sprintf("scouting-archive-%s.zip", now.Format(time.DateOnly)))
+		_, err := archive.Export(c.Response(), scoutDB(c), now)
+		return err
+	})
+
+	e.POST("/settings/archive", func(c echo.Context) error {
+		db := scoutDB(c)
+		header, err := c.FormFile("file")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T("error.missingFile"))
+		}
+		upload, err := header.Open()
+		if err != nil {
+			return fmt.Errorf("opening uploaded file: %w", err)
+		}
+		defer upload.Close()
+
+		manifest, err := archive.Import(db, upload, header.Size)
+		if errors.Is(err, archive.ErrInvalidArchive) || errors.Is(err, archive.ErrIncompatible) {
+			message := localizer(c).T("settings.invalidArchive", err.Error())
+			return renderSettings(c, http.StatusUnprocessableEntity, message)
+		}
+		if err != nil {
+			return fmt.Errorf("restoring archive: %w", err)
+		}
+		// The restored Scout may have chosen another language, which applies from the next page on.
+		message := localizer(c).T("settings.restored", manifest.Rows(), localizer(c).DateTime(manifest.Created))
+		return renderSettings(c, http.StatusOK, message)
+	}, middleware.BodyLimit(maxArchiveSize))
+}
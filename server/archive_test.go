@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func TestArchiveRestore(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	player := &database.Player{Name: "Archived"}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export/archive.zip", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /export/archive.zip returned %d", rec.Code)
+	}
+	archive := rec.Body.Bytes()
+
+	if err := db.Create(&database.Player{Name: "Added later"}).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+
+	restore := func(contents []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		cookie := csrfCookie(t, s)
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("file", "archive.zip")
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		fw.Write(contents)
+		mw.WriteField("_csrf", cookie.Value)
+		mw.Close()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/settings/archive", &body)
+		req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+		req.AddCookie(cookie)
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := restore([]byte("not an archive")); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Restoring an invalid archive returned %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if rec := restore(archive); rec.Code != http.StatusOK {
+		t.Fatalf("Restoring the archive returned %d: %s", rec.Code, rec.Body.String())
+	}
+	players, err := database.AllPlayers(db)
+	if err != nil {
+		t.Fatalf("AllPlayers() failed: %v", err)
+	}
+	if len(players) != 1 || players[0].ID != player.ID {
+		t.Errorf("Expected only the archived player after restoring, got %+v", players)
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/thirdknife/scoutingapp/calendar"
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+const (
+	// calendarFeedLimit is how many upcoming events the calendar feed lists.
+	calendarFeedLimit = 500
+	// maxFixturesSize limits the size of uploaded fixture lists.
+	maxFixturesSize = "4M"
+)
+
+// registerCalendarRoutes serves the Scout's calendar feed, rotating its secret URL, and importing fixtures into
+// planned events.
+func registerCalendarRoutes(e *echo.Echo) {
+	// Calendar apps fetch the feed without the Scout's cookies, so the secret token in the URL is what grants access.
+	e.GET("/calendar/:token/events.ics", func(c echo.Context) error {
+		db := scoutDB(c)
+		token, err := database.CalendarToken(db)
+		if err != nil {
+			return fmt.Errorf("fetching calendar token: %w", err)
+		}
+		if subtle.ConstantTimeCompare([]byte(c.Param("token")), []byte(token)) != 1 {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		now := time.Now()
+		events, err := database.UpcomingEvents(db, now, calendarFeedLimit)
+		if err != nil {
+			return fmt.Errorf("fetching events: %w", err)
+		}
+		l := localizer(c)
+		c.Response().Header().Set(echo.HeaderContentType, calendar.ContentType)
+		c.Response().Header().Set("Cache-Control", "no-store")
+		c.Response().WriteHeader(http.StatusOK)
+		return calendar.WriteEvents(c.Response(), l, l.T("calendar.name"), events, now)
+	})
+
+	e.POST("/settings/calendar/reset", func(c echo.Context) error {
+		if _, err := database.ResetCalendarToken(scoutDB(c)); err != nil {
+			return fmt.Errorf("resetting calendar token: %w", err)
+		}
+		return renderSettings(c, http.StatusOK, localizer(c).T("settings.calendarReset"))
+	})
+
+	e.POST("/settings/calendar/import", func(c echo.Context) error {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T("error.missingFile"))
+		}
+		upload, err := header.Open()
+		if err != nil {
+			return fmt.Errorf("opening uploaded file: %w", err)
+		}
+		defer upload.Close()
+
+		fixtures, err := calendar.Parse(upload)
+		if errors.Is(err, calendar.ErrInvalidFile) {
+			message := localizer(c).T("settings.invalidFixtures", err.Error())
+			return renderSettings(c, http.StatusUnprocessableEntity, message)
+		}
+		if err != nil {
+			return fmt.Errorf("reading fixtures: %w", err)
+		}
+		result, err := calendar.ImportFixtures(scoutDB(c), fixtures, time.Now())
+		if err != nil {
+			return fmt.Errorf("importing fixtures: %w", err)
+		}
+		message := localizer(c).T("settings.fixturesImported", result.Created, result.Updated, result.Past)
+		return renderSettings(c, http.StatusOK, message)
+	}, middleware.BodyLimit(maxFixturesSize))
+}
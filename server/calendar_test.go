@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	event := &database.Event{Name: "Rovers v United", Date: time.Now().AddDate(0, 0, 3).Format(database.DateFormat)}
+	if err := db.Create(event).Error; err != nil {
+		t.Fatalf("Failed to create Event: %v", err)
+	}
+	player := &database.Player{Name: "Watched"}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	if err := db.Create(&database.EventPlayer{EventID: event.ID, PlayerID: player.ID}).Error; err != nil {
+		t.Fatalf("Failed to create EventPlayer: %v", err)
+	}
+	token, err := database.CalendarToken(db)
+	if err != nil {
+		t.Fatalf("CalendarToken() failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/settings", nil))
+	if !strings.Contains(rec.Body.String(), "/calendar/"+token+"/events.ics") {
+		t.Errorf("Expected the settings page to show the feed URL")
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar/"+token+"/events.ics", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), "text/calendar") {
+		t.Fatalf("GET the feed returned %d with %q", rec.Code, rec.Header().Get(echo.HeaderContentType))
+	}
+	for _, want := range []string{"SUMMARY:Rovers v United", "Watched"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected the feed to contain %q, got %s", want, rec.Body.String())
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar/guessed/events.ics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET a feed with the wrong token returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	cookie := csrfCookie(t, s)
+	req := httptest.NewRequest(http.MethodPost, "/settings/calendar/reset", strings.NewReader("_csrf="+cookie.Value))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Resetting the feed URL returned %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar/"+token+"/events.ics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET the feed after resetting its URL returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestImportFixtures(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	start := time.Now().AddDate(0, 1, 0).UTC().Format("20060102T150405Z")
+	file := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1@league\r\nSUMMARY:Imported\r\nDTSTART:" + start +
+		"\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	upload := func(contents string) *httptest.ResponseRecorder {
+		t.Helper()
+		cookie := csrfCookie(t, s)
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("file", "fixtures.ics")
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		fw.Write([]byte(contents))
+		mw.WriteField("_csrf", cookie.Value)
+		mw.Close()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/settings/calendar/import", &body)
+		req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+		req.AddCookie(cookie)
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := upload("not a calendar"); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Importing an invalid file returned %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	for i := 0; i < 2; i++ {
+		if rec := upload(file); rec.Code != http.StatusOK {
+			t.Fatalf("Importing fixtures returned %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	var events []*database.Event
+	if err := db.Find(&events).Error; err != nil {
+		t.Fatalf("Failed to retrieve Events: %v", err)
+	}
+	if len(events) != 1 || events[0].Name != "Imported" {
+		t.Errorf("Expected importing twice to plan one event, got %+v", events)
+	}
+}
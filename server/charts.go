@@ -0,0 +1,25 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/charts"
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+// ratingGroupParam returns the rating group named by the group route parameter. ok is false for unknown groups.
+func ratingGroupParam(c echo.Context) (group database.RatingGroup, ok bool) {
+	group = database.RatingGroup(c.Param("group"))
+	return group, len(database.GroupAttributes(group)) > 0
+}
+
+// renderSVG responds with a chart as a standalone SVG document.
+func renderSVG(c echo.Context, chart charts.Chart) error {
+	svg, err := charts.Standalone(chart)
+	if err != nil {
+		return fmt.Errorf("drawing chart: %w", err)
+	}
+	return c.Blob(http.StatusOK, "image/svg+xml", svg)
+}
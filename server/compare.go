@@ -0,0 +1,137 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/charts"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/scoring"
+	base "github.com/thirdknife/scoutingapp/views"
+	"gorm.io/gorm"
+)
+
+// errInvalidComparison is wrapped by errors caused by the user's selection on the comparison page.
+var errInvalidComparison = errors.New("invalid comparison")
+
+// comparisonError is an invalid selection on the comparison page, explained by a message key and its arguments.
+type comparisonError struct {
+	key  string
+	args []any
+}
+
+func (e *comparisonError) Error() string {
+	return fmt.Sprintf("%v: %s %v", errInvalidComparison, e.key, e.args)
+}
+
+func (e *comparisonError) Unwrap() error {
+	return errInvalidComparison
+}
+
+// compareForm reads the comparison selection from the query string. Player IDs are written the way uuid.UUID writes
+// them, so that players selected more than once, however their IDs were written, are compared once.
+func compareForm(c echo.Context) base.CompareForm {
+	var ids []string
+	for _, id := range c.QueryParams()["players"] {
+		if parsed, err := uuid.Parse(id); err == nil {
+			// IDs that don't parse are kept as they are, for comparePlayers to report.
+			id = parsed.String()
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return base.CompareForm{
+		PlayerIDs: ids,
+		From:      c.QueryParam("from"),
+		To:        c.QueryParam("to"),
+		Category:  c.QueryParam("category"),
+	}
+}
+
+// comparePlayers compares the players selected in form.
+func comparePlayers(db *gorm.DB, form base.CompareForm) (*scoring.Comparison, error) {
+	filter, err := scoring.ParseFilter(form.From, form.To, form.Category)
+	if err != nil {
+		return nil, &comparisonError{key: "compare.invalidFilter"}
+	}
+	if len(form.PlayerIDs) < scoring.MinComparedPlayers || len(form.PlayerIDs) > scoring.MaxComparedPlayers {
+		return nil, &comparisonError{key: "compare.playerCount", args: []any{scoring.MinComparedPlayers, scoring.MaxComparedPlayers}}
+	}
+	ids := make([]uuid.UUID, len(form.PlayerIDs))
+	for i, id := range form.PlayerIDs {
+		if ids[i], err = uuid.Parse(id); err != nil {
+			return nil, &comparisonError{key: "compare.unknownPlayer", args: []any{id}}
+		}
+	}
+
+	byID, err := database.PlayersByID(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	analyses, err := database.AnalysesForPlayers(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	players := make([]*database.Player, len(ids))
+	playerAnalyses := make([][]*database.AnalysisDetail, len(ids))
+	for i, id := range ids {
+		if byID[id] == nil {
+			return nil, &comparisonError{key: "compare.unknownPlayer", args: []any{id.String()}}
+		}
+		players[i] = byID[id]
+		playerAnalyses[i] = analyses[id]
+	}
+	comparison, err := scoring.Compare(players, playerAnalyses, filter)
+	if err != nil {
+		return nil, fmt.Errorf("comparing players failed: %w", err)
+	}
+	return comparison, nil
+}
+
+// registerCompareRoutes serves the side-by-side comparison of players.
+func registerCompareRoutes(e *echo.Echo) {
+	e.GET("/compare", func(c echo.Context) error {
+		db := scoutDB(c)
+		players, err := database.AllPlayers(db)
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		form := compareForm(c)
+		if len(form.PlayerIDs) == 0 {
+			return RenderComponent(c, http.StatusOK, base.ComparePlayers(players, form, nil, nil, ""))
+		}
+		comparison, err := comparePlayers(db, form)
+		var invalid *comparisonError
+		if errors.As(err, &invalid) {
+			message := localizer(c).T(invalid.key, invalid.args...)
+			return RenderComponent(c, http.StatusBadRequest, base.ComparePlayers(players, form, nil, nil, message))
+		}
+		if err != nil {
+			return fmt.Errorf("comparing players: %w", err)
+		}
+		radars := charts.GroupRadars(charts.ComparisonAverages(comparison), localizer(c))
+		return RenderComponent(c, http.StatusOK, base.ComparePlayers(players, form, comparison, radars, ""))
+	})
+
+	e.GET("/compare/radar/:group", func(c echo.Context) error {
+		db := scoutDB(c)
+		group, ok := ratingGroupParam(c)
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotFound, localizer(c).T("error.unknownRatingGroup"))
+		}
+		comparison, err := comparePlayers(db, compareForm(c))
+		var invalid *comparisonError
+		if errors.As(err, &invalid) {
+			return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T(invalid.key, invalid.args...)).SetInternal(err)
+		}
+		if err != nil {
+			return fmt.Errorf("comparing players: %w", err)
+		}
+		return renderSVG(c, charts.GroupRadar(group, charts.ComparisonAverages(comparison), localizer(c)))
+	})
+}
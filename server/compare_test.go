@@ -0,0 +1,47 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func TestCompareDuplicatePlayers(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	players := []*database.Player{{Name: "Ann"}, {Name: "Bo"}}
+	for _, p := range players {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("Failed to create Player: %v", err)
+		}
+		tactical := &database.TacticalAnalysis{Vision: 7, Awareness: database.Unrated, MovementOffTheBall: database.Unrated}
+		if err := db.Create(tactical).Error; err != nil {
+			t.Fatalf("Failed to create TacticalAnalysis: %v", err)
+		}
+		analysis := &database.Analysis{PlayerID: p.ID, Category: database.Match, Date: "2024-01-01 15:00", TacticalAnalysisID: tactical.ID}
+		if err := db.Create(analysis).Error; err != nil {
+			t.Fatalf("Failed to create Analysis: %v", err)
+		}
+	}
+	ann, bo := players[0].ID.String(), players[1].ID.String()
+
+	rec := httptest.NewRecorder()
+	upper := strings.ToUpper(ann)
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/compare?players="+ann+"&players="+bo+"&players="+upper+"&players={"+ann+"}", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Comparing with a duplicate player returned %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := strings.Count(rec.Body.String(), `href="/players/`+ann+`"`); got != 1 {
+		t.Errorf("Expected Ann to be compared once, got %d columns", got)
+	}
+
+	// Selecting the same player twice is only one player, which is too few to compare.
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/compare?players="+ann+"&players="+ann, nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Comparing a player with themselves returned %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
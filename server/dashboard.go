@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/scoring"
+	base "github.com/thirdknife/scoutingapp/views"
+)
+
+const (
+	// latestAnalysesLimit is how many analyses the dashboard lists.
+	latestAnalysesLimit = 10
+	// notSeenAfter is how long it takes before a player is listed as not seen recently.
+	notSeenAfter = 90 * 24 * time.Hour
+	// topProspectsPerPosition is how many top rated players the dashboard lists for each position.
+	topProspectsPerPosition = 3
+	// upcomingEventsLimit is how many upcoming events the dashboard lists.
+	upcomingEventsLimit = 10
+)
+
+// registerDashboardRoutes serves the dashboard page along with one endpoint per widget, which the page loads lazily.
+func registerDashboardRoutes(e *echo.Echo) {
+	e.GET("/dashboard", func(c echo.Context) error {
+		return RenderComponent(c, http.StatusOK, base.Dashboard())
+	})
+
+	e.GET("/dashboard/positions", func(c echo.Context) error {
+		db := scoutDB(c)
+		counts, err := database.CountPlayersByPosition(db)
+		if err != nil {
+			return fmt.Errorf("counting players: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.DashboardPositions(counts))
+	})
+
+	e.GET("/dashboard/latest-analyses", func(c echo.Context) error {
+		db := scoutDB(c)
+		analyses, err := database.LatestAnalyses(db, latestAnalysesLimit)
+		if err != nil {
+			return fmt.Errorf("fetching analyses: %w", err)
+		}
+		playerIDs := make([]uuid.UUID, len(analyses))
+		for i, a := range analyses {
+			playerIDs[i] = a.PlayerID
+		}
+		players, err := database.PlayersByID(db, playerIDs)
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.DashboardLatestAnalyses(analyses, players))
+	})
+
+	e.GET("/dashboard/not-seen", func(c echo.Context) error {
+		db := scoutDB(c)
+		lastSeen, err := database.PlayersNotSeenSince(db, time.Now().Add(-notSeenAfter))
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.DashboardNotSeen(lastSeen))
+	})
+
+	e.GET("/dashboard/top-prospects", func(c echo.Context) error {
+		db := scoutDB(c)
+		top, err := scoring.New(time.Now()).TopByPosition(db, topProspectsPerPosition)
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.DashboardTopProspects(top))
+	})
+
+	e.GET("/dashboard/upcoming-events", func(c echo.Context) error {
+		db := scoutDB(c)
+		events, err := database.UpcomingEvents(db, time.Now(), upcomingEventsLimit)
+		if err != nil {
+			return fmt.Errorf("fetching events: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.DashboardUpcomingEvents(events))
+	})
+}
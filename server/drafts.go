@@ -0,0 +1,88 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	base "github.com/thirdknife/scoutingapp/views"
+)
+
+// maxDraftsPerSync limits how many drafts a single sync request may send.
+const maxDraftsPerSync = 100
+
+// draftSync is the body of a sync request, holding every draft queued in the browser.
+type draftSync struct {
+	Drafts []*database.AnalysisDraft `json:"drafts"`
+}
+
+// draftResult is the outcome of syncing one draft. Status is "saved" for new drafts, "duplicate" for drafts that
+// were saved by an earlier sync and "invalid" for drafts that can never be saved, with Error explaining why.
+type draftResult struct {
+	ClientID   uuid.UUID  `json:"clientId"`
+	AnalysisID *uuid.UUID `json:"analysisId,omitempty"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// registerDraftRoutes serves the offline drafting form, the files that make the app installable and usable offline,
+// and the endpoint that queued drafts are synced to.
+func registerDraftRoutes(e *echo.Echo, publicDir string) {
+	e.GET("/drafts", func(c echo.Context) error {
+		db := scoutDB(c)
+		players, err := database.AllPlayers(db)
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.DraftAnalysis(players))
+	})
+
+	// The service worker can only control pages within the path it is served from, so it is served from the root
+	// rather than /public. Browsers must revalidate it to pick up new versions promptly.
+	e.GET("/sw.js", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+		return c.File(filepath.Join(publicDir, "sw.js"))
+	})
+	e.GET("/manifest.webmanifest", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, "application/manifest+json")
+		return c.File(filepath.Join(publicDir, "manifest.webmanifest"))
+	})
+
+	// Drafts are synced idempotently: the browser keeps sending a draft until it gets a result for it, so a draft
+	// that was saved but whose response was lost is reported as a duplicate rather than saved twice.
+	e.POST("/api/drafts", func(c echo.Context) error {
+		db := scoutDB(c)
+		var body draftSync
+		if err := c.Bind(&body); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "malformed drafts")
+		}
+		if len(body.Drafts) > maxDraftsPerSync {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "too many drafts")
+		}
+		results := make([]draftResult, 0, len(body.Drafts))
+		for _, draft := range body.Drafts {
+			if draft == nil {
+				continue
+			}
+			result := draftResult{ClientID: draft.ClientID}
+			id, saved, err := database.SaveDraft(db, draft)
+			switch {
+			case errors.Is(err, database.ErrInvalidDraft):
+				result.Status, result.Error = "invalid", err.Error()
+			case err != nil:
+				// The draft stays queued in the browser and is sent again with the next sync.
+				return fmt.Errorf("saving drafts: %w", err)
+			case saved:
+				result.Status, result.AnalysisID = "saved", &id
+			default:
+				result.Status, result.AnalysisID = "duplicate", &id
+			}
+			results = append(results, result)
+		}
+		return c.JSON(http.StatusOK, map[string]any{"results": results})
+	})
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/logging"
+	"github.com/thirdknife/scoutingapp/playerimport"
+	base "github.com/thirdknife/scoutingapp/views"
+	"gorm.io/gorm"
+)
+
+// problem is an error response of the API in the RFC 9457 problem details format.
+type problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// publicError returns the status of an error and the detail that can be shown to Scouts. Handlers return typed errors
+// and leave choosing the response to this function. The text of unexpected errors may contain queries or file paths,
+// so it is only logged (see requestLogMiddleware) and never shown.
+func publicError(err error) (status int, detail string) {
+	var he *echo.HTTPError
+	switch {
+	case errors.As(err, &he):
+		if he.Code >= http.StatusInternalServerError {
+			return he.Code, ""
+		}
+		// Messages of HTTP errors are written for Scouts, unlike their internal errors.
+		if message, ok := he.Message.(string); ok && message != http.StatusText(he.Code) {
+			return he.Code, message
+		}
+		return he.Code, ""
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound, ""
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return http.StatusConflict, ""
+	case errors.Is(err, database.ErrInvalidDraft), errors.Is(err, database.ErrInvalidWeightProfile), errors.Is(err, errInvalidComparison),
+		errors.Is(err, playerimport.ErrInvalidFile):
+		// Validation errors explain what is wrong with the Scout's input.
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, database.ErrScoutsClosed):
+		return http.StatusServiceUnavailable, ""
+	}
+	return http.StatusInternalServerError, ""
+}
+
+// wantsJSON reports whether the error response should be problem details rather than a page: API requests, and
+// requests that accept JSON but not HTML.
+func wantsJSON(c echo.Context) bool {
+	if strings.HasPrefix(c.Request().URL.Path, "/api/") {
+		return true
+	}
+	accept := c.Request().Header.Get(echo.HeaderAccept)
+	return strings.Contains(accept, "json") && !strings.Contains(accept, echo.MIMETextHTML)
+}
+
+// httpErrorHandler responds to every error returned by handlers and middleware. Errors are logged by
+// requestLogMiddleware.
+func httpErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	status, detail := publicError(err)
+	requestID := logging.RequestID(c.Request().Context())
+	var rendered error
+	switch {
+	case c.Request().Method == http.MethodHead:
+		rendered = c.NoContent(status)
+	case wantsJSON(c):
+		c.Response().Header().Set(echo.HeaderContentType, "application/problem+json")
+		rendered = c.JSON(status, problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(status),
+			Status:    status,
+			Detail:    detail,
+			Instance:  c.Request().URL.Path,
+			RequestID: requestID,
+		})
+	default:
+		rendered = RenderComponent(c, status, base.ErrorPage(status, detail, requestID))
+	}
+	if rendered != nil {
+		ctx := c.Request().Context()
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to render error", "error", rendered.Error())
+	}
+}
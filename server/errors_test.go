@@ -0,0 +1,124 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+func TestPublicError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail string
+	}{
+		{"not found", fmt.Errorf("fetching player: %w", gorm.ErrRecordNotFound), http.StatusNotFound, ""},
+		{"duplicate", fmt.Errorf("saving: %w", gorm.ErrDuplicatedKey), http.StatusConflict, ""},
+		{"invalid input", fmt.Errorf("%w: name is required", database.ErrInvalidWeightProfile), http.StatusBadRequest, "invalid weight profile: name is required"},
+		{"closed", database.ErrScoutsClosed, http.StatusServiceUnavailable, ""},
+		{"http error", echo.NewHTTPError(http.StatusNotFound, "unknown rating group"), http.StatusNotFound, "unknown rating group"},
+		{"http error without message", echo.NewHTTPError(http.StatusForbidden), http.StatusForbidden, ""},
+		{"internal http error", echo.NewHTTPError(http.StatusServiceUnavailable, "no such file /tmp/x.db"), http.StatusServiceUnavailable, ""},
+		{"unexpected", errors.New("no such table: players"), http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, detail := publicError(tt.err)
+			if status != tt.wantStatus || detail != tt.wantDetail {
+				t.Errorf("publicError() = %d, %q, want %d, %q", status, detail, tt.wantStatus, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	s, _ := createTestServer(t, io.Discard)
+	missing := uuid.NewString()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/players/"+missing, nil)
+	req.Header.Set("Accept-Language", "es")
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET of a missing player returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if !strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), echo.MIMETextHTML) || !strings.Contains(rec.Body.String(), "No encontrado") {
+		t.Errorf("Expected a translated error page, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/players/"+missing, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET of a missing player from the API returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if got := rec.Header().Get(echo.HeaderContentType); got != "application/problem+json" {
+		t.Errorf("Expected problem details, got content type %q", got)
+	}
+	var p problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Failed to decode problem details: %v", err)
+	}
+	if p.Status != http.StatusNotFound || p.Title != "Not Found" || p.Instance != "/api/players/"+missing || p.RequestID != rec.Header().Get(echo.HeaderXRequestID) {
+		t.Errorf("Unexpected problem details %+v", p)
+	}
+}
+
+func TestHandlerErrorsAreTranslated(t *testing.T) {
+	s, _ := createTestServer(t, io.Discard)
+	cookie := csrfCookie(t, s)
+	form := url.Values{"_csrf": {cookie.Value}, "name": {""}, "Athletic.Pace": {"abc"}}
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{"rating group", httptest.NewRequest(http.MethodGet, "/players/"+uuid.NewString()+"/radar/Unknown", nil), "Ese grupo de valoraciones no existe."},
+		{"comparison", httptest.NewRequest(http.MethodGet, "/compare?players="+uuid.NewString(), nil), "Selecciona entre 2 y 4 jugadores."},
+		{"weight", httptest.NewRequest(http.MethodPost, "/weight-profiles", strings.NewReader(form.Encode())), "El peso de Velocidad debe ser un número."},
+		{"weight profile", httptest.NewRequest(http.MethodGet, "/api/weight-profiles/unknown", nil), "Ese perfil de ponderación no existe."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Header.Set("Accept-Language", "es")
+			tt.req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			tt.req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, tt.req)
+			if !strings.Contains(html.UnescapeString(rec.Body.String()), tt.want) {
+				t.Errorf("Expected %q in the response, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestInternalErrorsAreNotShown(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	// Break the database so that listing players fails with an error mentioning the query.
+	if err := db.Migrator().DropTable(&database.PlayerAnalysis{}); err != nil {
+		t.Fatalf("Failed to drop table: %v", err)
+	}
+
+	for _, path := range []string{"/players", "/api/players"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("GET %s returned %d, want %d", path, rec.Code, http.StatusInternalServerError)
+		}
+		if body := rec.Body.String(); strings.Contains(body, "player_analyses") || strings.Contains(body, "no such table") {
+			t.Errorf("GET %s leaked the internal error: %s", path, body)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/export"
+	"github.com/thirdknife/scoutingapp/playerlist"
+)
+
+// filteredPlayers returns every player matching the filters of the players table in the query parameters, in the
+// table's order. Unlike the table, the result isn't paged.
+func filteredPlayers(c echo.Context) ([]*playerlist.Row, error) {
+	summaries, _, err := scoredSummaries(c, scoutDB(c))
+	if err != nil {
+		return nil, err
+	}
+	return playerlist.Filter(playerlist.ParseQuery(c.QueryParams()), summaries, time.Now()), nil
+}
+
+// startDownload sets the headers of a file download. The body is written by the caller; errors while writing it
+// can't change the response, which has already started, but are still logged.
+func startDownload(c echo.Context, contentType, filename string) {
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	c.Response().WriteHeader(http.StatusOK)
+}
+
+// registerExportRoutes serves downloads of the players listed in the players table, which take the same query
+// parameters as /players.
+func registerExportRoutes(e *echo.Echo) {
+	e.GET("/export/players.csv", func(c echo.Context) error {
+		rows, err := filteredPlayers(c)
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		startDownload(c, "text/csv; charset=utf-8", "players.csv")
+		return export.WritePlayersCSV(c.Response(), rows)
+	})
+
+	e.GET("/export/analyses.csv", func(c echo.Context) error {
+		rows, err := filteredPlayers(c)
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		startDownload(c, "text/csv; charset=utf-8", "analyses.csv")
+		return export.WriteAnalysesCSV(c.Response(), scoutDB(c), rows)
+	})
+
+	e.GET("/export/players.xlsx", func(c echo.Context) error {
+		rows, err := filteredPlayers(c)
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		startDownload(c, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "players.xlsx")
+		return export.WriteWorkbook(c.Response(), scoutDB(c), rows)
+	})
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+func TestExportRespectsFilters(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	for name, position := range map[string]database.PositionType{"Kept": database.Forward, "Filtered": database.Defender} {
+		player := &database.Player{Name: name}
+		if err := db.Create(player).Error; err != nil {
+			t.Fatalf("Failed to create Player: %v", err)
+		}
+		if err := db.Create(&database.PlayerAnalysis{PlayerID: player.ID, Position: position}).Error; err != nil {
+			t.Fatalf("Failed to create PlayerAnalysis: %v", err)
+		}
+		if err := db.Create(&database.Analysis{PlayerID: player.ID, Date: "2024-01-01 15:00"}).Error; err != nil {
+			t.Fatalf("Failed to create Analysis: %v", err)
+		}
+	}
+
+	for _, path := range []string{"/export/players.csv", "/export/analyses.csv"} {
+		rec := httptest.NewRecorder()
+		// Paging doesn't apply to exports.
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"?position=Forward&size=1&page=2", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s returned %d", path, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment") {
+			t.Errorf("Expected GET %s to be a download, got Content-Disposition %q", path, got)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "Kept") || strings.Contains(body, "Filtered") {
+			t.Errorf("Expected GET %s to only export the filtered player, got %s", path, body)
+		}
+	}
+
+	// Workbooks are compressed, so only check that one is downloaded.
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export/players.xlsx?position=Forward", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "PK") {
+		t.Errorf("GET /export/players.xlsx returned %d, want a zip file", rec.Code)
+	}
+}
+
+func TestExportLoadsAnalysesInBatches(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	player := &database.Player{Name: "Busy"}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	analyses := make([]*database.Analysis, database.AnalysisBatchSize+1)
+	for i := range analyses {
+		analyses[i] = &database.Analysis{PlayerID: player.ID, Date: "2024-01-01 15:00"}
+	}
+	if err := db.CreateInBatches(analyses, 100).Error; err != nil {
+		t.Fatalf("Failed to create Analyses: %v", err)
+	}
+
+	// Record the most analyses any query loaded, which scoring and exporting must keep to a single batch.
+	loaded := int64(0)
+	err := db.Callback().Query().After("gorm:query").Register("test:loaded_analyses", func(tx *gorm.DB) {
+		if tx.Statement.Table == "analyses" {
+			loaded = max(loaded, tx.Statement.RowsAffected)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to register callback: %v", err)
+	}
+	for _, path := range []string{"/export/players.csv", "/export/analyses.csv", "/export/players.xlsx"} {
+		loaded = 0
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s returned %d", path, rec.Code)
+		}
+		if loaded == 0 || loaded > database.AnalysisBatchSize {
+			t.Errorf("GET %s loaded %d analyses at once, want at most %d", path, loaded, database.AnalysisBatchSize)
+		}
+	}
+}
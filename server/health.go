@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// operationalPaths are polled by the club server's monitoring rather than used by Scouts. They don't need a Scout
+// database, a locale or a CSRF cookie, and aren't logged.
+var operationalPaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// isOperational is a middleware.Skipper for requests to operationalPaths.
+func isOperational(c echo.Context) bool {
+	return operationalPaths[c.Request().URL.Path]
+}
+
+// readinessTimeout bounds how long /readyz waits for a database.
+const readinessTimeout = 2 * time.Second
+
+// readiness is the response of /readyz. Checks maps each check to "ok" or the reason it failed.
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// registerOperationalRoutes serves health checks and metrics.
+func (s *Server) registerOperationalRoutes(e *echo.Echo) {
+	// The process is alive as long as it can respond.
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	e.GET("/readyz", func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), readinessTimeout)
+		defer cancel()
+		r := readiness{Status: "ok", Checks: map[string]string{}}
+		for name, check := range map[string]func(context.Context) error{
+			"dataDir":  s.checkDataDir,
+			"database": s.checkDatabase,
+		} {
+			r.Checks[name] = "ok"
+			if err := check(ctx); err != nil {
+				r.Status = "unavailable"
+				r.Checks[name] = err.Error()
+			}
+		}
+		if r.Status != "ok" {
+			return c.JSON(http.StatusServiceUnavailable, r)
+		}
+		return c.JSON(http.StatusOK, r)
+	})
+
+	e.GET("/metrics", echo.WrapHandler(s.metrics.Handler()))
+}
+
+// checkDataDir checks that new Scout databases can be created.
+func (s *Server) checkDataDir(context.Context) error {
+	f, err := os.CreateTemp(s.deps.Scouts.Dir(), ".readyz-*")
+	if err != nil {
+		return fmt.Errorf("data directory is not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkDatabase checks that the Scout database opens and responds.
+func (s *Server) checkDatabase(ctx context.Context) error {
+	db, err := s.deps.Scouts.Open(s.config.Scout)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// metricsMiddleware records the duration of every request by route.
+func (s *Server) metricsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			status := c.Response().Status
+			if he, ok := err.(*echo.HTTPError); ok && !c.Response().Committed {
+				status = he.Code
+			}
+			route := c.Path()
+			if route == "" {
+				// Unmatched requests are grouped together, so that the number of series stays bounded.
+				route = "unmatched"
+			}
+			s.metrics.ObserveRequest(c.Request().Method, route, status, time.Since(start))
+			return err
+		}
+	}
+}
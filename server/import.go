@@ -0,0 +1,194 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/thirdknife/scoutingapp/playerimport"
+	base "github.com/thirdknife/scoutingapp/views"
+)
+
+const (
+	// maxImportSize limits the size of uploaded files.
+	maxImportSize = "2M"
+	// importUploadTTL is how long an uploaded file is kept for its columns to be mapped and imported.
+	importUploadTTL = time.Hour
+	// maxImportUploads limits how many uploaded files are kept at a time. The oldest make way for new ones.
+	maxImportUploads = 32
+)
+
+// errImportExpired is returned for mapping forms whose upload is no longer kept.
+var errImportExpired = errors.New("uploaded file expired")
+
+// importUpload is a file uploaded to be imported by a Scout.
+type importUpload struct {
+	scout   string
+	file    *playerimport.File
+	expires time.Time
+}
+
+// importUploads keeps uploaded files between the steps of an import, so that the mapping form only needs to carry
+// their ID rather than the file itself.
+type importUploads struct {
+	mu      sync.Mutex
+	uploads map[string]*importUpload
+}
+
+// add keeps an uploaded file and returns its ID.
+func (u *importUploads) add(scout string, file *playerimport.File, now time.Time) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.uploads == nil {
+		u.uploads = map[string]*importUpload{}
+	}
+	var oldest string
+	for id, upload := range u.uploads {
+		if now.After(upload.expires) {
+			delete(u.uploads, id)
+		} else if oldest == "" || upload.expires.Before(u.uploads[oldest].expires) {
+			oldest = id
+		}
+	}
+	if len(u.uploads) >= maxImportUploads {
+		delete(u.uploads, oldest)
+	}
+	id := uuid.NewString()
+	u.uploads[id] = &importUpload{scout: scout, file: file, expires: now.Add(importUploadTTL)}
+	return id
+}
+
+// get returns the file the Scout uploaded with the ID, if it hasn't expired.
+func (u *importUploads) get(scout, id string, now time.Time) (*playerimport.File, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	upload, ok := u.uploads[id]
+	if !ok || upload.scout != scout || now.After(upload.expires) {
+		return nil, false
+	}
+	return upload.file, true
+}
+
+// remove forgets an upload once it has been imported.
+func (u *importUploads) remove(id string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.uploads, id)
+}
+
+// importForm reads the state of an import from the mapping form. The ID of the uploaded file is carried in the
+// "upload" field, and the field of each column in the "map" fields, in column order.
+func (s *Server) importForm(c echo.Context) (base.ImportForm, error) {
+	id := c.FormValue("upload")
+	file, ok := s.imports.get(s.config.Scout, id, time.Now())
+	if !ok {
+		return base.ImportForm{}, errImportExpired
+	}
+	params, err := c.FormParams()
+	if err != nil {
+		return base.ImportForm{}, fmt.Errorf("%w: %v", playerimport.ErrInvalidFile, err)
+	}
+	form := base.ImportForm{
+		Upload:  id,
+		File:    file,
+		Mapping: playerimport.Mapping{},
+		Options: playerimport.Options{SkipDuplicates: c.FormValue("skipDuplicates") == "true"},
+	}
+	for _, field := range params["map"] {
+		form.Mapping = append(form.Mapping, playerimport.Field(field))
+	}
+	return form, nil
+}
+
+// previewImport renders the mapping form along with a dry run of the import. Mappings that don't fit the file are
+// explained instead.
+func previewImport(c echo.Context, form base.ImportForm) error {
+	report, err := playerimport.DryRun(scoutDB(c), form.File, form.Mapping, form.Options, time.Now())
+	if errors.Is(err, playerimport.ErrInvalidFile) {
+		message := localizer(c).T("import.invalidFile", err.Error())
+		return RenderComponent(c, http.StatusUnprocessableEntity, base.ImportPlayers(form, nil, message, false))
+	}
+	if err != nil {
+		return fmt.Errorf("checking import: %w", err)
+	}
+	return RenderComponent(c, http.StatusOK, base.ImportPlayers(form, report, "", false))
+}
+
+// renderImportExpired asks the Scout to upload their file again once it is no longer kept.
+func renderImportExpired(c echo.Context) error {
+	return RenderComponent(c, http.StatusGone, base.ImportUpload(localizer(c).T("import.expired")))
+}
+
+// registerImportRoutes serves the steps of importing players from a CSV file: uploading it, mapping its columns and
+// previewing the result, and committing the import.
+func (s *Server) registerImportRoutes(e *echo.Echo) {
+	e.GET("/import", func(c echo.Context) error {
+		return RenderComponent(c, http.StatusOK, base.ImportUpload(""))
+	})
+
+	g := e.Group("/import", middleware.BodyLimit(maxImportSize))
+
+	g.POST("", func(c echo.Context) error {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T("error.missingFile"))
+		}
+		upload, err := header.Open()
+		if err != nil {
+			return fmt.Errorf("opening uploaded file: %w", err)
+		}
+		defer upload.Close()
+		file, err := playerimport.Parse(upload)
+		if errors.Is(err, playerimport.ErrInvalidFile) {
+			message := localizer(c).T("import.invalidFile", err.Error())
+			return RenderComponent(c, http.StatusUnprocessableEntity, base.ImportUpload(message))
+		}
+		if err != nil {
+			return err
+		}
+		form := base.ImportForm{
+			Upload:  s.imports.add(s.config.Scout, file, time.Now()),
+			File:    file,
+			Mapping: playerimport.GuessMapping(file.Header),
+		}
+		return previewImport(c, form)
+	})
+
+	g.POST("/preview", func(c echo.Context) error {
+		form, err := s.importForm(c)
+		if errors.Is(err, errImportExpired) {
+			return renderImportExpired(c)
+		}
+		if err != nil {
+			return err
+		}
+		return previewImport(c, form)
+	})
+
+	g.POST("/commit", func(c echo.Context) error {
+		form, err := s.importForm(c)
+		if errors.Is(err, errImportExpired) {
+			return renderImportExpired(c)
+		}
+		if err != nil {
+			return err
+		}
+		report, err := playerimport.Commit(scoutDB(c), form.File, form.Mapping, form.Options, time.Now())
+		switch {
+		case errors.Is(err, playerimport.ErrInvalidRows):
+			// The players changed since the preview, e.g. a duplicate was added in the meantime.
+			return RenderComponent(c, http.StatusConflict, base.ImportPlayers(form, report, "", false))
+		case errors.Is(err, playerimport.ErrInvalidFile):
+			return previewImport(c, form)
+		case err != nil:
+			return fmt.Errorf("importing players: %w", err)
+		}
+		s.imports.remove(form.Upload)
+		return RenderComponent(c, http.StatusOK, base.ImportPlayers(form, report, "", true))
+	})
+}
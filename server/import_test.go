@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/playerimport"
+)
+
+// uploadImport uploads a CSV file to be imported and returns the preview along with the ID of the upload.
+func uploadImport(t *testing.T, s *Server, cookie *http.Cookie, data string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "players.csv")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	io.WriteString(fw, data)
+	mw.WriteField("_csrf", cookie.Value)
+	mw.Close()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/import", &body)
+	req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+	req.AddCookie(cookie)
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /import returned %d: %s", rec.Code, rec.Body.String())
+	}
+	match := regexp.MustCompile(`name="upload" value="([^"]+)"`).FindStringSubmatch(rec.Body.String())
+	if match == nil {
+		t.Fatalf("Expected the ID of the upload in the mapping form: %s", rec.Body.String())
+	}
+	return rec, match[1]
+}
+
+func TestImportPlayers(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	cookie := csrfCookie(t, s)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/import", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /import returned %d", rec.Code)
+	}
+
+	// Upload a file whose columns can't all be guessed.
+	rec, _ = uploadImport(t, s, cookie, "Name,Verein,Position\nA,Rovers,Forward\nB,United,Sweeper\n")
+	if !strings.Contains(rec.Body.String(), "Sweeper") || strings.Contains(rec.Body.String(), `formaction="/import/commit"`) {
+		t.Errorf("Expected the unknown position to be reported and the import not to be offered")
+	}
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		form.Set("_csrf", cookie.Value)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.AddCookie(cookie)
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+	data := "Name,Verein,Position\nA,Rovers,Forward\nB,United,Defender\n"
+	_, upload := uploadImport(t, s, cookie, data)
+	mapped := url.Values{"upload": {upload}, "map": {"name", "club", "position"}}
+
+	rec = post("/import/preview", mapped)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `formaction="/import/commit"`) {
+		t.Fatalf("Expected the fixed file to be offered for import, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var n int64
+	db.Model(&database.Player{}).Count(&n)
+	if n != 0 {
+		t.Fatalf("Expected previews not to import anything, got %d players", n)
+	}
+
+	rec = post("/import/commit", mapped)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /import/commit returned %d: %s", rec.Code, rec.Body.String())
+	}
+	db.Model(&database.Player{}).Count(&n)
+	if n != 2 {
+		t.Errorf("Expected 2 players to be imported, got %d", n)
+	}
+	if rec := post("/import/commit", mapped); rec.Code != http.StatusGone {
+		t.Errorf("Expected an imported upload to be forgotten, got %d", rec.Code)
+	}
+
+	// Importing again finds the duplicates and imports nothing.
+	_, upload = uploadImport(t, s, cookie, data)
+	mapped.Set("upload", upload)
+	rec = post("/import/commit", mapped)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected importing duplicates to fail, got %d", rec.Code)
+	}
+	db.Model(&database.Player{}).Count(&n)
+	if n != 2 {
+		t.Errorf("Expected nothing more to be imported, got %d players", n)
+	}
+
+	rec = post("/import/preview", url.Values{"upload": {upload}, "map": {"club", "", "position"}})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected a mapping without the name to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestImportLargeFile(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	cookie := csrfCookie(t, s)
+
+	// Commas and line breaks triple in size when URL-encoded, so this file would exceed maxImportSize if the mapping
+	// form carried it.
+	var data strings.Builder
+	data.WriteString("name,notes\n")
+	for i := 0; data.Len() < 1<<20; i++ {
+		fmt.Fprintf(&data, "Player %d,\"%s\"\n", i, strings.Repeat(",", 1000))
+	}
+	_, upload := uploadImport(t, s, cookie, data.String())
+
+	form := url.Values{"_csrf": {cookie.Value}, "upload": {upload}, "map": {"name", "notes"}}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/import/commit", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.AddCookie(cookie)
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /import/commit returned %d: %s", rec.Code, rec.Body.String())
+	}
+	var n int64
+	db.Model(&database.Player{}).Count(&n)
+	if n == 0 {
+		t.Errorf("Expected the players to be imported")
+	}
+}
+
+func TestImportUploads(t *testing.T) {
+	var uploads importUploads
+	now := time.Now()
+	file := &playerimport.File{Header: []string{"name"}}
+	id := uploads.add("scout", file, now)
+	if got, ok := uploads.get("scout", id, now); !ok || got != file {
+		t.Errorf("Expected the upload to be kept")
+	}
+	if _, ok := uploads.get("other scout", id, now); ok {
+		t.Errorf("Expected other Scouts not to get the upload")
+	}
+	if _, ok := uploads.get("scout", id, now.Add(importUploadTTL+time.Second)); ok {
+		t.Errorf("Expected the upload to expire")
+	}
+	for i := 0; i < maxImportUploads; i++ {
+		uploads.add("scout", file, now.Add(time.Duration(i+1)*time.Second))
+	}
+	if _, ok := uploads.get("scout", id, now); ok {
+		t.Errorf("Expected the oldest upload to make way for newer ones")
+	}
+	if len(uploads.uploads) != maxImportUploads {
+		t.Errorf("Expected %d uploads to be kept, got %d", maxImportUploads, len(uploads.uploads))
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/i18n"
+	base "github.com/thirdknife/scoutingapp/views"
+)
+
+// localeMiddleware picks the language of every request, preferring the language the Scout chose over the one their
+// browser asks for, and makes it available to views through the request context.
+func localeMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isOperational(c) {
+				return next(c)
+			}
+			preference := ""
+			// Failing to read the preference shouldn't fail the request; the browser's language is used instead.
+			if scout, err := database.LocalScout(scoutDB(c)); err == nil {
+				preference = scout.Language
+			}
+			tag := i18n.Match(preference, c.Request().Header.Get("Accept-Language"))
+			ctx := i18n.WithLocalizer(c.Request().Context(), i18n.New(tag))
+			c.SetRequest(c.Request().WithContext(ctx))
+			c.Response().Header().Set("Content-Language", tag.String())
+			// Pages differ by language, so caches must not serve one browser's page to another.
+			c.Response().Header().Add("Vary", "Accept-Language")
+			return next(c)
+		}
+	}
+}
+
+// localizer is the Localizer chosen for the request by localeMiddleware.
+func localizer(c echo.Context) *i18n.Localizer {
+	return i18n.FromContext(c.Request().Context())
+}
+
+// registerSettingsRoutes serves the Scout's preferences.
+func registerSettingsRoutes(e *echo.Echo) {
+	e.GET("/settings", func(c echo.Context) error {
+		return renderSettings(c, http.StatusOK, "")
+	})
+
+	e.POST("/settings", func(c echo.Context) error {
+		db := scoutDB(c)
+		language := c.FormValue("language")
+		if language != "" && !i18n.Supported(language) {
+			return renderSettings(c, http.StatusBadRequest, localizer(c).T("settings.unsupported"))
+		}
+		if err := database.SetScoutLanguage(db, language); err != nil {
+			return fmt.Errorf("saving settings: %w", err)
+		}
+		// Confirm in the language that was just chosen.
+		tag := i18n.Match(language, c.Request().Header.Get("Accept-Language"))
+		l := i18n.New(tag)
+		c.SetRequest(c.Request().WithContext(i18n.WithLocalizer(c.Request().Context(), l)))
+		c.Response().Header().Set("Content-Language", tag.String())
+		return renderSettings(c, http.StatusOK, l.T("settings.saved"))
+	})
+}
+
+// renderSettings renders the settings page with the Scout's current preferences and message above the forms.
+func renderSettings(c echo.Context, status int, message string) error {
+	db := scoutDB(c)
+	scout, err := database.LocalScout(db)
+	if err != nil {
+		return fmt.Errorf("fetching settings: %w", err)
+	}
+	token, err := database.CalendarToken(db)
+	if err != nil {
+		return fmt.Errorf("fetching calendar token: %w", err)
+	}
+	calendarURL := absoluteURL(c, "/calendar/"+token+"/events.ics")
+	return RenderComponent(c, status, base.Settings(scout.Language, calendarURL, message))
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/thirdknife/scoutingapp/logging"
+)
+
+// requestContextMiddleware puts a logger into the request context that annotates every record with the request ID and
+// the acting Scout. It must run after middleware.RequestID.
+func (s *Server) requestContextMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id := c.Response().Header().Get(echo.HeaderXRequestID)
+			logger := s.deps.Logger.With("request_id", id, "scout", s.config.Scout)
+			ctx := logging.WithRequestID(logging.WithLogger(c.Request().Context(), logger), id)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// requestLogMiddleware logs every request once it has been handled. Server errors are logged as errors.
+func requestLogMiddleware() echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		Skipper:     isOperational,
+		HandleError: true,
+		LogLatency:  true,
+		LogMethod:   true,
+		LogURI:      true,
+		LogStatus:   true,
+		LogError:    true,
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			ctx := c.Request().Context()
+			attrs := []slog.Attr{
+				slog.String("method", v.Method),
+				slog.String("uri", v.URI),
+				slog.Int("status", v.Status),
+				slog.Duration("duration", v.Latency),
+			}
+			level := slog.LevelInfo
+			if v.Error != nil {
+				attrs = append(attrs, slog.String("error", errorText(v.Error)))
+			}
+			if v.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logging.FromContext(ctx).LogAttrs(ctx, level, "request", attrs...)
+			return nil
+		},
+	})
+}
+
+// errorText describes an error for the log, including the internal error of an echo.HTTPError which isn't shown to
+// users.
+func errorText(err error) string {
+	if he, ok := err.(*echo.HTTPError); ok && he.Internal != nil {
+		return fmt.Sprintf("%v: %v", he.Message, he.Internal)
+	}
+	return err.Error()
+}
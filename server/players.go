@@ -0,0 +1,177 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/charts"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/playerlist"
+	"github.com/thirdknife/scoutingapp/scoring"
+	base "github.com/thirdknife/scoutingapp/views"
+	"gorm.io/gorm"
+)
+
+// playerDetails is everything recorded about a single player, with the player's Score computed.
+type playerDetails struct {
+	Player *database.Player
+	// Profile is nil if the Scout hasn't recorded a PlayerAnalysis.
+	Profile  *database.PlayerAnalysis
+	Analyses []*database.AnalysisDetail
+}
+
+// averages returns the player's average rating of every attribute, ready for charting.
+func (d *playerDetails) averages() []charts.PlayerAverages {
+	return []charts.PlayerAverages{{Name: d.Player.Name, Averages: scoring.Averages(d.Analyses)}}
+}
+
+// loadPlayerDetails loads the player identified by the id route parameter. A missing or malformed ID is reported as
+// gorm.ErrRecordNotFound.
+func loadPlayerDetails(c echo.Context, db *gorm.DB) (*playerDetails, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return playerDetailsByID(db, id)
+}
+
+// playerDetailsByID loads the player with the given ID.
+func playerDetailsByID(db *gorm.DB, id uuid.UUID) (*playerDetails, error) {
+	player, err := database.PlayerByID(db, id)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := database.PlayerAnalysisFor(db, id)
+	if err != nil {
+		return nil, err
+	}
+	analyses, err := database.AnalysesForPlayer(db, id)
+	if err != nil {
+		return nil, err
+	}
+	var position database.PositionType
+	if profile != nil {
+		position = profile.Position
+	}
+	scoring.New(time.Now()).SetScore(player, position, analyses)
+	return &playerDetails{Player: player, Profile: profile, Analyses: analyses}, nil
+}
+
+// rankingEngine returns the scoring engine for the weight profile selected by the profile query parameter, along
+// with the selected profile. The profile is nil if none was selected. An unknown profile is reported as
+// gorm.ErrRecordNotFound.
+func rankingEngine(c echo.Context, db *gorm.DB) (*scoring.Engine, *database.WeightProfile, error) {
+	engine := scoring.New(time.Now())
+	if c.QueryParam("profile") == "" {
+		return engine, nil, nil
+	}
+	id, err := uuid.Parse(c.QueryParam("profile"))
+	if err != nil {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	profile, weights, err := database.WeightProfileByID(db, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	engine.Weights = weights
+	return engine, profile, nil
+}
+
+// rankedPlayers returns every player with their Score computed by the selected weight profile. When a profile is
+// selected, the players are ranked by it.
+func rankedPlayers(c echo.Context, db *gorm.DB) ([]*database.Player, *database.WeightProfile, error) {
+	engine, profile, err := rankingEngine(c, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	players, err := database.AllPlayers(db)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := engine.ScorePlayers(db, players); err != nil {
+		return nil, nil, err
+	}
+	if profile != nil {
+		scoring.Rank(players)
+	}
+	return players, profile, nil
+}
+
+// scoredSummaries returns the summary of every player with their Score computed by the weight profile selected in the
+// query parameters, along with that profile, which is nil if none was selected.
+func scoredSummaries(c echo.Context, db *gorm.DB) ([]*database.PlayerSummary, *database.WeightProfile, error) {
+	engine, profile, err := rankingEngine(c, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	summaries, err := database.PlayerSummaries(db)
+	if err != nil {
+		return nil, nil, err
+	}
+	players := make([]*database.Player, len(summaries))
+	for i, s := range summaries {
+		players[i] = s.Player
+	}
+	if err := engine.ScorePlayers(db, players); err != nil {
+		return nil, nil, err
+	}
+	return summaries, profile, nil
+}
+
+// playersPage returns the page of the players table described by the query parameters, along with the selected
+// weight profile, which is nil if none was selected.
+func playersPage(c echo.Context, db *gorm.DB) (playerlist.Page, *database.WeightProfile, error) {
+	summaries, profile, err := scoredSummaries(c, db)
+	if err != nil {
+		return playerlist.Page{}, nil, err
+	}
+	return playerlist.Apply(playerlist.ParseQuery(c.QueryParams()), summaries, time.Now()), profile, nil
+}
+
+// registerPlayerRoutes serves the pages about players.
+func registerPlayerRoutes(e *echo.Echo) {
+	e.GET("/players", func(c echo.Context) error {
+		db := scoutDB(c)
+		page, selected, err := playersPage(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching players: %w", err)
+		}
+		// htmx only needs the table when paging or sorting, unless it is restoring a page missing from its history cache.
+		// The response varies on the header so that caches, including the service worker's, keep both versions apart.
+		c.Response().Header().Add("Vary", "HX-Request")
+		if c.Request().Header.Get("HX-Request") == "true" && c.Request().Header.Get("HX-History-Restore-Request") != "true" {
+			return RenderComponent(c, http.StatusOK, base.PlayersTable(page))
+		}
+		profiles, err := database.AllWeightProfiles(db)
+		if err != nil {
+			return fmt.Errorf("fetching weight profiles: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.ListPlayers(page, profiles, selected))
+	})
+
+	e.GET("/players/:id", func(c echo.Context) error {
+		db := scoutDB(c)
+		details, err := loadPlayerDetails(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		radars := charts.GroupRadars(details.averages(), localizer(c))
+		return RenderComponent(c, http.StatusOK, base.PlayerProfile(details.Player, details.Profile, details.Analyses, radars))
+	})
+
+	e.GET("/players/:id/radar/:group", func(c echo.Context) error {
+		db := scoutDB(c)
+		group, ok := ratingGroupParam(c)
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotFound, localizer(c).T("error.unknownRatingGroup"))
+		}
+		details, err := loadPlayerDetails(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		return renderSVG(c, charts.GroupRadar(group, details.averages(), localizer(c)))
+	})
+}
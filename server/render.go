@@ -0,0 +1,24 @@
+package server
+
+import (
+	"net/url"
+
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+	base "github.com/thirdknife/scoutingapp/views"
+)
+
+func RenderComponent(c echo.Context, status int, cmp templ.Component) error {
+	// Headers must be set before WriteHeader, otherwise they are silently dropped.
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(status)
+
+	ctx := base.WithCSRFToken(c.Request().Context(), csrfToken(c))
+	return cmp.Render(ctx, c.Response().Writer)
+}
+
+// absoluteURL is the URL of path on this server as the request reached it, for links that are used outside the app.
+func absoluteURL(c echo.Context, path string) string {
+	u := url.URL{Scheme: c.Scheme(), Host: c.Request().Host, Path: path}
+	return u.String()
+}
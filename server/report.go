@@ -0,0 +1,57 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/report"
+)
+
+// maxReportAnalyses is the most analyses a report lists, which keeps reports printable.
+const maxReportAnalyses = 50
+
+// reportLatest reads how many of the latest analyses to list from the query string.
+func reportLatest(c echo.Context) int {
+	latest, err := strconv.Atoi(c.QueryParam("analyses"))
+	if err != nil {
+		return report.DefaultLatest
+	}
+	return max(0, min(latest, maxReportAnalyses))
+}
+
+// registerReportRoutes serves printable PDF reports about players, linked from the profile page and in the API.
+func registerReportRoutes(e *echo.Echo) {
+	handler := func(c echo.Context) error {
+		db := scoutDB(c)
+		details, err := loadPlayerDetails(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		scout, err := database.LocalScout(db)
+		if err != nil {
+			return fmt.Errorf("fetching scout: %w", err)
+		}
+		r := &report.Report{
+			Player:    details.Player,
+			Profile:   details.Profile,
+			Analyses:  details.Analyses,
+			Latest:    reportLatest(c),
+			Scout:     scout,
+			Generated: time.Now(),
+		}
+		// The report is drawn before responding so that failures are still shown as error pages.
+		var pdf bytes.Buffer
+		if err := report.WritePDF(&pdf, r, localizer(c)); err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "report-"+details.Player.ID.String()+".pdf"))
+		return c.Blob(http.StatusOK, "application/pdf", pdf.Bytes())
+	}
+	e.GET("/players/:id/report.pdf", handler)
+	e.GET("/api/players/:id/report.pdf", handler)
+}
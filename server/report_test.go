@@ -0,0 +1,46 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func TestPlayerReport(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	player := &database.Player{Name: "Ronaldinho"}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	if err := db.Create(&database.Analysis{PlayerID: player.ID, Date: "2024-01-01 15:00", Notes: "Magic."}).Error; err != nil {
+		t.Fatalf("Failed to create Analysis: %v", err)
+	}
+
+	for _, path := range []string{"/players/" + player.ID.String() + "/report.pdf", "/api/players/" + player.ID.String() + "/report.pdf?analyses=1"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s returned %d", path, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/pdf" {
+			t.Errorf("Expected GET %s to return a PDF, got Content-Type %q", path, got)
+		}
+		if got := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment") {
+			t.Errorf("Expected GET %s to be a download, got Content-Disposition %q", path, got)
+		}
+		if !strings.HasPrefix(rec.Body.String(), "%PDF-") {
+			t.Errorf("Expected GET %s to return a PDF document", path)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/players/"+uuid.NewString()+"/report.pdf", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Report of an unknown player returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
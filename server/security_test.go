@@ -0,0 +1,68 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	s, _ := createTestServer(t, io.Discard)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET / returned %d, want %d", rec.Code, http.StatusOK)
+	}
+	for header, want := range map[string]string{
+		echo.HeaderContentSecurityPolicy: contentSecurityPolicy,
+		echo.HeaderXContentTypeOptions:   "nosniff",
+		echo.HeaderXFrameOptions:         "DENY",
+		echo.HeaderXXSSProtection:        "1; mode=block",
+		echo.HeaderReferrerPolicy:        "same-origin",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCSRFRejectsUnsafeRequests(t *testing.T) {
+	s, _ := createTestServer(t, io.Discard)
+	cookie := csrfCookie(t, s)
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{name: "without a token", want: http.StatusBadRequest},
+		{name: "with another token", token: "forged", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"language": {"de"}}
+			if tt.token != "" {
+				form.Set("_csrf", tt.token)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/settings", strings.NewReader(form.Encode()))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("POST /settings %s returned %d, want %d", tt.name, rec.Code, tt.want)
+			}
+		})
+	}
+
+	// The rejected forms changed nothing.
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/settings", nil))
+	if strings.Contains(rec.Body.String(), `lang="de"`) {
+		t.Errorf("Expected the language to stay unchanged")
+	}
+}
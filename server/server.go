@@ -0,0 +1,203 @@
+// Package server serves the scouting app over HTTP. Each Scout's data is kept in their own database, which is opened
+// for the requests of that Scout.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/metrics"
+	"github.com/thirdknife/scoutingapp/replication"
+	base "github.com/thirdknife/scoutingapp/views"
+	"gorm.io/gorm"
+)
+
+// Config configures a Server. Zero values are replaced by defaults.
+type Config struct {
+	// Addr is the address Run listens on. Defaults to ":42069".
+	Addr string
+	// PublicDir is the directory of static files served under /public. Defaults to "public".
+	PublicDir string
+	// Scout is the hash identifying the Scout whose database is used.
+	// TODO: Identify the Scout of each request once they can log in.
+	Scout string
+	// ShutdownTimeout is how long requests in flight are given to finish when shutting down. Defaults to 10 seconds.
+	ShutdownTimeout time.Duration
+	// SyncPeers are the URLs of servers that may be synced with even though they are on a loopback or private
+	// address, e.g. "http://192.168.1.10:42069". Scouts can only sync with other servers if they are public.
+	SyncPeers []string
+	// TrustedProxies are the networks of reverse proxies whose X-Forwarded-For header is believed. Without any, the
+	// address of a client is the one the request came from.
+	TrustedProxies []*net.IPNet
+}
+
+func (c Config) withDefaults() Config {
+	if c.Addr == "" {
+		c.Addr = ":42069"
+	}
+	if c.PublicDir == "" {
+		c.PublicDir = "public"
+	}
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = 10 * time.Second
+	}
+	return c
+}
+
+// Deps are the dependencies of a Server.
+type Deps struct {
+	// Scouts opens the database of each Scout. The Server adds its metrics plugin and closes it when shutting down.
+	Scouts *database.Scouts
+	// Logger is where the Server logs to. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Server is the scouting app. It implements http.Handler so that it can be tested with httptest.
+type Server struct {
+	config  Config
+	deps    Deps
+	echo    *echo.Echo
+	metrics *metrics.Metrics
+	// imports keeps the files uploaded to be imported until their columns are mapped.
+	imports importUploads
+}
+
+// New returns a Server with all of its routes registered.
+func New(config Config, deps Deps) *Server {
+	config = config.withDefaults()
+	if deps.Logger == nil {
+		deps.Logger = slog.Default()
+	}
+	s := &Server{config: config, deps: deps, echo: echo.New(), metrics: metrics.New()}
+	s.metrics.OpenDatabases(deps.Scouts.Len)
+	deps.Scouts.Use(s.metrics.Plugin(), replication.Plugin())
+	e := s.echo
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = httpErrorHandler
+	e.IPExtractor = ipExtractor(config.TrustedProxies)
+
+	e.Use(middleware.RequestID())
+	e.Use(s.requestContextMiddleware())
+	e.Use(s.metricsMiddleware())
+	e.Use(requestLogMiddleware())
+	e.Static("/public", config.PublicDir)
+	e.Use(secureHeadersMiddleware())
+	e.Use(s.scoutMiddleware())
+	// The locale is chosen before checking CSRF tokens so that rejected forms are explained in the Scout's language.
+	e.Use(localeMiddleware())
+	e.Use(csrfMiddleware())
+
+	s.registerOperationalRoutes(e)
+	registerPlayerRoutes(e)
+	registerTrendRoutes(e)
+	registerReportRoutes(e)
+	registerShareRoutes(e)
+	s.registerTeamRoutes(e)
+	registerWeightProfileRoutes(e)
+	registerCompareRoutes(e)
+	registerDashboardRoutes(e)
+	registerDraftRoutes(e, config.PublicDir)
+	registerExportRoutes(e)
+	s.registerImportRoutes(e)
+	registerArchiveRoutes(e)
+	registerCalendarRoutes(e)
+	registerSettingsRoutes(e)
+	s.registerSyncRoutes(e)
+	registerAPIRoutes(e)
+
+	e.GET("/", func(c echo.Context) error {
+		return RenderComponent(c, http.StatusOK, base.Home())
+	})
+	return s
+}
+
+// ipExtractor returns how the address of a client is found. Headers naming it are only believed when they were set
+// by one of the trusted proxies, as clients can send them too.
+func ipExtractor(trusted []*net.IPNet) echo.IPExtractor {
+	if len(trusted) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, network := range trusted {
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// ServeHTTP serves a request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.echo.ServeHTTP(w, r)
+}
+
+// Run serves requests until ctx is done or the process receives SIGINT or SIGTERM, then shuts down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	s.deps.Logger.Info("serving", "addr", s.config.Addr)
+	go func() {
+		errs <- s.echo.Start(s.config.Addr)
+	}()
+	select {
+	case err := <-errs:
+		// The server failed to start, but databases may already have been opened.
+		return errors.Join(fmt.Errorf("failed to serve: %w", err), s.deps.Scouts.Close())
+	case <-ctx.Done():
+	}
+	s.deps.Logger.Info("shutting down", "timeout", s.config.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	return s.Shutdown(shutdownCtx)
+}
+
+// Shutdown stops accepting requests, waits for the requests in flight to finish until ctx is done, and then closes
+// every open Scout database.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+	if err := s.echo.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain requests: %w", err))
+	}
+	if err := s.deps.Scouts.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+const scoutDBKey = "scoutDB"
+
+// scoutMiddleware opens the database of the Scout making the request.
+func (s *Server) scoutMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isOperational(c) {
+				return next(c)
+			}
+			db, err := s.deps.Scouts.Open(s.config.Scout)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusServiceUnavailable).SetInternal(err)
+			}
+			// Queries log with the request's logger, see database.queryLogger.
+			c.Set(scoutDBKey, db.WithContext(c.Request().Context()))
+			return next(c)
+		}
+	}
+}
+
+// scoutDB is the database of the Scout making the request.
+func scoutDB(c echo.Context) *gorm.DB {
+	return c.Get(scoutDBKey).(*gorm.DB)
+}
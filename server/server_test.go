@@ -0,0 +1,195 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/logging"
+	"gorm.io/gorm"
+)
+
+const testScout = "TEST_SCOUT"
+
+// createTestServer returns a Server for testScout and its database. Logs are written to out.
+func createTestServer(t *testing.T, out io.Writer) (*Server, *gorm.DB) {
+	t.Helper()
+	return createConfiguredTestServer(t, out, Config{})
+}
+
+// createConfiguredTestServer is createTestServer with more configuration.
+func createConfiguredTestServer(t *testing.T, out io.Writer, config Config) (*Server, *gorm.DB) {
+	t.Helper()
+	config.Scout, config.PublicDir = testScout, "../public"
+	scouts := database.NewScouts(t.TempDir())
+	logger, err := logging.New(out, "text", slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	s := New(config, Deps{Scouts: scouts, Logger: logger})
+	t.Cleanup(func() { scouts.Close() })
+	db, err := scouts.Open(testScout)
+	if err != nil {
+		t.Fatalf("Failed to open Scout database: %v", err)
+	}
+	return s, db
+}
+
+// csrfCookie gets a CSRF token the way a browser would, from the cookie set on a page.
+func csrfCookie(t *testing.T, s *Server) *http.Cookie {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/settings", nil))
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "_csrf" {
+			return c
+		}
+	}
+	t.Fatalf("Expected a CSRF cookie")
+	return nil
+}
+
+func TestListPlayers(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	if err := db.Create(&database.Player{Name: "Ronaldinho"}).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/players", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /players returned %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !strings.Contains(rec.Body.String(), "Ronaldinho") {
+		t.Errorf("Expected the player to be listed, got %s", rec.Body.String())
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	s, _ := createTestServer(t, io.Discard)
+	form := url.Values{"language": {"de"}}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/settings", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden && rec.Code != http.StatusBadRequest {
+		t.Errorf("POST without a CSRF token returned %d, want it to be rejected", rec.Code)
+	}
+
+	cookie := csrfCookie(t, s)
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/settings", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.Header.Set(echo.HeaderXCSRFToken, cookie.Value)
+	req.AddCookie(cookie)
+	s.ServeHTTP(rec, req)
+	if rec.Code >= 400 {
+		t.Errorf("POST with a CSRF token returned %d", rec.Code)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() failed: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database: %v", err)
+	}
+	if err := sqlDB.Ping(); err == nil {
+		t.Errorf("Expected the Scout database to be closed")
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/players", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /players after Shutdown() returned %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestRequestLogging(t *testing.T) {
+	var out bytes.Buffer
+	s, _ := createTestServer(t, &out)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/players/"+uuid.NewString(), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("GET of a missing player returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	id := rec.Header().Get(echo.HeaderXRequestID)
+	if id == "" {
+		t.Fatalf("Expected a request ID header")
+	}
+	if !strings.Contains(rec.Body.String(), id) {
+		t.Errorf("Expected the error page to show request ID %s, got %s", id, rec.Body.String())
+	}
+	for _, want := range []string{"msg=request", "request_id=" + id, "scout=" + testScout, "status=404"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the log to contain %q, got %q", want, out.String())
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	s, _ := createTestServer(t, io.Discard)
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s returned %d, want %d: %s", path, rec.Code, http.StatusOK, rec.Body.String())
+		}
+		if len(rec.Result().Cookies()) > 0 {
+			t.Errorf("Expected GET %s not to set cookies", path)
+		}
+	}
+
+	if err := s.deps.Scouts.Close(); err != nil {
+		t.Fatalf("Failed to close databases: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /readyz without databases returned %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	var r readiness
+	if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+		t.Fatalf("Failed to decode readiness: %v", err)
+	}
+	if r.Checks["dataDir"] != "ok" || r.Checks["database"] == "ok" {
+		t.Errorf("Expected only the database check to fail, got %v", r.Checks)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	player := &database.Player{Name: "Ronaldinho"}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/players/"+player.ID.String(), nil))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`scoutingapp_request_duration_seconds_count{method="GET",route="/players/:id",status="200"} 1`,
+		`scoutingapp_records_created_total{table="players"} 1`,
+		`scoutingapp_open_databases 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected metrics to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/charts"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/share"
+	base "github.com/thirdknife/scoutingapp/views"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxShareDays is the longest a share link can be valid for.
+	maxShareDays = 90
+	// maxUserAgentLength limits how much of the User-Agent header is kept in the access log of a share link.
+	maxUserAgentLength = 255
+	// maxPasswordAttempts is how many wrong passwords a link accepts within passwordLockout before it refuses to
+	// check any more.
+	maxPasswordAttempts = 5
+	// passwordLockout is how long wrong passwords count towards maxPasswordAttempts.
+	passwordLockout = 15 * time.Minute
+)
+
+// registerShareRoutes serves the links that share a player with people who have no account, and the read-only
+// profile those links open.
+func registerShareRoutes(e *echo.Echo) {
+	e.GET("/players/:id/shares", func(c echo.Context) error {
+		return renderShareLinks(c, http.StatusOK, "")
+	})
+
+	e.POST("/players/:id/shares", func(c echo.Context) error {
+		db := scoutDB(c)
+		player, err := loadPlayer(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		days, err := strconv.Atoi(c.FormValue("days"))
+		if err != nil || days < 1 || days > maxShareDays {
+			return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T("share.invalidDays", maxShareDays))
+		}
+		hash, err := share.HashPassword(c.FormValue("password"))
+		if err != nil {
+			return fmt.Errorf("hashing password: %w", err)
+		}
+		link := &database.ShareLink{
+			PlayerID:     player.ID,
+			Recipient:    strings.TrimSpace(c.FormValue("recipient")),
+			ExpiresAt:    time.Now().AddDate(0, 0, days),
+			PasswordHash: hash,
+		}
+		if err := db.Create(link).Error; err != nil {
+			return fmt.Errorf("creating share link: %w", err)
+		}
+		return renderShareLinks(c, http.StatusOK, localizer(c).T("share.created"))
+	})
+
+	e.POST("/players/:id/shares/:link/revoke", func(c echo.Context) error {
+		db := scoutDB(c)
+		player, err := loadPlayer(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		id, err := uuid.Parse(c.Param("link"))
+		if err != nil {
+			return gorm.ErrRecordNotFound
+		}
+		link, err := database.ShareLinkByID(db, id)
+		if err != nil {
+			return fmt.Errorf("fetching share link: %w", err)
+		}
+		if link.PlayerID != player.ID {
+			return gorm.ErrRecordNotFound
+		}
+		if err := database.RevokeShareLink(db, link, time.Now()); err != nil {
+			return err
+		}
+		return renderShareLinks(c, http.StatusOK, localizer(c).T("share.revokedMessage"))
+	})
+
+	// The password of a link is posted back to the link itself.
+	e.GET("/shared/:token", openShareLink)
+	e.POST("/shared/:token", openShareLink)
+}
+
+// loadPlayer loads the player identified by the id route parameter, without the details of loadPlayerDetails.
+func loadPlayer(c echo.Context, db *gorm.DB) (*database.Player, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return database.PlayerByID(db, id)
+}
+
+// renderShareLinks renders the links that share the player identified by the id route parameter.
+func renderShareLinks(c echo.Context, status int, message string) error {
+	db := scoutDB(c)
+	player, err := loadPlayer(c, db)
+	if err != nil {
+		return fmt.Errorf("fetching player: %w", err)
+	}
+	key, err := database.ShareKey(db)
+	if err != nil {
+		return fmt.Errorf("fetching share key: %w", err)
+	}
+	links, err := database.ShareLinksForPlayer(db, player.ID)
+	if err != nil {
+		return fmt.Errorf("fetching share links: %w", err)
+	}
+	ids := make([]uuid.UUID, len(links))
+	for i, l := range links {
+		ids[i] = l.ID
+	}
+	accesses, err := database.ShareAccesses(db, ids)
+	if err != nil {
+		return fmt.Errorf("fetching share link accesses: %w", err)
+	}
+	now := time.Now()
+	rows := make([]base.ShareLinkRow, len(links))
+	for i, l := range links {
+		rows[i] = base.ShareLinkRow{
+			Link:     l,
+			URL:      absoluteURL(c, "/shared/"+share.Sign(key, l.ID, l.ExpiresAt)),
+			Active:   l.Active(now),
+			Accesses: accesses[l.ID],
+		}
+	}
+	return RenderComponent(c, status, base.ShareLinks(player, rows, message))
+}
+
+// openShareLink shows the player shared by the token route parameter, after asking for the link's password if it
+// has one. Every attempt to open a valid link is recorded in its access log.
+func openShareLink(c echo.Context) error {
+	db := scoutDB(c)
+	token := c.Param("token")
+	key, err := database.ShareKey(db)
+	if err != nil {
+		return fmt.Errorf("fetching share key: %w", err)
+	}
+	id, expires, err := share.Verify(key, token)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound).SetInternal(err)
+	}
+	link, err := database.ShareLinkByID(db, id)
+	if err != nil {
+		return fmt.Errorf("fetching share link: %w", err)
+	}
+	// The page must not outlive the link in caches, nor be found through search engines.
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("X-Robots-Tag", "noindex")
+
+	now := time.Now()
+	switch {
+	case link.RevokedAt != nil:
+		return logShareAccess(c, link, database.ShareRevoked, echo.NewHTTPError(http.StatusGone))
+	case !link.Active(now) || !now.Before(expires):
+		return logShareAccess(c, link, database.ShareExpired, echo.NewHTTPError(http.StatusGone))
+	}
+	if link.PasswordHash != "" {
+		if c.Request().Method != http.MethodPost {
+			return RenderComponent(c, http.StatusOK, base.SharePassword(token, false))
+		}
+		attempts, err := database.CountShareAccesses(db, link.ID, database.ShareWrongPassword, now.Add(-passwordLockout))
+		if err != nil {
+			return err
+		}
+		if attempts >= maxPasswordAttempts {
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(passwordLockout.Seconds())))
+			return logShareAccess(c, link, database.ShareLocked, echo.NewHTTPError(http.StatusTooManyRequests,
+				localizer(c).T("share.tooManyAttempts", int(passwordLockout.Minutes()))))
+		}
+		if !share.CheckPassword(link.PasswordHash, c.FormValue("password")) {
+			if err := logShareAccess(c, link, database.ShareWrongPassword, nil); err != nil {
+				return err
+			}
+			return RenderComponent(c, http.StatusUnauthorized, base.SharePassword(token, true))
+		}
+	}
+
+	details, err := playerDetailsByID(db, link.PlayerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusGone).SetInternal(err)
+		}
+		return fmt.Errorf("fetching player: %w", err)
+	}
+	scout, err := database.LocalScout(db)
+	if err != nil {
+		return fmt.Errorf("fetching scout: %w", err)
+	}
+	if err := logShareAccess(c, link, database.ShareViewed, nil); err != nil {
+		return err
+	}
+	radars := charts.GroupRadars(details.averages(), localizer(c))
+	return RenderComponent(c, http.StatusOK,
+		base.SharedProfile(details.Player, details.Profile, details.Analyses, radars, scout, link.ExpiresAt))
+}
+
+// logShareAccess records the outcome of an attempt to open the link, and then returns result. The IP address is the
+// one found by ipExtractor.
+func logShareAccess(c echo.Context, link *database.ShareLink, outcome database.ShareOutcome, result error) error {
+	userAgent := c.Request().UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	access := &database.ShareAccess{ShareLinkID: link.ID, Outcome: outcome, IP: c.RealIP(), UserAgent: userAgent}
+	if err := database.LogShareAccess(scoutDB(c), access); err != nil {
+		return err
+	}
+	return result
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/share"
+)
+
+func TestShareLink(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	player := &database.Player{Name: "Shared"}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	profile := &database.PlayerAnalysis{PlayerID: player.ID, Club: "Rovers", Telephone: "555-0100"}
+	if err := db.Create(profile).Error; err != nil {
+		t.Fatalf("Failed to create PlayerAnalysis: %v", err)
+	}
+	sharesPath := "/players/" + player.ID.String() + "/shares"
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		cookie := csrfCookie(t, s)
+		form.Set("_csrf", cookie.Value)
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(sharesPath, url.Values{"days": {"1000"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("Creating a link valid for too long returned %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	rec := post(sharesPath, url.Values{"days": {"7"}, "recipient": {"Agent"}, "password": {"secret"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Creating a link returned %d: %s", rec.Code, rec.Body.String())
+	}
+	match := regexp.MustCompile(`https?://[^/"]+(/shared/[^"]+)`).FindStringSubmatch(rec.Body.String())
+	if match == nil {
+		t.Fatalf("Expected the page to show the link, got %s", rec.Body.String())
+	}
+	sharedPath := match[1]
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, sharedPath, nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "Rovers") {
+		t.Errorf("Expected the link to ask for its password first, got %d", rec.Code)
+	}
+	if rec := post(sharedPath, url.Values{"password": {"guess"}}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Opening the link with a wrong password returned %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	rec = post(sharedPath, url.Values{"password": {"secret"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Opening the link returned %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Rovers") || strings.Contains(body, "555-0100") || strings.Contains(body, `href="/settings"`) {
+		t.Errorf("Expected a read-only profile without contact details, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/shared/forged.token", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Opening a forged link returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	links, err := database.ShareLinksForPlayer(db, player.ID)
+	if err != nil || len(links) != 1 {
+		t.Fatalf("Expected 1 link, got %d (%v)", len(links), err)
+	}
+	if rec := post(sharesPath+"/"+links[0].ID.String()+"/revoke", url.Values{}); rec.Code != http.StatusOK {
+		t.Fatalf("Revoking the link returned %d", rec.Code)
+	}
+	if rec := post(sharedPath, url.Values{"password": {"secret"}}); rec.Code != http.StatusGone {
+		t.Errorf("Opening a revoked link returned %d, want %d", rec.Code, http.StatusGone)
+	}
+
+	accesses, err := database.ShareAccesses(db, []uuid.UUID{links[0].ID})
+	if err != nil {
+		t.Fatalf("ShareAccesses() failed: %v", err)
+	}
+	var outcomes []string
+	for _, a := range accesses[links[0].ID] {
+		outcomes = append(outcomes, string(a.Outcome))
+	}
+	if got, want := strings.Join(outcomes, ","), "revoked,viewed,wrongPassword"; got != want {
+		t.Errorf("Expected access log %s, got %s", want, got)
+	}
+}
+
+func TestSharedProfileHidesPrivateDetails(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	player := &database.Player{Name: "Shared"}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	profile := &database.PlayerAnalysis{PlayerID: player.ID, Club: "Rovers", ManagerName: "Mo Manager", Telephone: "555-0100", Notes: "Asks for too much money"}
+	if err := db.Create(profile).Error; err != nil {
+		t.Fatalf("Failed to create PlayerAnalysis: %v", err)
+	}
+
+	cookie := csrfCookie(t, s)
+	form := url.Values{"_csrf": {cookie.Value}, "days": {"7"}}
+	req := httptest.NewRequest(http.MethodPost, "/players/"+player.ID.String()+"/shares", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	match := regexp.MustCompile(`https?://[^/"]+(/shared/[^"]+)`).FindStringSubmatch(rec.Body.String())
+	if match == nil {
+		t.Fatalf("Expected the page to show the link, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, match[1], nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Rovers") {
+		t.Fatalf("Expected the shared profile, got %d: %s", rec.Code, body)
+	}
+	for _, private := range []string{"Mo Manager", "555-0100", "Asks for too much money"} {
+		if strings.Contains(body, private) {
+			t.Errorf("Expected %q to be left out of the shared profile", private)
+		}
+	}
+
+	// The Scout still sees everything on their own profile.
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/players/"+player.ID.String(), nil))
+	for _, private := range []string{"Mo Manager", "555-0100", "Asks for too much money"} {
+		if !strings.Contains(rec.Body.String(), private) {
+			t.Errorf("Expected %q on the Scout's own profile", private)
+		}
+	}
+}
+
+func TestSharePasswordAttemptsAreLimited(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	player := &database.Player{Name: "Shared"}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	hash, err := share.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword() failed: %v", err)
+	}
+	link := &database.ShareLink{PlayerID: player.ID, ExpiresAt: time.Now().Add(time.Hour), PasswordHash: hash}
+	if err := db.Create(link).Error; err != nil {
+		t.Fatalf("Failed to create ShareLink: %v", err)
+	}
+	key, err := database.ShareKey(db)
+	if err != nil {
+		t.Fatalf("ShareKey() failed: %v", err)
+	}
+	sharedPath := "/shared/" + share.Sign(key, link.ID, link.ExpiresAt)
+
+	open := func(password string) int {
+		t.Helper()
+		cookie := csrfCookie(t, s)
+		form := url.Values{"_csrf": {cookie.Value}, "password": {password}}
+		req := httptest.NewRequest(http.MethodPost, sharedPath, strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for i := 0; i < maxPasswordAttempts; i++ {
+		if code := open("guess"); code != http.StatusUnauthorized {
+			t.Fatalf("Wrong password %d returned %d, want %d", i+1, code, http.StatusUnauthorized)
+		}
+	}
+	if code := open("secret"); code != http.StatusTooManyRequests {
+		t.Errorf("Opening the link after too many wrong passwords returned %d, want %d", code, http.StatusTooManyRequests)
+	}
+
+	// Attempts older than the lockout no longer count.
+	if err := db.Model(&database.ShareAccess{}).Where("share_link_id = ?", link.ID).
+		Update("created_at", time.Now().Add(-passwordLockout-time.Minute)).Error; err != nil {
+		t.Fatalf("Failed to age the access log: %v", err)
+	}
+	if code := open("secret"); code != http.StatusOK {
+		t.Errorf("Opening the link after the lockout returned %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestShareAccessIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("192.0.2.0/24")
+	if err != nil {
+		t.Fatalf("Failed to parse network: %v", err)
+	}
+	tests := []struct {
+		name    string
+		proxies []*net.IPNet
+		want    string
+	}{
+		// httptest requests come from 192.0.2.1.
+		{name: "direct", want: "192.0.2.1"},
+		{name: "trusted proxy", proxies: []*net.IPNet{proxies}, want: "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db := createConfiguredTestServer(t, io.Discard, Config{TrustedProxies: tt.proxies})
+			player := &database.Player{Name: "Shared"}
+			if err := db.Create(player).Error; err != nil {
+				t.Fatalf("Failed to create Player: %v", err)
+			}
+			link := &database.ShareLink{PlayerID: player.ID, ExpiresAt: time.Now().Add(time.Hour)}
+			if err := db.Create(link).Error; err != nil {
+				t.Fatalf("Failed to create ShareLink: %v", err)
+			}
+			key, err := database.ShareKey(db)
+			if err != nil {
+				t.Fatalf("ShareKey() failed: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/shared/"+share.Sign(key, link.ID, link.ExpiresAt), nil)
+			req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+			req.Header.Set(echo.HeaderXRealIP, "203.0.113.8")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Opening the link returned %d", rec.Code)
+			}
+
+			accesses, err := database.ShareAccesses(db, []uuid.UUID{link.ID})
+			if err != nil {
+				t.Fatalf("ShareAccesses() failed: %v", err)
+			}
+			if got := accesses[link.ID]; len(got) != 1 || got[0].IP != tt.want {
+				t.Errorf("Expected 1 access from %s, got %+v", tt.want, got)
+			}
+		})
+	}
+}
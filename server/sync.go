@@ -0,0 +1,157 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/replication"
+	base "github.com/thirdknife/scoutingapp/views"
+	"gorm.io/gorm"
+)
+
+// maxPushSize limits the size of the changes a replica pushes at a time.
+const maxPushSize = "16M"
+
+// syncStatus describes the server's side of syncing, under /api/sync.
+type syncStatus struct {
+	Replica string `json:"replica"`
+	// Cursor is the Seq of the server's last change.
+	Cursor int64 `json:"cursor"`
+}
+
+// isSyncPeer reports whether server is one of the configured SyncPeers.
+func (s *Server) isSyncPeer(server string) bool {
+	for _, peer := range s.config.SyncPeers {
+		if strings.TrimSuffix(peer, "/") == strings.TrimSuffix(server, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// registerSyncRoutes serves the changes other replicas of the Scout's database pull and push, see package
+// replication, along with the page to sync this database with a server and decide on conflicts.
+func (s *Server) registerSyncRoutes(e *echo.Echo) {
+	e.GET("/api/sync", func(c echo.Context) error {
+		db := scoutDB(c)
+		state, err := database.LocalSyncState(db)
+		if err != nil {
+			return fmt.Errorf("fetching sync state: %w", err)
+		}
+		status := syncStatus{Replica: state.Replica}
+		if err := db.Model(&database.Change{}).Select("COALESCE(MAX(seq), 0)").Scan(&status.Cursor).Error; err != nil {
+			return fmt.Errorf("fetching sync cursor: %w", err)
+		}
+		return c.JSON(http.StatusOK, status)
+	})
+
+	e.GET("/api/sync/changes", func(c echo.Context) error {
+		replica := c.QueryParam("replica")
+		since, err := strconv.ParseInt(c.QueryParam("since"), 10, 64)
+		if replica == "" || err != nil || since < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "replica and since are required")
+		}
+		batch, err := replication.Pull(scoutDB(c), replica, since, replication.BatchSize)
+		if err != nil {
+			return fmt.Errorf("pulling changes: %w", err)
+		}
+		return c.JSON(http.StatusOK, batch)
+	})
+
+	e.POST("/api/sync/changes", func(c echo.Context) error {
+		var push replication.Push
+		if err := c.Bind(&push); err != nil || push.Replica == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "malformed changes")
+		}
+		if len(push.Records) > replication.BatchSize {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "too many changes")
+		}
+		result, err := replication.Receive(scoutDB(c), &push, time.Now())
+		if errors.Is(err, replication.ErrUnknownTable) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		if err != nil {
+			return fmt.Errorf("receiving changes: %w", err)
+		}
+		return c.JSON(http.StatusOK, result)
+	}, middleware.BodyLimit(maxPushSize))
+
+	e.GET("/sync", func(c echo.Context) error {
+		return renderSync(c, http.StatusOK, "")
+	})
+
+	e.POST("/sync", func(c echo.Context) error {
+		db := scoutDB(c)
+		l := localizer(c)
+		server := c.FormValue("server")
+		client, err := replication.NewClient(server, s.isSyncPeer(server))
+		if errors.Is(err, replication.ErrForbiddenServer) {
+			return renderSync(c, http.StatusBadRequest, l.T("sync.forbiddenServer"))
+		}
+		if err != nil {
+			return renderSync(c, http.StatusBadRequest, l.T("sync.invalidServer"))
+		}
+		state, err := database.LocalSyncState(db)
+		if err != nil {
+			return fmt.Errorf("fetching sync state: %w", err)
+		}
+		if state.Server != server {
+			// The cursors only mean something to the server they came from.
+			err := db.Model(state).Updates(map[string]any{"server": server, "pulled": 0, "pushed": 0}).Error
+			if err != nil {
+				return fmt.Errorf("saving sync server: %w", err)
+			}
+		}
+		result, err := replication.Sync(c.Request().Context(), db, client, time.Now())
+		if errors.Is(err, replication.ErrForbiddenServer) {
+			// The server's name resolved to a local address.
+			return renderSync(c, http.StatusBadRequest, l.T("sync.forbiddenServer"))
+		}
+		if err != nil {
+			return renderSync(c, http.StatusBadGateway, l.T("sync.failed", err.Error()))
+		}
+		return renderSync(c, http.StatusOK, l.T("sync.done", result.Pushed, result.Pulled, result.Conflicts))
+	})
+
+	e.POST("/sync/conflicts/:id/:decision", func(c echo.Context) error {
+		db := scoutDB(c)
+		decision := c.Param("decision")
+		if decision != "keep" && decision != "restore" {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return gorm.ErrRecordNotFound
+		}
+		conflict, err := database.SyncConflictByID(db, id)
+		if err != nil {
+			return err
+		}
+		if err := replication.Resolve(db, conflict, decision == "restore", time.Now()); err != nil {
+			return fmt.Errorf("resolving conflict: %w", err)
+		}
+		return renderSync(c, http.StatusOK, localizer(c).T("sync.resolved"))
+	})
+}
+
+// renderSync renders the sync page with the conflicts that await a decision and message above the form.
+func renderSync(c echo.Context, status int, message string) error {
+	db := scoutDB(c)
+	state, err := database.LocalSyncState(db)
+	if err != nil {
+		return fmt.Errorf("fetching sync state: %w", err)
+	}
+	conflicts, err := database.SyncConflicts(db)
+	if err != nil {
+		return fmt.Errorf("fetching sync conflicts: %w", err)
+	}
+	return RenderComponent(c, status, base.Sync(state, conflicts, message))
+}
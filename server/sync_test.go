@@ -0,0 +1,78 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func TestSync(t *testing.T) {
+	central, centralDB := createTestServer(t, io.Discard)
+	remote := httptest.NewServer(central)
+	defer remote.Close()
+	// The test server listens on a loopback address, which can only be synced with as a configured peer.
+	laptop, laptopDB := createConfiguredTestServer(t, io.Discard, Config{SyncPeers: []string{remote.URL + "/"}})
+
+	online := &database.Player{Name: "Online"}
+	if err := centralDB.Create(online).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	offline := &database.Player{Name: "Offline"}
+	if err := laptopDB.Create(offline).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+
+	post := func(s *Server, path string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		cookie := csrfCookie(t, s)
+		form.Set("_csrf", cookie.Value)
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, server := range []string{"not a server", "file:///etc/passwd", "http://127.0.0.1:9", "http://localhost", "http://[::1]", "http://169.254.169.254"} {
+		if rec := post(laptop, "/sync", url.Values{"server": {server}}); rec.Code != http.StatusBadRequest {
+			t.Errorf("Syncing with %s returned %d, want %d", server, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if rec := post(central, "/sync", url.Values{"server": {remote.URL}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("Syncing with a local server that isn't a peer returned %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := post(laptop, "/sync", url.Values{"server": {remote.URL}}); rec.Code != http.StatusOK {
+		t.Fatalf("Syncing returned %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, player := range []*database.Player{online, offline} {
+		if _, err := database.PlayerByID(centralDB, player.ID); err != nil {
+			t.Errorf("Expected %s on the server: %v", player.Name, err)
+		}
+		if _, err := database.PlayerByID(laptopDB, player.ID); err != nil {
+			t.Errorf("Expected %s on the laptop: %v", player.Name, err)
+		}
+	}
+
+	conflict := &database.SyncConflict{Table: "players", RowID: online.ID, Kept: `{"Name":"Online"}`}
+	if err := centralDB.Create(conflict).Error; err != nil {
+		t.Fatalf("Failed to create SyncConflict: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	central.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sync", nil))
+	if !strings.Contains(rec.Body.String(), conflict.ID.String()) {
+		t.Errorf("Expected the conflict in the inbox")
+	}
+	if rec := post(central, "/sync/conflicts/"+conflict.ID.String()+"/keep", url.Values{}); rec.Code != http.StatusOK {
+		t.Errorf("Keeping a conflict returned %d", rec.Code)
+	}
+	if conflicts, err := database.SyncConflicts(centralDB); err != nil || len(conflicts) != 0 {
+		t.Errorf("Expected the conflict to be resolved, got %d: %v", len(conflicts), err)
+	}
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/team"
+	base "github.com/thirdknife/scoutingapp/views"
+	"gorm.io/gorm"
+)
+
+// registerTeamRoutes serves the Teams the Scout is a member of, their workspaces, and publishing players to them.
+func (s *Server) registerTeamRoutes(e *echo.Echo) {
+	e.GET("/teams", func(c echo.Context) error {
+		teams, err := s.teamsDB(c)
+		if err != nil {
+			return err
+		}
+		list, err := database.TeamsOf(teams, s.config.Scout)
+		if err != nil {
+			return fmt.Errorf("fetching teams: %w", err)
+		}
+		scout, err := database.LocalScout(scoutDB(c))
+		if err != nil {
+			return fmt.Errorf("fetching scout: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.ListTeams(s.config.Scout, scout.Username, list))
+	})
+
+	e.POST("/teams", func(c echo.Context) error {
+		teams, err := s.teamsDB(c)
+		if err != nil {
+			return err
+		}
+		name, member := strings.TrimSpace(c.FormValue("name")), strings.TrimSpace(c.FormValue("member"))
+		if name == "" || member == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T("teams.missingName"))
+		}
+		created, err := database.CreateTeam(teams, name, s.config.Scout, member)
+		if err != nil {
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, "/teams/"+created.ID.String())
+	})
+
+	e.GET("/teams/:team", func(c echo.Context) error {
+		return s.renderTeam(c, http.StatusOK, "")
+	})
+
+	e.POST("/teams/:team/members", func(c echo.Context) error {
+		teams, t, err := s.ownedTeam(c)
+		if err != nil {
+			return err
+		}
+		scout, name := strings.TrimSpace(c.FormValue("scout")), strings.TrimSpace(c.FormValue("name"))
+		if scout == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T("teams.missingMember"))
+		}
+		if name == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T("teams.missingName"))
+		}
+		err = database.AddTeamMember(teams, t.ID, scout, name)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return s.renderTeam(c, http.StatusConflict, localizer(c).T("teams.alreadyMember", name))
+		}
+		if err != nil {
+			return err
+		}
+		return s.renderTeam(c, http.StatusOK, localizer(c).T("teams.memberAdded", name))
+	})
+
+	e.POST("/teams/:team/members/:scout/remove", func(c echo.Context) error {
+		teams, t, err := s.ownedTeam(c)
+		if err != nil {
+			return err
+		}
+		if err := database.RemoveTeamMember(teams, t.ID, c.Param("scout")); err != nil {
+			return err
+		}
+		return s.renderTeam(c, http.StatusOK, localizer(c).T("teams.memberRemoved"))
+	})
+
+	e.GET("/players/:id/publish", func(c echo.Context) error {
+		teams, err := s.teamsDB(c)
+		if err != nil {
+			return err
+		}
+		details, err := loadPlayerDetails(c, scoutDB(c))
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		list, err := database.TeamsOf(teams, s.config.Scout)
+		if err != nil {
+			return fmt.Errorf("fetching teams: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.PublishPlayer(details.Player, list, details.Analyses))
+	})
+
+	e.POST("/players/:id/publish", func(c echo.Context) error {
+		db := scoutDB(c)
+		playerID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return gorm.ErrRecordNotFound
+		}
+		t, membership, err := s.memberTeam(c, c.FormValue("team"))
+		if err != nil {
+			return err
+		}
+		form, err := c.FormParams()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+		}
+		var analysisIDs []uuid.UUID
+		for _, value := range form["analyses"] {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T("teams.invalidAnalysis"))
+			}
+			analysisIDs = append(analysisIDs, id)
+		}
+		workspace, err := s.workspaceDB(c, t)
+		if err != nil {
+			return err
+		}
+		by := team.Publisher{Scout: s.config.Scout, Name: membership.Name}
+		_, err = team.Publish(db, workspace, by, playerID, analysisIDs, time.Now())
+		if errors.Is(err, team.ErrPublishedByOther) {
+			return echo.NewHTTPError(http.StatusConflict, localizer(c).T("teams.publishedByOther")).SetInternal(err)
+		}
+		if err != nil {
+			return fmt.Errorf("publishing player: %w", err)
+		}
+		return c.Redirect(http.StatusSeeOther, "/teams/"+t.ID.String())
+	})
+}
+
+// teamsDB is the database of every Team, see database.Scouts.Teams.
+func (s *Server) teamsDB(c echo.Context) (*gorm.DB, error) {
+	db, err := s.deps.Scouts.Teams()
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusServiceUnavailable).SetInternal(err)
+	}
+	return db.WithContext(c.Request().Context()), nil
+}
+
+// workspaceDB is the database of a Team's workspace.
+func (s *Server) workspaceDB(c echo.Context, t *database.Team) (*gorm.DB, error) {
+	db, err := s.deps.Scouts.Open(t.Workspace())
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusServiceUnavailable).SetInternal(err)
+	}
+	return db.WithContext(c.Request().Context()), nil
+}
+
+// memberTeam loads the Team with the given ID along with the Scout's membership. Teams the Scout isn't a member of
+// are reported as not found.
+func (s *Server) memberTeam(c echo.Context, id string) (*database.Team, *database.Membership, error) {
+	teams, err := s.teamsDB(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	teamID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	t, membership, err := database.TeamFor(teams, teamID, s.config.Scout)
+	if errors.Is(err, database.ErrNotMember) {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound).SetInternal(err)
+	}
+	return t, membership, err
+}
+
+// ownedTeam loads the Team of the team route parameter, which the Scout must own, along with the database of Teams.
+func (s *Server) ownedTeam(c echo.Context) (*gorm.DB, *database.Team, error) {
+	t, membership, err := s.memberTeam(c, c.Param("team"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if membership.Role != database.TeamOwner {
+		return nil, nil, echo.NewHTTPError(http.StatusForbidden).SetInternal(database.ErrNotMember)
+	}
+	teams, err := s.teamsDB(c)
+	return teams, t, err
+}
+
+// renderTeam renders the workspace of the Team of the team route parameter.
+func (s *Server) renderTeam(c echo.Context, status int, message string) error {
+	t, membership, err := s.memberTeam(c, c.Param("team"))
+	if err != nil {
+		return err
+	}
+	teams, err := s.teamsDB(c)
+	if err != nil {
+		return err
+	}
+	members, err := database.TeamMembers(teams, t.ID)
+	if err != nil {
+		return fmt.Errorf("fetching members: %w", err)
+	}
+	workspace, err := s.workspaceDB(c, t)
+	if err != nil {
+		return err
+	}
+	players, err := teamPlayers(workspace)
+	if err != nil {
+		return fmt.Errorf("fetching team players: %w", err)
+	}
+	return RenderComponent(c, status, base.TeamWorkspace(t, membership, members, players, message))
+}
+
+// teamPlayers lists every player in a workspace by name, crediting whoever published them and their analyses.
+func teamPlayers(workspace *gorm.DB) ([]base.TeamPlayerRow, error) {
+	players, err := database.AllPlayers(workspace)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := database.PlayerAnalysesByPlayer(workspace)
+	if err != nil {
+		return nil, err
+	}
+	playerPublications, err := database.Publications(workspace, database.PublishedPlayer)
+	if err != nil {
+		return nil, err
+	}
+	analysisPublications, err := database.Publications(workspace, database.PublishedAnalysis)
+	if err != nil {
+		return nil, err
+	}
+	var analyses []*database.Analysis
+	if err := workspace.Find(&analyses).Error; err != nil {
+		return nil, fmt.Errorf("retrieving Analyses failed: %w", err)
+	}
+	counts := map[uuid.UUID]int{}
+	scouts := map[uuid.UUID]map[string]bool{}
+	for _, a := range analyses {
+		counts[a.PlayerID]++
+		if p, ok := analysisPublications[a.ID]; ok {
+			if scouts[a.PlayerID] == nil {
+				scouts[a.PlayerID] = map[string]bool{}
+			}
+			scouts[a.PlayerID][p.ScoutName] = true
+		}
+	}
+
+	rows := make([]base.TeamPlayerRow, len(players))
+	for i, p := range players {
+		var names []string
+		for name := range scouts[p.ID] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rows[i] = base.TeamPlayerRow{
+			Player:      p,
+			Profile:     profiles[p.ID],
+			Publication: playerPublications[p.ID],
+			Analyses:    counts[p.ID],
+			Scouts:      names,
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Player.Name < rows[j].Player.Name })
+	return rows, nil
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+)
+
+func TestTeamWorkspace(t *testing.T) {
+	s, db := createTestServer(t, io.Discard)
+	player := &database.Player{Name: "Published"}
+	if err := db.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	analysis := &database.Analysis{PlayerID: player.ID, Date: "2024-01-01 15:00"}
+	if err := db.Create(analysis).Error; err != nil {
+		t.Fatalf("Failed to create Analysis: %v", err)
+	}
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		cookie := csrfCookie(t, s)
+		form.Set("_csrf", cookie.Value)
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := post("/teams", url.Values{"name": {"Rovers"}, "member": {"Ann"}})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("Creating a team returned %d: %s", rec.Code, rec.Body.String())
+	}
+	teamPath := rec.Header().Get(echo.HeaderLocation)
+	if rec := get("/teams"); !strings.Contains(rec.Body.String(), teamPath) {
+		t.Errorf("Expected the team to be listed")
+	}
+
+	if rec := post(teamPath+"/members", url.Values{"scout": {""}, "name": {"Bo"}}); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Scout ID") {
+		t.Errorf("Adding a member without a scout ID returned %d, want %d asking for it", rec.Code, http.StatusBadRequest)
+	}
+	if rec := post(teamPath+"/members", url.Values{"scout": {"bo"}, "name": {"Bo"}}); rec.Code != http.StatusOK {
+		t.Fatalf("Adding a member returned %d", rec.Code)
+	}
+	if rec := post(teamPath+"/members", url.Values{"scout": {"bo"}, "name": {"Bo"}}); rec.Code != http.StatusConflict {
+		t.Errorf("Adding a member twice returned %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	teamID := strings.TrimPrefix(teamPath, "/teams/")
+	publishPath := "/players/" + player.ID.String() + "/publish"
+	if rec := get(publishPath); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), analysis.ID.String()) {
+		t.Errorf("Expected the publish form to offer the analysis, got %d", rec.Code)
+	}
+	rec = post(publishPath, url.Values{"team": {teamID}, "analyses": {analysis.ID.String()}})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("Publishing returned %d: %s", rec.Code, rec.Body.String())
+	}
+	body := get(teamPath).Body.String()
+	for _, want := range []string{"Published", "Bo", "1 by Ann"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the workspace to show %q, got %s", want, body)
+		}
+	}
+
+	// Teams the Scout isn't a member of can't be seen or published to.
+	teams, err := s.deps.Scouts.Teams()
+	if err != nil {
+		t.Fatalf("Teams() failed: %v", err)
+	}
+	other, err := database.CreateTeam(teams, "United", "bo", "Bo")
+	if err != nil {
+		t.Fatalf("CreateTeam() failed: %v", err)
+	}
+	if rec := get("/teams/" + other.ID.String()); rec.Code != http.StatusNotFound {
+		t.Errorf("GET a team of others returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := post(publishPath, url.Values{"team": {other.ID.String()}}); rec.Code != http.StatusNotFound {
+		t.Errorf("Publishing to a team of others returned %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if err := database.AddTeamMember(teams, other.ID, testScout, "Ann"); err != nil {
+		t.Fatalf("AddTeamMember() failed: %v", err)
+	}
+	if rec := post("/teams/"+other.ID.String()+"/members", url.Values{"scout": {"cy"}, "name": {"Cy"}}); rec.Code != http.StatusForbidden {
+		t.Errorf("Adding a member as a member returned %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
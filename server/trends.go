@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/charts"
+	"github.com/thirdknife/scoutingapp/database"
+	base "github.com/thirdknife/scoutingapp/views"
+)
+
+const (
+	// defaultTrendWindow is the number of analyses in the moving average unless the scout picks another.
+	defaultTrendWindow = 3
+	maxTrendWindow     = 20
+)
+
+// trendWindow reads the moving average window from the query string.
+func trendWindow(c echo.Context) int {
+	window, err := strconv.Atoi(c.QueryParam("window"))
+	if err != nil {
+		return defaultTrendWindow
+	}
+	return max(1, min(window, maxTrendWindow))
+}
+
+// registerTrendRoutes serves the charts of how a player's ratings changed over time.
+func registerTrendRoutes(e *echo.Echo) {
+	e.GET("/players/:id/trends", func(c echo.Context) error {
+		db := scoutDB(c)
+		details, err := loadPlayerDetails(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		form := base.TrendsForm{Attribute: c.QueryParam("attribute"), Window: trendWindow(c)}
+
+		var attributeTrend *charts.Trend
+		if form.Attribute != "" {
+			attribute, err := database.ParseAttribute(form.Attribute)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, localizer(c).T("error.unknownAttribute"))
+			}
+			attributeTrend = charts.AttributeTrend(attribute, details.Analyses, form.Window, localizer(c))
+		}
+		var groupTrends []*charts.Trend
+		for _, g := range charts.RatedGroups(details.averages()) {
+			groupTrends = append(groupTrends, charts.GroupTrend(g, details.Analyses, form.Window, localizer(c)))
+		}
+		return RenderComponent(c, http.StatusOK, base.PlayerTrends(details.Player, form, attributeTrend, groupTrends))
+	})
+
+	e.GET("/players/:id/trend/group/:group", func(c echo.Context) error {
+		db := scoutDB(c)
+		group, ok := ratingGroupParam(c)
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotFound, localizer(c).T("error.unknownRatingGroup"))
+		}
+		details, err := loadPlayerDetails(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		return renderSVG(c, charts.GroupTrend(group, details.Analyses, trendWindow(c), localizer(c)))
+	})
+
+	e.GET("/players/:id/trend/attribute/:attribute", func(c echo.Context) error {
+		db := scoutDB(c)
+		attribute, err := database.ParseAttribute(c.Param("attribute"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusNotFound, localizer(c).T("error.unknownAttribute"))
+		}
+		details, err := loadPlayerDetails(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching player: %w", err)
+		}
+		return renderSVG(c, charts.AttributeTrend(attribute, details.Analyses, trendWindow(c), localizer(c)))
+	})
+}
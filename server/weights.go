@@ -0,0 +1,117 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/i18n"
+	base "github.com/thirdknife/scoutingapp/views"
+	"gorm.io/gorm"
+)
+
+// registerWeightProfileRoutes serves the pages for managing weight profiles.
+func registerWeightProfileRoutes(e *echo.Echo) {
+	e.GET("/weight-profiles", func(c echo.Context) error {
+		db := scoutDB(c)
+		profiles, err := database.AllWeightProfiles(db)
+		if err != nil {
+			return fmt.Errorf("fetching weight profiles: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.ListWeightProfiles(profiles))
+	})
+
+	e.GET("/weight-profiles/new", func(c echo.Context) error {
+		return RenderComponent(c, http.StatusOK, base.EditWeightProfile(&database.WeightProfile{}, nil, ""))
+	})
+
+	e.POST("/weight-profiles", func(c echo.Context) error {
+		db := scoutDB(c)
+		return saveWeightProfile(c, db, &database.WeightProfile{})
+	})
+
+	e.GET("/weight-profiles/:id", func(c echo.Context) error {
+		db := scoutDB(c)
+		profile, weights, err := weightProfileFromParam(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching weight profile: %w", err)
+		}
+		return RenderComponent(c, http.StatusOK, base.EditWeightProfile(profile, weights, ""))
+	})
+
+	e.POST("/weight-profiles/:id", func(c echo.Context) error {
+		db := scoutDB(c)
+		profile, _, err := weightProfileFromParam(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching weight profile: %w", err)
+		}
+		return saveWeightProfile(c, db, profile)
+	})
+
+	e.POST("/weight-profiles/:id/delete", func(c echo.Context) error {
+		db := scoutDB(c)
+		profile, _, err := weightProfileFromParam(c, db)
+		if err != nil {
+			return fmt.Errorf("fetching weight profile: %w", err)
+		}
+		if err := database.DeleteWeightProfile(db, profile.ID); err != nil {
+			return fmt.Errorf("deleting weight profile: %w", err)
+		}
+		return c.Redirect(http.StatusSeeOther, "/weight-profiles")
+	})
+}
+
+func weightProfileFromParam(c echo.Context, db *gorm.DB) (*database.WeightProfile, database.Weights, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	return database.WeightProfileByID(db, id)
+}
+
+// saveWeightProfile saves the submitted form to profile. Invalid submissions are shown again with an explanation.
+func saveWeightProfile(c echo.Context, db *gorm.DB, profile *database.WeightProfile) error {
+	profile.Name = c.FormValue("name")
+	profile.Description = c.FormValue("description")
+	weights := database.Weights{}
+	for _, attribute := range database.AllAttributes() {
+		value := strings.TrimSpace(c.FormValue(attribute.Key()))
+		if value == "" {
+			continue
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			l := localizer(c)
+			message := l.T("weights.notANumber", l.Attribute(attribute))
+			return RenderComponent(c, http.StatusUnprocessableEntity, base.EditWeightProfile(profile, weights, message))
+		}
+		weights[attribute] = weight
+	}
+
+	err := database.SaveWeightProfile(db, profile, weights)
+	var invalid *database.WeightProfileError
+	if errors.As(err, &invalid) {
+		message := weightProfileProblem(localizer(c), invalid)
+		return RenderComponent(c, http.StatusUnprocessableEntity, base.EditWeightProfile(profile, weights, message))
+	}
+	if err != nil {
+		return fmt.Errorf("saving weight profile: %w", err)
+	}
+	return c.Redirect(http.StatusSeeOther, "/weight-profiles/"+profile.ID.String())
+}
+
+// weightProfileProblem explains why a weight profile can't be saved in the Scout's language.
+func weightProfileProblem(l *i18n.Localizer, err *database.WeightProfileError) string {
+	switch err.Code {
+	case "nameTaken":
+		return l.T("weights.nameTaken", err.Name)
+	case "weightRange":
+		return l.T("weights.weightRange", l.Attribute(err.Attribute), database.MaxWeight)
+	}
+	return l.T("weights." + err.Code)
+}
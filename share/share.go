@@ -0,0 +1,85 @@
+// Package share signs the tokens of links that let people without an account view a player, and protects links with
+// passwords.
+//
+// A token is the ID of a database.ShareLink and the time it expires, followed by an HMAC-SHA256 of both under the
+// Scout's database.ShareKey. Tokens can't be guessed or altered to extend a link, and checking one doesn't need the
+// database. Revoking a link is recorded in the database, so tokens must still be checked against their ShareLink.
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidToken is returned for tokens that weren't signed with the key.
+var ErrInvalidToken = errors.New("invalid share token")
+
+// payloadSize is the size of a token's payload: a UUID followed by the expiry in Unix seconds.
+const payloadSize = 16 + 8
+
+var encoding = base64.RawURLEncoding
+
+// Sign returns the token of the link with the given ID that expires at the given time.
+func Sign(key []byte, id uuid.UUID, expires time.Time) string {
+	payload := make([]byte, payloadSize)
+	copy(payload, id[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(expires.Unix()))
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(mac(key, payload))
+}
+
+// Verify returns the link ID and expiry signed in token. Whether the link has expired is left to the caller, which
+// should record the attempt.
+func Verify(key []byte, token string) (uuid.UUID, time.Time, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, time.Time{}, ErrInvalidToken
+	}
+	payload, err := encoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != payloadSize {
+		return uuid.Nil, time.Time{}, ErrInvalidToken
+	}
+	signature, err := encoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(signature, mac(key, payload)) {
+		return uuid.Nil, time.Time{}, ErrInvalidToken
+	}
+	id, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.Nil, time.Time{}, ErrInvalidToken
+	}
+	return id, time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0), nil
+}
+
+func mac(key, payload []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// HashPassword returns the hash of a link's password to store in database.ShareLink.PasswordHash. An empty password
+// has an empty hash, i.e. the link doesn't ask for one.
+func HashPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password opens a link with the given password hash.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
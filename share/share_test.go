@@ -0,0 +1,54 @@
+package share
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSignVerify(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	id := uuid.New()
+	expires := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	token := Sign(key, id, expires)
+
+	gotID, gotExpires, err := Verify(key, token)
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if gotID != id || !gotExpires.Equal(expires) {
+		t.Errorf("Verify() = %s, %v, expected %s, %v", gotID, gotExpires, id, expires)
+	}
+
+	// Extending the link changes its payload, which the signature no longer matches.
+	extended := Sign(key, id, expires.AddDate(1, 0, 0))
+	forged := strings.Split(extended, ".")[0] + "." + strings.Split(token, ".")[1]
+	for name, invalid := range map[string]string{
+		"other key":  Sign([]byte("another key"), id, expires),
+		"forged":     forged,
+		"no mac":     strings.Split(token, ".")[0],
+		"truncated":  token[:len(token)-2],
+		"not base64": "!!!.!!!",
+		"empty":      "",
+	} {
+		if _, _, err := Verify(key, invalid); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%s) = %v, expected ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword() failed: %v", err)
+	}
+	if !CheckPassword(hash, "secret") || CheckPassword(hash, "guess") || CheckPassword(hash, "") {
+		t.Errorf("Expected only the right password to open the link")
+	}
+	if hash, err := HashPassword(""); err != nil || hash != "" || !CheckPassword(hash, "") {
+		t.Errorf("Expected links without a password to open without one, got %q (%v)", hash, err)
+	}
+}
@@ -0,0 +1,127 @@
+// Package team publishes players and analyses from a Scout's own database to the workspace of a database.Team.
+//
+// Published rows keep their IDs, so publishing a player again updates it in the workspace rather than adding it
+// twice. Every published Player and Analysis has a database.Publication that credits the Scout who published it.
+package team
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+// ErrPublishedByOther is returned when publishing an analysis that another Scout published, which only they may
+// change.
+var ErrPublishedByOther = errors.New("analysis was published by another scout")
+
+// Publisher is the Scout who publishes to a workspace.
+type Publisher struct {
+	// Scout is the hash identifying the Scout, see database.Scouts.Open.
+	Scout string
+	// Name is how the Scout is shown to the rest of the Team.
+	Name string
+}
+
+// Publish copies a player, their PlayerAnalysis and the analyses with the given IDs from a Scout's database to a
+// workspace, crediting the publisher. Analyses that aren't the player's are reported as gorm.ErrRecordNotFound. It
+// returns how many analyses were published.
+func Publish(from, to *gorm.DB, by Publisher, playerID uuid.UUID, analysisIDs []uuid.UUID, now time.Time) (int, error) {
+	player, err := database.PlayerByID(from, playerID)
+	if err != nil {
+		return 0, err
+	}
+	profile, err := database.PlayerAnalysisFor(from, playerID)
+	if err != nil {
+		return 0, err
+	}
+	analyses, err := selectAnalyses(from, playerID, analysisIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	err = to.Transaction(func(tx *gorm.DB) error {
+		publications, err := database.Publications(tx, database.PublishedAnalysis)
+		if err != nil {
+			return err
+		}
+		for _, a := range analyses {
+			if p, ok := publications[a.ID]; ok && p.Scout != by.Scout {
+				return fmt.Errorf("%w: analysis %s was published by %s", ErrPublishedByOther, a.ID, p.ScoutName)
+			}
+		}
+
+		// The IDs from the Scout's database are kept, rather than generated by BaseModel.BeforeCreate.
+		tx = tx.Session(&gorm.Session{SkipHooks: true})
+		if err := tx.Save(player).Error; err != nil {
+			return fmt.Errorf("publishing Player %s failed: %w", player.ID, err)
+		}
+		if profile != nil {
+			// A player has one PlayerAnalysis, so one published by someone else before is replaced.
+			if err := tx.Where("player_id = ? AND id <> ?", player.ID, profile.ID).Delete(&database.PlayerAnalysis{}).Error; err != nil {
+				return fmt.Errorf("replacing PlayerAnalysis of Player %s failed: %w", player.ID, err)
+			}
+			if err := tx.Save(profile).Error; err != nil {
+				return fmt.Errorf("publishing PlayerAnalysis of Player %s failed: %w", player.ID, err)
+			}
+		}
+		if err := credit(tx, player.ID, database.PublishedPlayer, by, now); err != nil {
+			return err
+		}
+		for _, a := range analyses {
+			for _, record := range a.Records() {
+				if err := tx.Save(record).Error; err != nil {
+					return fmt.Errorf("publishing Analysis %s failed: %w", a.ID, err)
+				}
+			}
+			if err := credit(tx, a.ID, database.PublishedAnalysis, by, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(analyses), nil
+}
+
+// selectAnalyses returns the player's analyses with the given IDs.
+func selectAnalyses(db *gorm.DB, playerID uuid.UUID, ids []uuid.UUID) ([]*database.AnalysisDetail, error) {
+	all, err := database.AnalysesForPlayer(db, playerID)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[uuid.UUID]*database.AnalysisDetail{}
+	for _, a := range all {
+		byID[a.ID] = a
+	}
+	selected := make([]*database.AnalysisDetail, 0, len(ids))
+	for _, id := range ids {
+		a, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("retrieving Analysis %s of Player %s failed: %w", id, playerID, gorm.ErrRecordNotFound)
+		}
+		selected = append(selected, a)
+	}
+	return selected, nil
+}
+
+// credit records that the Scout published a record, replacing whoever published it before.
+func credit(tx *gorm.DB, recordID uuid.UUID, kind database.PublicationKind, by Publisher, now time.Time) error {
+	publication := database.Publication{RecordID: recordID}
+	if err := tx.Where("record_id = ?", recordID).Limit(1).Find(&publication).Error; err != nil {
+		return fmt.Errorf("retrieving Publication of %s failed: %w", recordID, err)
+	}
+	if publication.ID == uuid.Nil {
+		publication.ID = uuid.New()
+	}
+	publication.Kind, publication.Scout, publication.ScoutName, publication.PublishedAt = kind, by.Scout, by.Name, now
+	if err := tx.Save(&publication).Error; err != nil {
+		return fmt.Errorf("crediting %s %s failed: %w", kind, recordID, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package team
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thirdknife/scoutingapp/database"
+	"gorm.io/gorm"
+)
+
+func TestPublish(t *testing.T) {
+	personal, workspace := database.CreateTestDB(t), database.CreateTestDB(t)
+	player := &database.Player{Name: "Published"}
+	if err := personal.Create(player).Error; err != nil {
+		t.Fatalf("Failed to create Player: %v", err)
+	}
+	if err := personal.Create(&database.PlayerAnalysis{PlayerID: player.ID, Club: "Rovers"}).Error; err != nil {
+		t.Fatalf("Failed to create PlayerAnalysis: %v", err)
+	}
+	tactical := &database.TacticalAnalysis{Vision: 8, Awareness: 7, MovementOffTheBall: 6}
+	if err := personal.Create(tactical).Error; err != nil {
+		t.Fatalf("Failed to create TacticalAnalysis: %v", err)
+	}
+	shared := &database.Analysis{PlayerID: player.ID, Date: "2024-01-01 15:00", TacticalAnalysisID: tactical.ID}
+	private := &database.Analysis{PlayerID: player.ID, Date: "2024-02-01 15:00"}
+	for _, a := range []*database.Analysis{shared, private} {
+		if err := personal.Create(a).Error; err != nil {
+			t.Fatalf("Failed to create Analysis: %v", err)
+		}
+	}
+
+	ann := Publisher{Scout: "ann", Name: "Ann"}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := Publish(personal, workspace, ann, player.ID, []uuid.UUID{uuid.New()}, now); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Publishing an unknown analysis returned %v, expected gorm.ErrRecordNotFound", err)
+	}
+	n, err := Publish(personal, workspace, ann, player.ID, []uuid.UUID{shared.ID}, now)
+	if err != nil {
+		t.Fatalf("Publish() failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 published analysis, got %d", n)
+	}
+
+	analyses, err := database.AnalysesForPlayer(workspace, player.ID)
+	if err != nil {
+		t.Fatalf("AnalysesForPlayer() failed: %v", err)
+	}
+	if len(analyses) != 1 || analyses[0].ID != shared.ID {
+		t.Fatalf("Expected only the published analysis in the workspace, got %+v", analyses)
+	}
+	if rating, ok := analyses[0].Rating(database.Attribute{Group: database.TacticalRatings, Name: "Vision"}); !ok || rating != 8 {
+		t.Errorf("Expected the ratings to be published, got %d", rating)
+	}
+	profile, err := database.PlayerAnalysisFor(workspace, player.ID)
+	if err != nil || profile == nil || profile.Club != "Rovers" {
+		t.Errorf("Expected the profile to be published, got %+v (%v)", profile, err)
+	}
+	publications, err := database.Publications(workspace, database.PublishedAnalysis)
+	if err != nil {
+		t.Fatalf("Publications() failed: %v", err)
+	}
+	if p := publications[shared.ID]; p == nil || p.ScoutName != "Ann" || !p.PublishedAt.Equal(now) {
+		t.Errorf("Expected the analysis to be credited to Ann, got %+v", p)
+	}
+
+	// Publishing again updates the workspace.
+	if err := personal.Model(player).Update("name", "Renamed").Error; err != nil {
+		t.Fatalf("Failed to update Player: %v", err)
+	}
+	if _, err := Publish(personal, workspace, ann, player.ID, []uuid.UUID{shared.ID}, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Publish() failed the second time: %v", err)
+	}
+	var players []*database.Player
+	if err := workspace.Find(&players).Error; err != nil {
+		t.Fatalf("Failed to retrieve Players: %v", err)
+	}
+	if len(players) != 1 || players[0].Name != "Renamed" {
+		t.Errorf("Expected publishing again to update the player, got %+v", players)
+	}
+
+	bo := Publisher{Scout: "bo", Name: "Bo"}
+	if _, err := Publish(personal, workspace, bo, player.ID, []uuid.UUID{shared.ID}, now); !errors.Is(err, ErrPublishedByOther) {
+		t.Errorf("Publishing another Scout's analysis returned %v, expected ErrPublishedByOther", err)
+	}
+}
@@ -0,0 +1,35 @@
+package views
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// CSRFFormField is the name of the hidden form field rendered by CSRFField.
+const CSRFFormField = "_csrf"
+
+// csrfHeader is the request header htmx uses to send the CSRF token.
+const csrfHeader = "X-CSRF-Token"
+
+// htmxConfig disables the htmx features that would need 'unsafe-inline' or 'unsafe-eval' in the
+// Content-Security-Policy.
+const htmxConfig = `{"includeIndicatorStyles":false,"allowEval":false,"allowScriptTags":false}`
+
+type csrfTokenKey struct{}
+
+// WithCSRFToken returns a copy of ctx that carries the CSRF token for the current request, so layout can
+// embed it into the page.
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfTokenKey{}, token)
+}
+
+func csrfToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey{}).(string)
+	return token
+}
+
+// csrfHeaders is the hx-headers value that makes htmx send the CSRF token with every request it issues.
+func csrfHeaders(ctx context.Context) string {
+	headers, _ := json.Marshal(map[string]string{csrfHeader: csrfToken(ctx)})
+	return string(headers)
+}
@@ -0,0 +1,292 @@
+package views
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/google/uuid"
+	db "github.com/thirdknife/scoutingapp/database"
+	"github.com/thirdknife/scoutingapp/i18n"
+	"github.com/thirdknife/scoutingapp/playerimport"
+	"github.com/thirdknife/scoutingapp/playerlist"
+)
+
+// t translates a message into the language of the current request. See i18n.Localizer.T.
+func t(ctx context.Context, key string, args ...any) string {
+	return i18n.FromContext(ctx).T(key, args...)
+}
+
+// languageTag is the BCP 47 tag of the language the page is rendered in.
+func languageTag(ctx context.Context) string {
+	return i18n.FromContext(ctx).Tag.String()
+}
+
+// positionName is how a position is displayed. Players without a recorded position have an empty PositionType.
+func positionName(ctx context.Context, p db.PositionType) string {
+	return i18n.FromContext(ctx).Position(p)
+}
+
+func groupName(ctx context.Context, g db.RatingGroup) string {
+	return i18n.FromContext(ctx).Group(g)
+}
+
+func categoryName(ctx context.Context, c db.AnalysisCategory) string {
+	return i18n.FromContext(ctx).Category(c)
+}
+
+func columnLabel(ctx context.Context, c playerlist.Column) string {
+	return t(ctx, "column."+string(c))
+}
+
+func numberText(ctx context.Context, f float64, decimals int) string {
+	return i18n.FromContext(ctx).Number(f, decimals)
+}
+
+func integerText(ctx context.Context, i int) string {
+	return i18n.FromContext(ctx).Integer(i)
+}
+
+// dateTimeText formats a date stored as yyyy-mm-dd hh:mm. Dates that can't be parsed are shown as they are.
+func dateTimeText(ctx context.Context, date string) string {
+	parsed, err := db.ParseDate(date)
+	if err != nil {
+		return date
+	}
+	return i18n.FromContext(ctx).DateTime(parsed)
+}
+
+// timeText formats a point in time, such as when a record was created.
+func timeText(ctx context.Context, t time.Time) string {
+	return i18n.FromContext(ctx).DateTime(t.Local())
+}
+
+// shareStatus says until when a share link can be viewed, or why it can't be anymore.
+func shareStatus(ctx context.Context, row ShareLinkRow) string {
+	switch {
+	case row.Link.RevokedAt != nil:
+		return t(ctx, "share.revoked", timeText(ctx, *row.Link.RevokedAt))
+	case !row.Active:
+		return t(ctx, "share.expired", timeText(ctx, row.Link.ExpiresAt))
+	}
+	return t(ctx, "share.expires", timeText(ctx, row.Link.ExpiresAt))
+}
+
+// scoutName is how a Scout is credited for their work.
+func scoutName(ctx context.Context, scout *db.Scout) string {
+	if scout == nil || scout.Username == "" {
+		return t(ctx, "unknown")
+	}
+	return scout.Username
+}
+
+// teamURL is the page of a Team's workspace.
+func teamURL(team *db.Team) templ.SafeURL {
+	return templ.URL("/teams/" + team.ID.String())
+}
+
+// teamAnalysesText counts a player's analyses in a Team's workspace, along with who published them.
+func teamAnalysesText(ctx context.Context, row TeamPlayerRow) string {
+	if row.Analyses == 0 {
+		return integerText(ctx, 0)
+	}
+	return t(ctx, "teams.analysesBy", row.Analyses, strings.Join(row.Scouts, ", "))
+}
+
+// publicationText credits whoever published a record, or is empty if nobody is credited.
+func publicationText(ctx context.Context, p *db.Publication) string {
+	if p == nil {
+		return ""
+	}
+	return t(ctx, "teams.publication", p.ScoutName, timeText(ctx, p.PublishedAt))
+}
+
+// syncVersion shows a version of a row in the conflict inbox: its JSON, indented, or that it was deleted.
+func syncVersion(ctx context.Context, row string) string {
+	if row == "" {
+		return t(ctx, "sync.deleted")
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(row), "", "  "); err != nil {
+		return row
+	}
+	return indented.String()
+}
+
+// birthdateText formats a player's birthdate. Birthdates that can't be parsed are shown as they are.
+func birthdateText(ctx context.Context, profile *db.PlayerAnalysis) string {
+	birthdate, err := profile.BirthdateTime()
+	if err != nil {
+		return profile.Birthdate
+	}
+	return i18n.FromContext(ctx).Date(birthdate)
+}
+
+// errorStatuses are the statuses with their own title and description on error pages. Others use a generic one.
+var errorStatuses = map[int]bool{400: true, 403: true, 404: true, 405: true, 410: true, 413: true, 500: true, 503: true}
+
+// errorMessage is the "title" or "description" of an error page for a status.
+func errorMessage(ctx context.Context, kind string, status int) string {
+	if errorStatuses[status] {
+		return t(ctx, fmt.Sprintf("error.%s.%d", kind, status))
+	}
+	return t(ctx, "error."+kind)
+}
+
+// importSampleRows is how many rows of a file are shown while mapping its columns.
+const importSampleRows = 3
+
+func importSamples(f *playerimport.File) [][]string {
+	return f.Records[:min(len(f.Records), importSampleRows)]
+}
+
+// importProblem explains why a row can't be imported.
+func importProblem(ctx context.Context, p playerimport.Problem) string {
+	return t(ctx, "import.problem."+p.Code, t(ctx, "import.field."+string(p.Field)), p.Value)
+}
+
+func playerURL(id uuid.UUID) templ.SafeURL {
+	return templ.URL("/players/" + id.String())
+}
+
+// weightProfileURL is where a profile's form is submitted. New profiles are posted to /weight-profiles.
+func weightProfileURL(p *db.WeightProfile) templ.SafeURL {
+	if p.ID == uuid.Nil {
+		return "/weight-profiles"
+	}
+	return templ.URL("/weight-profiles/" + p.ID.String())
+}
+
+// weightText is the value of a weight's form field, which is left empty for attributes without a weight.
+func weightText(weights db.Weights, a db.Attribute) string {
+	if weights[a] == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(weights[a], 'f', -1, 64)
+}
+
+// compareRadarURL is where an overlaid radar chart of the compared players can be downloaded.
+func compareRadarURL(form CompareForm, group string) templ.SafeURL {
+	query := url.Values{"players": form.PlayerIDs}
+	for key, value := range map[string]string{"from": form.From, "to": form.To, "category": form.Category} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return templ.URL("/compare/radar/" + group + "?" + query.Encode())
+}
+
+// trendURL is where a trend chart of a player can be downloaded. chart is "group/<group>" or
+// "attribute/<attribute key>".
+func trendURL(player *db.Player, chart string, window int) templ.SafeURL {
+	return templ.URL(fmt.Sprintf("/players/%s/trend/%s?window=%d", player.ID, chart, window))
+}
+
+// webcalURL is the calendar feed at calendarURL with the webcal scheme, which opens it in the Scout's calendar app.
+// templ.URL would reject the scheme, but calendarURL is built by the server.
+func webcalURL(calendarURL string) templ.SafeURL {
+	u, err := url.Parse(calendarURL)
+	if err != nil {
+		return ""
+	}
+	u.Scheme = "webcal"
+	return templ.SafeURL(u.String())
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func playerName(ctx context.Context, players map[uuid.UUID]*db.Player, id uuid.UUID) string {
+	if p, ok := players[id]; ok {
+		return p.Name
+	}
+	return t(ctx, "player.unknown")
+}
+
+// playerAge is the current age of a player, or an explanation of why it can't be computed.
+func playerAge(ctx context.Context, profile *db.PlayerAnalysis) string {
+	age, err := profile.Age(time.Now())
+	if err != nil {
+		return t(ctx, "unknown")
+	}
+	return integerText(ctx, age)
+}
+
+func playTime(ctx context.Context, minutes *int) string {
+	if minutes == nil {
+		return t(ctx, "playTime.notRecorded")
+	}
+	if *minutes == 0 {
+		return t(ctx, "playTime.bench")
+	}
+	return t(ctx, "playTime.minutes", *minutes)
+}
+
+// groupLabel is the heading used for a group of ratings. Defender, midfielder and forward ratings are all
+// positional ratings.
+func groupLabel(ctx context.Context, g db.RatingGroup) string {
+	if g.IsPositional() {
+		return t(ctx, "group.positional", groupName(ctx, g))
+	}
+	return groupName(ctx, g)
+}
+
+// attributeLabel is how an attribute is displayed.
+func attributeLabel(ctx context.Context, a db.Attribute) string {
+	return i18n.FromContext(ctx).Attribute(a)
+}
+
+// scoreText formats a score computed by the scoring package. Players without a score haven't been rated.
+func scoreText(ctx context.Context, score *float64) string {
+	if score == nil {
+		return "-"
+	}
+	return numberText(ctx, *score, 1)
+}
+
+// ratingText formats a rating. Ratings are whole numbers from 0 to 10, which are written the same in every language.
+func ratingText(rating int, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return strconv.Itoa(rating)
+}
+
+// sortIndicator marks the column the players table is sorted by with the direction of the sort.
+func sortIndicator(q playerlist.Query, c playerlist.Column) string {
+	switch {
+	case q.Sort != c:
+		return ""
+	case q.Descending:
+		return " ▼"
+	}
+	return " ▲"
+}
+
+func ageText(ctx context.Context, age *int) string {
+	if age == nil {
+		return t(ctx, "unknown")
+	}
+	return integerText(ctx, *age)
+}
+
+// playersShown summarises which players are on a page of the players table.
+func playersShown(ctx context.Context, page playerlist.Page) string {
+	if page.Total == 0 {
+		return t(ctx, "players.none")
+	}
+	first := (page.Query.Page-1)*page.Query.PageSize + 1
+	return t(ctx, "players.shown", first, first+len(page.Rows)-1, page.Total)
+}